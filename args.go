@@ -0,0 +1,282 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/heydabop/disgo/discord"
+	"github.com/heydabop/disgo/timeparse"
+)
+
+type argKind int
+
+const (
+	argUser     argKind = iota //mention, or fuzzy username if mentionOnly isn't set
+	argChannel                 //channel mention
+	argInt                     //integer between min and max
+	argAmount                  //amount of money, at least minAmount
+	argDuration                //"2 weeks and 3 days", "1h30m"
+	argText                    //everything left on the line
+)
+
+type argSpec struct {
+	name        string
+	kind        argKind
+	optional    bool
+	mentionOnly bool
+	flag        string //given anywhere after this marker, like "--order 4" or "about pizza", instead of by position; argInt or argText, which runs to the next marker
+	min, max    int
+	def         int
+	minAmount   float64
+}
+
+// argCommandFunc is a command handler that receives arguments already parsed against its command's args spec
+type argCommandFunc func(discord.Session, string, string, string, string, commandArgs) (string, error)
+
+type commandArgs struct {
+	raw    []string
+	values map[string]interface{}
+}
+
+var (
+	mentionArg          = argSpec{name: "@user", kind: argUser, mentionOnly: true}
+	minutesArg          = argSpec{name: "minutes", kind: argInt, optional: true, min: 1, max: 525600, def: 5}
+	usernameArg         = argSpec{name: "username", kind: argUser}
+	optionalUsernameArg = argSpec{name: "username", kind: argUser, optional: true}
+	reasonArg           = argSpec{name: "reason", kind: argText, optional: true}
+
+	channelIDRegex = regexp.MustCompile(`^<#(\d+)>$`)
+	roleIDRegex    = regexp.MustCompile(`^<@&(\d+)>$`)
+)
+
+func (a commandArgs) has(name string) bool {
+	_, found := a.values[name]
+	return found
+}
+
+func (a commandArgs) user(name string) string {
+	userID, _ := a.values[name].(string)
+	return userID
+}
+
+func (a commandArgs) channel(name string) string {
+	chanID, _ := a.values[name].(string)
+	return chanID
+}
+
+func (a commandArgs) int(name string) int {
+	value, _ := a.values[name].(int)
+	return value
+}
+
+func (a commandArgs) amount(name string) float64 {
+	value, _ := a.values[name].(float64)
+	return value
+}
+
+func (a commandArgs) duration(name string) timeparse.Duration {
+	value, _ := a.values[name].(timeparse.Duration)
+	return value
+}
+
+func (a commandArgs) text(name string) string {
+	value, _ := a.values[name].(string)
+	return value
+}
+
+// argUsage builds a usage string like "[username] [number (optional)]" from specs
+func argUsage(specs []argSpec) string {
+	parts := make([]string, len(specs))
+	for i, spec := range specs {
+		if spec.flag != "" && spec.kind == argInt {
+			parts[i] = fmt.Sprintf("[%s n (optional)]", spec.flag)
+		} else if spec.flag != "" {
+			parts[i] = fmt.Sprintf("[%s %s (optional)]", spec.flag, spec.name)
+		} else if spec.optional {
+			parts[i] = fmt.Sprintf("[%s (optional)]", spec.name)
+		} else {
+			parts[i] = fmt.Sprintf("[%s]", spec.name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// intArg checks that token is a whole number within spec's bounds
+func intArg(spec argSpec, token string) (int, error) {
+	value, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a whole number", spec.name)
+	}
+	if value < spec.min || value > spec.max {
+		return 0, fmt.Errorf("%s must be between %d and %d", spec.name, spec.min, spec.max)
+	}
+	return value, nil
+}
+
+// parseFlags takes the args in specs given after their markers out of tokens, returning what's left for the positional args
+func parseFlags(args commandArgs, specs []argSpec, tokens []string) ([]string, error) {
+	flags := make(map[string]argSpec)
+	for _, spec := range specs {
+		if spec.flag != "" {
+			flags[spec.flag] = spec
+			if spec.kind == argInt {
+				args.values[spec.name] = spec.def
+			}
+		}
+	}
+	if len(flags) == 0 {
+		return tokens, nil
+	}
+	positional := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		spec, found := flags[strings.ToLower(tokens[i])]
+		if !found {
+			positional = append(positional, tokens[i])
+			continue
+		}
+		if spec.kind == argText {
+			end := i + 1
+			for ; end < len(tokens); end++ {
+				if _, found := flags[strings.ToLower(tokens[end])]; found {
+					break
+				}
+			}
+			if end == i+1 {
+				return nil, fmt.Errorf("%s needs a %s", spec.flag, spec.name)
+			}
+			args.values[spec.name] = strings.Join(tokens[i+1:end], " ")
+			i = end - 1
+			continue
+		}
+		if i+1 == len(tokens) {
+			return nil, fmt.Errorf("%s needs a value", spec.flag)
+		}
+		value, err := intArg(spec, tokens[i+1])
+		if err != nil {
+			return nil, err
+		}
+		args.values[spec.name] = value
+		i++
+	}
+	return positional, nil
+}
+
+// parseArgs matches tokens against specs in order, resolving users, channels, numbers and durations
+func parseArgs(session discord.Session, chanID string, specs []argSpec, tokens []string) (commandArgs, error) {
+	args := commandArgs{raw: tokens, values: make(map[string]interface{}, len(specs))}
+	tokens, err := parseFlags(args, specs, tokens)
+	if err != nil {
+		return args, err
+	}
+	i := 0
+	for n, spec := range specs {
+		if spec.flag != "" {
+			continue
+		}
+		if i >= len(tokens) {
+			if !spec.optional {
+				return args, fmt.Errorf("Missing %s", spec.name)
+			}
+			if spec.kind == argInt {
+				args.values[spec.name] = spec.def
+			}
+			continue
+		}
+		token := tokens[i]
+		switch spec.kind {
+		case argUser:
+			if match := userIDRegex.FindStringSubmatch(token); match != nil {
+				args.values[spec.name] = match[1]
+				i++
+				break
+			}
+			if spec.mentionOnly {
+				return args, fmt.Errorf("%s must be a mention", spec.name)
+			}
+			//usernames can contain spaces, so take every remaining token unless something else still needs one
+			end := len(tokens)
+			for _, later := range specs[n+1:] {
+				if !later.optional && later.flag == "" {
+					end = i + 1
+					break
+				}
+			}
+			userID, err := getMostSimilarUserID(session, chanID, strings.Join(tokens[i:end], " "))
+			if err != nil {
+				return args, err
+			}
+			args.values[spec.name] = userID
+			i = end
+		case argChannel:
+			match := channelIDRegex.FindStringSubmatch(token)
+			if match == nil {
+				return args, fmt.Errorf("%s must be a channel mention", spec.name)
+			}
+			args.values[spec.name] = match[1]
+			i++
+		case argInt:
+			if _, err := strconv.Atoi(token); err != nil && spec.optional {
+				args.values[spec.name] = spec.def
+				break
+			}
+			value, err := intArg(spec, token)
+			if err != nil {
+				return args, err
+			}
+			args.values[spec.name] = value
+			i++
+		case argAmount:
+			value, err := strconv.ParseFloat(token, 64)
+			if err != nil {
+				if spec.optional {
+					break
+				}
+				return args, fmt.Errorf("%s must be a number", spec.name)
+			}
+			if value < spec.minAmount {
+				return args, fmt.Errorf("%s must be at least %g", spec.name, spec.minAmount)
+			}
+			args.values[spec.name] = value
+			i++
+		case argDuration:
+			d, used, err := timeparse.ParseDuration(tokens[i:])
+			if err != nil {
+				if spec.optional {
+					break
+				}
+				return args, fmt.Errorf("%s must be a duration like 5 minutes or 2h30m", spec.name)
+			}
+			args.values[spec.name] = d
+			i += used
+		case argText:
+			args.values[spec.name] = strings.Join(tokens[i:], " ")
+			i = len(tokens)
+		}
+	}
+	if i < len(tokens) {
+		return args, fmt.Errorf("Unexpected %s", tokens[i])
+	}
+	return args, nil
+}
+
+// limitArg is the optional "how many results" argument shared by the leaderboard commands
+func limitArg(def int) argSpec {
+	return argSpec{name: "number", kind: argInt, optional: true, min: 0, max: 100, def: def}
+}
+
+// execute runs cmd, first parsing args against cmd's spec if it has one; prefix is how it was invoked, for usage errors
+func (cmd *command) execute(session discord.Session, prefix, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	if !canRunCommand(session, guildID, chanID, authorID, cmd) {
+		return unauthorizedReply, nil
+	}
+	if cmd.runArgs == nil {
+		return cmd.run(session, guildID, chanID, authorID, messageID, args)
+	}
+	parsed, err := parseArgs(session, chanID, cmd.args, args)
+	if err != nil {
+		return "", fmt.Errorf("%s. Usage: %s%s %s", err.Error(), prefix, cmd.name, cmd.usage)
+	}
+	return cmd.runArgs(session, guildID, chanID, authorID, messageID, parsed)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+)
+
+type commandCategory string
+
+const (
+	categoryStats      commandCategory = "stats"
+	categoryEconomy    commandCategory = "economy"
+	categoryGames      commandCategory = "games"
+	categoryModeration commandCategory = "moderation"
+	categoryFun        commandCategory = "fun"
+	categoryUtility    commandCategory = "utility"
+)
+
+var (
+	categoryOrder  = []commandCategory{categoryStats, categoryEconomy, categoryGames, categoryModeration, categoryFun, categoryUtility}
+	categoryTitles = map[commandCategory]string{
+		categoryStats:      "Stats",
+		categoryEconomy:    "Economy",
+		categoryGames:      "Games",
+		categoryModeration: "Moderation",
+		categoryFun:        "Fun",
+		categoryUtility:    "Utility",
+	}
+)
+
+type command struct {
+	name        string
+	aliases     []string
+	usage       string
+	description string
+	examples    []string
+	category    commandCategory
+	permission  int64 //any one of these permission bits (see effectivePermissions) allows the command, 0 allows everyone
+	ownerOnly   bool  //only the configured admin may run the command
+	noTyping    bool  //don't send ChannelTyping before running
+	hidden      bool  //left out of help
+	args        []argSpec
+	run         commandFunc
+	runArgs     argCommandFunc //used instead of run when args is set
+}
+
+var (
+	commandList    []*command
+	commandsByName = make(map[string]*command)
+)
+
+func registerCommand(cmd *command) {
+	if len(cmd.usage) == 0 && len(cmd.args) > 0 {
+		cmd.usage = argUsage(cmd.args)
+	}
+	for _, name := range append([]string{cmd.name}, cmd.aliases...) {
+		if existing, found := commandsByName[name]; found {
+			panic(fmt.Sprintf("command name %s registered by both %s and %s", name, existing.name, cmd.name))
+		}
+		commandsByName[name] = cmd
+	}
+	commandList = append(commandList, cmd)
+}
+
+// unregisterCommand removes name and its aliases from the registry
+func unregisterCommand(name string) {
+	cmd, found := commandsByName[name]
+	if !found {
+		return
+	}
+	for _, alias := range append([]string{cmd.name}, cmd.aliases...) {
+		delete(commandsByName, alias)
+	}
+	for i, listed := range commandList {
+		if listed == cmd {
+			commandList = append(commandList[:i], commandList[i+1:]...)
+			break
+		}
+	}
+}
+
+func lookupCommand(name string) (*command, bool) {
+	cmd, found := commandsByName[strings.ToLower(name)]
+	return cmd, found
+}
+
+// commandDisabled reports whether cmd is turned off in guildID
+func commandDisabled(guildID string, cmd *command) bool {
+	return getGuildSettings(guildID).disables(cmd)
+}
+
+func commandSummary(cmd *command) string {
+	line := "**" + cmd.name + "**"
+	if len(cmd.usage) > 0 {
+		line += " " + cmd.usage
+	}
+	if len(cmd.description) > 0 {
+		line += " - " + cmd.description
+	}
+	return line
+}
+
+// commandHelp describes a single command in full: usage, aliases and examples
+func commandHelp(cmd *command) string {
+	help := fmt.Sprintf("**/%s** %s\n", cmd.name, cmd.usage)
+	if len(cmd.description) > 0 {
+		help += cmd.description + "\n"
+	}
+	if len(cmd.aliases) > 0 {
+		help += "Aliases: /" + strings.Join(cmd.aliases, ", /") + "\n"
+	}
+	if cmd.ownerOnly {
+		help += "Restricted to the bot owner\n"
+	} else if cmd.permission != 0 {
+		help += "Restricted to moderators\n"
+	}
+	if len(cmd.examples) > 0 {
+		help += "Examples:\n`/" + strings.Join(cmd.examples, "`\n`/") + "`\n"
+	}
+	return help
+}
+
+// helpLines lists every command userID can run, grouped under category headings.
+// If category is non-empty only that category is listed.
+func helpLines(session discord.Session, guildID, chanID, userID string, category commandCategory) []string {
+	var lines []string
+	for _, cat := range categoryOrder {
+		if len(category) > 0 && cat != category {
+			continue
+		}
+		var catLines []string
+		for _, cmd := range commandList {
+			if cmd.category != cat || cmd.hidden || commandDisabled(guildID, cmd) || !canRunCommand(session, guildID, chanID, userID, cmd) {
+				continue
+			}
+			catLines = append(catLines, commandSummary(cmd))
+		}
+		if len(catLines) > 0 {
+			lines = append(lines, "__**"+categoryTitles[cat]+"**__")
+			lines = append(lines, catLines...)
+		}
+	}
+	if len(category) == 0 {
+		lines = append(lines, "", "Use /help <command> for details on a command or /help <category> for just that category")
+	}
+	return lines
+}
+
+func init() {
+	registerCommand(&command{name: "8ball", category: categoryFun, description: "answers yes or no questions, also triggered by asking me a question", usage: "[question]", run: eightball})
+	registerCommand(&command{name: "activity", category: categoryStats, description: "shows messages per hour over lifetime of channel", args: []argSpec{optionalUsernameArg}, runArgs: activity})
+	registerCommand(&command{name: "activityday", category: categoryStats, description: "shows messages per day of the week over lifetime of channel", args: []argSpec{optionalUsernameArg}, runArgs: activityDay})
+	registerCommand(&command{name: "age", category: categoryStats, description: "displays how long <username> has been in this server", args: []argSpec{usernameArg}, runArgs: age})
+	registerCommand(&command{name: "ascii", category: categoryFun, run: ascii})
+	registerCommand(&command{name: "ayy", category: categoryFun, run: ayy})
+	registerCommand(&command{name: "bet", category: categoryGames, description: "place a roulette bet (type /bet for more help)", examples: []string{"bet 0.5 single 13", "bet 1 corner 25 26 28 29", "bet 2.2 column 2"}, usage: "[amount] [bet type] [spaces...]", noTyping: true, run: bet})
+	registerCommand(&command{name: "bitrate", category: categoryStats, description: "shows voice channels and their bitrates", run: bitrate})
+	registerCommand(&command{name: "botuptime", category: categoryUtility, description: "shows time since bot last started", run: botuptime})
+	registerCommand(&command{name: "color", category: categoryFun, description: "generates a solid image of given color", examples: []string{"color #ff8800", "color 0af"}, usage: "[hex color code]", run: color})
+	registerCommand(&command{name: "config", category: categoryModeration, description: "shows or changes this server's prefix, disabled commands and categories, bot reactions, mod roles, how mutes work, and the timeout channel", examples: []string{"config prefix !", "config disable fun", "config enable roll", "config reactions off", "config modrole add disgo-mod", "config mute role Muted", "config timeout create"}, usage: "[prefix|disable|enable|reactions|modrole|mute|timeout (optional)] [value (optional)]", noTyping: true, run: config})
+	registerCommand(&command{name: "countdown", category: categoryFun, description: "days until one of this server's countdowns, or how far along it is if it has a start, or adds, removes or lists them", examples: []string{"countdown christmas", "countdown add christmas dec 25 every year", "countdown add retirement 2016-01-11 09:30 to 2048-02-28 16:00", "countdown list", "countdown remove christmas"}, usage: countdownUsage, noTyping: true, run: countdown})
+	registerCommand(&command{name: "cputemp", category: categoryUtility, description: "displays CPU temperature", run: cputemp})
+	registerCommand(&command{name: "cwc", category: categoryFun, description: "alias for /spam cwc2016", run: cwc})
+	registerCommand(&command{name: "define", category: categoryUtility, description: "defines a word", usage: "[word]", run: define})
+	registerCommand(&command{name: "delete", category: categoryUtility, description: "deletes last message sent by bot (if you caused it)", noTyping: true, run: deleteLastMessage})
+	registerCommand(&command{name: "dolphin", category: categoryUtility, description: "links to Dolphin emulator files", run: dolphin})
+	registerCommand(&command{name: "downvote", category: categoryStats, description: "downvotes user, also triggered by @[user]--", examples: []string{"downvote @user"}, noTyping: true, args: []argSpec{mentionArg}, runArgs: downvote})
+	registerCommand(&command{name: "forsen", category: categoryFun, description: "alias for /spam forsenlol", run: forsen})
+	registerCommand(&command{name: "fortune", category: categoryFun, description: `get a "fortune"`, run: fortune})
+	registerCommand(&command{name: "gameactivity", category: categoryStats, description: "shows played hours per hour of <game> (or all games if none provided) over lifetime of channel", usage: "[game (optional)]", run: gameactivity})
+	registerCommand(&command{name: "gif", category: categoryFun, description: "looks for an embedded or linked image in the last 10 messages and reuploads it with modern GIF® compression", run: giffy})
+	registerCommand(&command{name: "give", category: categoryEconomy, description: "gives <amount> of your money to <user>", examples: []string{"give @user 2.5"}, args: []argSpec{mentionArg, {name: "amount", kind: argAmount, minAmount: 0.1}}, runArgs: give})
+	registerCommand(&command{name: "greentext", category: categoryFun, description: "makes greentext with a couple messages from the channel's history", args: []argSpec{optionalUsernameArg}, runArgs: greentext})
+	registerCommand(&command{name: "guess", aliases: []string{"g"}, category: categoryGames, description: "guesses a letter in the current hangman game", examples: []string{"guess e"}, usage: "[letter]", run: guess})
+	registerCommand(&command{name: "hangman", category: categoryGames, description: "starts a game of hangman in this channel", run: hangmanCmd})
+	registerCommand(&command{name: "help", aliases: []string{"command", "commands"}, category: categoryUtility, usage: "[command or category (optional)]", description: "DMs you the list of commands you can run, or shows details for one command or category", examples: []string{"help", "help remindme", "help games"}, noTyping: true, run: help})
+	registerCommand(&command{name: "ignore", category: categoryModeration, description: "ignores commands from <user> for <minutes> (default 5)", examples: []string{"ignore @user 10", "ignore @user 10 spamming /spin"}, permission: discordgo.PermissionModerateMembers, noTyping: true, args: []argSpec{mentionArg, minutesArg, reasonArg}, runArgs: ignore})
+	registerCommand(&command{name: "invite", category: categoryUtility, description: "link to invite me to another server", run: invite})
+	registerCommand(&command{name: "jobs", category: categoryUtility, description: "lists my scheduled jobs and when they next run", ownerOnly: true, run: listJobs})
+	registerCommand(&command{name: "jpg", category: categoryFun, description: "looks for an embedded or linked image in the last 10 messages and reuploads it with modern JPEG™ compression", run: jpg})
+	registerCommand(&command{name: "kickme", aliases: []string{"kms"}, category: categoryFun, description: "kicks you from the server and DMs you an invite back", run: kickme})
+	registerCommand(&command{name: "lastmessage", category: categoryStats, description: "displays when <username> last sent a message", args: []argSpec{usernameArg}, runArgs: lastUserMessage})
+	registerCommand(&command{name: "lastplayed", category: categoryStats, description: "displays game last played by <username>", args: []argSpec{usernameArg}, runArgs: lastPlayed})
+	registerCommand(&command{name: "lastseen", category: categoryStats, description: "displays when <username> was last seen", args: []argSpec{usernameArg}, runArgs: lastseen})
+	registerCommand(&command{name: "lirik", category: categoryFun, description: "alias for /spam lirik", run: lirik})
+	registerCommand(&command{name: "math", category: categoryUtility, description: "does math", usage: "[math stuff]", run: maths})
+	registerCommand(&command{name: "meme", category: categoryFun, description: "random meme from channel history", run: meme})
+	registerCommand(&command{name: "messages", category: categoryStats, description: "displays how many messages have been sent in this channel", run: totalMessages})
+	registerCommand(&command{name: "modlog", category: categoryModeration, description: "shows the ignores, mutes and timeouts given to <user>", examples: []string{"modlog @user"}, permission: discordgo.PermissionModerateMembers, args: []argSpec{mentionArg}, runArgs: modlog})
+	registerCommand(&command{name: "money", category: categoryEconomy, description: "displays top <number> users and their money", examples: []string{"money 10"}, args: []argSpec{limitArg(5)}, runArgs: money})
+	registerCommand(&command{name: "mute", category: categoryModeration, description: "mutes <user> for <minutes> (default 5), however config mute says to", examples: []string{"mute @user 10", "mute @user 10 calm down"}, permission: discordgo.PermissionModerateMembers, noTyping: true, args: []argSpec{mentionArg, minutesArg, reasonArg}, runArgs: mute})
+	registerCommand(&command{name: "nest", category: categoryUtility, description: "graphs today's thermostat log", run: nest})
+	registerCommand(&command{name: "ooer", aliases: []string{"zalgo"}, category: categoryFun, description: "Ǫ̧̩͟͜H̝̼ ̡̳͖͑̇M̔́Aͤ̓Ńͮ ̛̔ͯ͌ͪĮ̷̒̀͠ ͦ͋̐̾͡Ḁ̶͗ͪ͡Mͧͪ ̧ͩN̴̫̳̚͢Ǫ͈̬̫̏T̢̟̭͎͈ ̷̳̜̦͆G̵͛O̿́O̯͇̎̋͝D͖̈ ̼̰W͙̦̿͞͝I̛̮̊ͦ̚T̘͑H̨͎̲̑͢ ̢̗͍̟̽C̀ͯ͊̀͡O̷͈ͯ͌ͅM̓̓P̢̬̋̃͊U̜̱̓͡͞T̀̇Ě̷R̈̎ ̨̭ͭ̿͠P̳ͯͩ̎͟Ľ̳͏̨̩Ž̯ ͇̜Ť̤̻͖͜O̤̲҉̑ͯ ͤ͊H̢̼̿͆ͥḀ̢̢ͮ̊L̫͈̳̪̀P̶̯͆̾͟", usage: "[message]", run: ooer})
+	registerCommand(&command{name: "pee", category: categoryFun, description: "logs that you peed", noTyping: true, run: pee})
+	registerCommand(&command{name: "peecounter", category: categoryStats, description: "displays pee counts in the last 24 hours", run: peeCounter})
+	registerCommand(&command{name: "permission", category: categoryUtility, description: "prints my permissions in this channel to the log", noTyping: true, hidden: true, run: permission})
+	registerCommand(&command{name: "ping", category: categoryUtility, description: "displays ping to discordapp.com", run: ping})
+	registerCommand(&command{name: "playing", category: categoryUtility, description: "sets the game I'm playing", usage: "[game]", ownerOnly: true, run: playing})
+	registerCommand(&command{name: "playtime", category: categoryStats, description: "shows up to <number> summated (probably incorrect) playtimes in hours of every game across all users, or top 10 games of <username>", examples: []string{"playtime 5", "playtime @user"}, args: []argSpec{limitArg(10), optionalUsernameArg}, runArgs: playtime})
+	registerCommand(&command{name: "poop", aliases: []string{"poo"}, category: categoryFun, run: poop})
+	registerCommand(&command{name: "recentplaytime", category: categoryStats, description: "same as playtime but with a duration (like remindme) before normal args, calculates only as far back as duration", examples: []string{"recentplaytime 2 weeks 5", "recentplaytime 1 day @user"}, args: []argSpec{{name: "duration", kind: argDuration}, limitArg(10), optionalUsernameArg}, runArgs: recentPlaytime})
+	registerCommand(&command{name: "remindme", category: categoryUtility, description: "mentions you with <x> after <duration>, at <time>, or every <duration>, start with dm to get it in a DM (see /reminders to list, cancel and snooze them)", examples: []string{"remindme in 5 hours 10 minutes to order a pizza", "remindme tomorrow at 5pm to call mom", "remindme next friday to pay rent", "remindme on dec 24 at 18:00 to wrap presents", "remindme at 2024-12-24T18:30:00+01:00 to make a clever xd facebook status", "remindme every day at 9am to stretch", "remindme dm every 2 weeks to water the plants"}, usage: "[dm (optional)] in [duration] to [x] OR [time] to [x] OR every [duration] at [time of day (optional)] to [x]", run: remindme})
+	registerCommand(&command{name: "reminders", category: categoryUtility, description: "lists your pending reminders, or cancels or snoozes one by its #", examples: []string{"reminders", "reminders cancel 12", "reminders snooze 12 10m"}, usage: "[cancel|snooze (optional)] [id (optional)] [duration (optional)]", noTyping: true, run: reminders})
+	registerCommand(&command{name: "rename", category: categoryFun, description: "renames bot", usage: "[new username]", noTyping: true, run: rename})
+	registerCommand(&command{name: "roll", category: categoryGames, description: `"rolls" <x> dice with <y> sides, or rolls 1-100 if no dice are specified`, examples: []string{"roll", "roll 20", "roll 3d6+2"}, usage: "[x]d[y]", run: roll})
+	registerCommand(&command{name: "roulette", aliases: []string{"spin"}, category: categoryGames, description: "spin roulette wheel", run: roulette})
+	registerCommand(&command{name: "serverage", category: categoryStats, description: "displays how long ago this server was created", run: serverAge})
+	registerCommand(&command{name: "servers", category: categoryStats, description: "displays how many servers I'm in", run: totalServers})
+	registerCommand(&command{name: "soda", category: categoryFun, description: "alias for /spam sodapoppin", run: soda})
+	registerCommand(&command{name: "source", category: categoryUtility, description: "link to bot source code on github", run: source})
+	registerCommand(&command{name: "spam", category: categoryFun, description: "generates a messages based on logs from <streamer>, shows all streamer logs if no streamer is specified", usage: "[streamer (optional)]", run: spam})
+	registerCommand(&command{name: "spamdiscord", category: categoryFun, description: "generates a message based on logs from this server", examples: []string{"spamdiscord", "spamdiscord about pizza", "spamdiscord --order 4 --min 10"}, args: spamArgs(1), runArgs: spamdiscord})
+	registerCommand(&command{name: "spamdiscord2", category: categoryFun, description: `generates a message based on logs from this server, less "creative" than spamdiscord but generally less nonsense`, examples: []string{"spamdiscord2", "spamdiscord2 about pizza", "spamdiscord2 --order 4 --min 10"}, args: spamArgs(2), runArgs: spamdiscord})
+	registerCommand(&command{name: "spamdiscord3", category: categoryFun, description: `generates a message based on logs from this server, less "creative" than spamdiscord2 but more likely to repeat someone`, examples: []string{"spamdiscord3", "spamdiscord3 about pizza", "spamdiscord3 --order 4 --min 10"}, args: spamArgs(3), runArgs: spamdiscord})
+	registerCommand(&command{name: "spamuser", category: categoryFun, description: "generates a message based on discord logs of <username>", examples: []string{"spamuser @user", "spamuser @user about pizza", "spamuser @user --order 4 --max 12"}, args: append([]argSpec{usernameArg}, spamArgs(1)...), runArgs: spamuser})
+	registerCommand(&command{name: "spamuser2", category: categoryFun, description: `generates a message based on discord logs of <username>, less "creative" than spamuser but generally less nonsense`, examples: []string{"spamuser2 @user", "spamuser2 @user about pizza", "spamuser2 @user --order 4 --max 12"}, args: append([]argSpec{usernameArg}, spamArgs(2)...), runArgs: spamuser})
+	registerCommand(&command{name: "spamuser3", category: categoryFun, description: `generates a message based on discord logs of <username>, less "creative" than spamuser2 but more likely to repeat them`, examples: []string{"spamuser3 @user", "spamuser3 @user about pizza", "spamuser3 @user --order 4 --max 12"}, args: append([]argSpec{usernameArg}, spamArgs(3)...), runArgs: spamuser})
+	registerCommand(&command{name: "speedtest", category: categoryUtility, description: "runs a speedtest from my server", ownerOnly: true, run: speedtest})
+	registerCommand(&command{name: "superooer", category: categoryFun, description: "like ooer but more", usage: "[message]", run: superooer})
+	registerCommand(&command{name: "time", category: categoryUtility, description: "shows <username>'s local time, or the local time of everyone online here who's set a timezone with /tz", examples: []string{"time", "time @user"}, args: []argSpec{optionalUsernameArg}, runArgs: timeCmd})
+	registerCommand(&command{name: "timeout", category: categoryModeration, description: "keeps <user> in the timeout channel for <duration> (default 30 seconds)", examples: []string{"timeout @user", "timeout @user 5m", "timeout @user 10 minutes stop yelling"}, permission: discordgo.PermissionVoiceMoveMembers, noTyping: true, args: []argSpec{mentionArg, {name: "duration", kind: argDuration, optional: true}, reasonArg}, runArgs: timeout})
+	registerCommand(&command{name: "top", category: categoryStats, description: "displays top <number> users sorted by messages sent", examples: []string{"top 10"}, args: []argSpec{limitArg(5)}, runArgs: top})
+	registerCommand(&command{name: "topcommand", category: categoryStats, description: "displays who has issued <command> most", examples: []string{"topcommand spamuser"}, usage: "[command]", run: topcommand})
+	registerCommand(&command{name: "topemoji", category: categoryStats, description: "displays top <number> emojis sorted by times used", args: []argSpec{limitArg(10)}, runArgs: topEmoji})
+	registerCommand(&command{name: "toplength", category: categoryStats, description: "displays top <number> users sorted by average words/message", args: []argSpec{limitArg(5)}, runArgs: topLength})
+	registerCommand(&command{name: "toponline", category: categoryStats, description: "shows the maximum number of people that were ever simultaneously online", run: topOnline})
+	registerCommand(&command{name: "topquote", category: categoryStats, description: `displays top <number> of "quotes" from bot spam, sorted by votes from /upquote`, args: []argSpec{limitArg(5)}, runArgs: topquote})
+	registerCommand(&command{name: "track", category: categoryUtility, description: "displays current status of shipment and mentions you upon delivery", examples: []string{"track usps 9400100000000000000000"}, usage: "[carrier] [tracking number]", run: track})
+	registerCommand(&command{name: "tz", aliases: []string{"timezone"}, category: categoryUtility, description: "shows your timezone, or sets it for /time, reminders and activity charts", examples: []string{"tz", "tz set Europe/Oslo", "tz clear"}, usage: "[set|clear (optional)] [zone (optional)]", noTyping: true, run: tz})
+	registerCommand(&command{name: "unignore", category: categoryModeration, description: "stops ignoring <user>", permission: discordgo.PermissionModerateMembers, noTyping: true, args: []argSpec{mentionArg}, runArgs: unignore})
+	registerCommand(&command{name: "unmute", category: categoryModeration, description: "unmutes <user>", permission: discordgo.PermissionModerateMembers, noTyping: true, args: []argSpec{mentionArg}, runArgs: unmute})
+	registerCommand(&command{name: "updateavatar", category: categoryUtility, description: "sets my avatar to avatar.png", ownerOnly: true, run: updateAvatar})
+	registerCommand(&command{name: "upquote", aliases: []string{"uq"}, category: categoryFun, description: "upvotes last statement generated by /spamuser or /spamdiscord", noTyping: true, run: upquote})
+	registerCommand(&command{name: "uptime", category: categoryUtility, description: "displays bot's server uptime and load", run: uptime})
+	registerCommand(&command{name: "upvote", category: categoryStats, description: "upvotes user, also triggered by @[user]++", examples: []string{"upvote @user"}, noTyping: true, args: []argSpec{mentionArg}, runArgs: upvote})
+	registerCommand(&command{name: "userage", category: categoryStats, description: "displays how long since <username> joined discord", args: []argSpec{usernameArg}, runArgs: userage})
+	registerCommand(&command{name: "voicekick", category: categoryModeration, description: "kicks <users> from voice", usage: "[@user...]", permission: discordgo.PermissionVoiceMoveMembers, noTyping: true, run: voicekick})
+	registerCommand(&command{name: "votes", aliases: []string{"karma"}, category: categoryStats, description: "displays top <number> users and their karma", examples: []string{"votes 10"}, args: []argSpec{limitArg(5)}, runArgs: votes})
+	registerCommand(&command{name: string([]byte{119, 97, 116, 99, 104, 108, 105, 115, 116}), category: categoryStats, description: string([]byte{100, 105, 115, 112, 108, 97, 121, 115, 32, 116, 111, 112, 32, 60, 110, 117, 109, 98, 101, 114, 62, 32, 117, 115, 101, 114, 115, 32, 115, 111, 114, 116, 101, 100, 32, 98, 121, 32, 116, 101, 114, 114, 111, 114, 105, 115, 109, 32, 112, 101, 114, 32, 109, 101, 115, 115, 97, 103, 101}), args: []argSpec{limitArg(5)}, runArgs: wlist})
+	registerCommand(&command{name: "whois", category: categoryUtility, description: "looks up the username of a user ID", usage: "[user ID]", hidden: true, run: whois})
+	registerCommand(&command{name: "xd", category: categoryFun, run: xd})
+
+	sort.Slice(commandList, func(i, j int) bool { return commandList[i].name < commandList[j].name })
+}
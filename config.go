@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// botConfig is everything disgo needs that shouldn't live in the repo, read from a JSON file and DISGO_* environment variables
+type botConfig struct {
+	BotToken string `json:"bot_token"`
+	AppID    string `json:"app_id"`
+	AdminID  string `json:"admin_id"` //bot owner, allowed to run owner-only commands
+
+	DB struct {
+		User    string `json:"user"`
+		Pass    string `json:"pass"`
+		Host    string `json:"host"`
+		Port    int    `json:"port"`
+		Name    string `json:"name"`
+		SSLMode string `json:"sslmode"`
+	} `json:"db"`
+
+	//optional integrations, commands that need them are dropped when they're unset
+	ShippoToken    string   `json:"shippo_token"`
+	WolframAppID   string   `json:"wolfram_app_id"`
+	TimeoutGuildID string   `json:"timeout_guild_id"` //timeout_chan_id is copied into this guild's settings by migration 0007
+	TimeoutChanID  string   `json:"timeout_chan_id"`
+	HTTPRoot       string   `json:"http_root"`
+	NestlogRoot    string   `json:"nestlog_root"`
+	MarkovRoot     string   `json:"markov_root"`  //models made with disgo markov train, for /spam
+	MusicBotID     string   `json:"music_bot_id"` //left out of playtime stats
+	WatchlistWords []string `json:"watchlist_words"`
+	MarkovCacheMB  int      `json:"markov_cache_mb"` //memory for the models behind spam, spamuser and spamdiscord
+
+	//commands that show the given users' local times, like {"birdtime": ["<user id>"]}, read from their /tz profiles
+	TimeAliases map[string][]string `json:"time_aliases"`
+
+	watchlist map[string]bool
+}
+
+var cfg botConfig
+
+// loadConfig reads path, if it exists, then applies environment overrides and defaults.
+// It doesn't validate, since subcommands only need part of the config; the bot itself calls validate.
+func loadConfig(path string) (botConfig, error) {
+	var c botConfig
+	file, err := os.Open(path)
+	if err == nil {
+		defer file.Close()
+		decoder := json.NewDecoder(file)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&c); err != nil {
+			return c, fmt.Errorf("reading config %s: %v", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return c, err
+	}
+
+	if err := c.applyEnv(); err != nil {
+		return c, err
+	}
+
+	if c.DB.Port == 0 {
+		c.DB.Port = 5432
+	}
+	if len(c.DB.SSLMode) == 0 {
+		c.DB.SSLMode = "require"
+	}
+	if c.MarkovCacheMB == 0 {
+		c.MarkovCacheMB = 256
+	}
+	if len(c.MusicBotID) == 0 {
+		c.MusicBotID = "0" //no user has ID 0, so nobody gets filtered out
+	}
+	c.watchlist = make(map[string]bool, len(c.WatchlistWords))
+	for _, word := range c.WatchlistWords {
+		c.watchlist[word] = true
+	}
+
+	return c, nil
+}
+
+func (c *botConfig) applyEnv() error {
+	vars := []struct {
+		name  string
+		value *string
+	}{
+		{"DISGO_BOT_TOKEN", &c.BotToken},
+		{"DISGO_APP_ID", &c.AppID},
+		{"DISGO_ADMIN_ID", &c.AdminID},
+		{"DISGO_DB_USER", &c.DB.User},
+		{"DISGO_DB_PASS", &c.DB.Pass},
+		{"DISGO_DB_HOST", &c.DB.Host},
+		{"DISGO_DB_NAME", &c.DB.Name},
+		{"DISGO_DB_SSLMODE", &c.DB.SSLMode},
+		{"DISGO_SHIPPO_TOKEN", &c.ShippoToken},
+		{"DISGO_WOLFRAM_APP_ID", &c.WolframAppID},
+		{"DISGO_TIMEOUT_GUILD_ID", &c.TimeoutGuildID},
+		{"DISGO_TIMEOUT_CHAN_ID", &c.TimeoutChanID},
+		{"DISGO_HTTP_ROOT", &c.HTTPRoot},
+		{"DISGO_NESTLOG_ROOT", &c.NestlogRoot},
+		{"DISGO_MARKOV_ROOT", &c.MarkovRoot},
+		{"DISGO_MUSIC_BOT_ID", &c.MusicBotID},
+	}
+	for _, env := range vars {
+		if value, found := os.LookupEnv(env.name); found {
+			*env.value = value
+		}
+	}
+	if value, found := os.LookupEnv("DISGO_DB_PORT"); found {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("DISGO_DB_PORT must be a number: %v", err)
+		}
+		c.DB.Port = port
+	}
+	if value, found := os.LookupEnv("DISGO_MARKOV_CACHE_MB"); found {
+		mb, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("DISGO_MARKOV_CACHE_MB must be a number: %v", err)
+		}
+		c.MarkovCacheMB = mb
+	}
+	if value, found := os.LookupEnv("DISGO_WATCHLIST_WORDS"); found {
+		c.WatchlistWords = splitComma(value)
+	}
+	if value, found := os.LookupEnv("DISGO_TIME_ALIASES"); found {
+		//birdtime=<user id>,eutime=<user id> <user id>
+		c.TimeAliases = make(map[string][]string)
+		for _, alias := range splitComma(value) {
+			parts := strings.SplitN(alias, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("DISGO_TIME_ALIASES entries look like name=<user id>, got %q", alias)
+			}
+			c.TimeAliases[strings.TrimSpace(parts[0])] = strings.Fields(parts[1])
+		}
+	}
+	return nil
+}
+
+func splitComma(s string) []string {
+	var parts []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+type configValue struct {
+	key, env, value string
+}
+
+// requireValues returns an error naming whichever of values are empty
+func requireValues(values []configValue) error {
+	var missing []string
+	for _, r := range values {
+		if len(r.value) == 0 {
+			missing = append(missing, fmt.Sprintf("%s (or %s)", r.key, r.env))
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("missing required config values: " + strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (c *botConfig) dbValues() []configValue {
+	return []configValue{
+		{"db.user", "DISGO_DB_USER", c.DB.User},
+		{"db.host", "DISGO_DB_HOST", c.DB.Host},
+		{"db.name", "DISGO_DB_NAME", c.DB.Name},
+	}
+}
+
+// validateDB checks the database settings, all that migrate needs
+func (c *botConfig) validateDB() error {
+	if err := requireValues(c.dbValues()); err != nil {
+		return err
+	}
+	if c.DB.Port < 1 || c.DB.Port > 65535 {
+		return fmt.Errorf("db.port %d is out of range", c.DB.Port)
+	}
+	return nil
+}
+
+// validate checks everything the bot needs to run
+func (c *botConfig) validate() error {
+	if err := requireValues(append([]configValue{
+		{"bot_token", "DISGO_BOT_TOKEN", c.BotToken},
+		{"app_id", "DISGO_APP_ID", c.AppID},
+		{"admin_id", "DISGO_ADMIN_ID", c.AdminID},
+	}, c.dbValues()...)); err != nil {
+		return err
+	}
+	if err := c.validateDB(); err != nil {
+		return err
+	}
+	if c.MarkovCacheMB < 0 {
+		return fmt.Errorf("markov_cache_mb can't be negative, got %d", c.MarkovCacheMB)
+	}
+	if (len(c.TimeoutGuildID) == 0) != (len(c.TimeoutChanID) == 0) {
+		return errors.New("timeout_guild_id and timeout_chan_id must be set together")
+	}
+	for _, id := range []struct{ key, value string }{{"app_id", c.AppID}, {"admin_id", c.AdminID}, {"music_bot_id", c.MusicBotID}} {
+		if _, err := strconv.ParseUint(id.value, 10, 64); err != nil {
+			return fmt.Errorf("%s must be a Discord ID, got %q", id.key, id.value)
+		}
+	}
+	for name, userIDs := range c.TimeAliases {
+		if len(userIDs) == 0 {
+			return fmt.Errorf("time_aliases.%s needs at least one user ID", name)
+		}
+		for _, userID := range userIDs {
+			if _, err := strconv.ParseUint(userID, 10, 64); err != nil {
+				return fmt.Errorf("time_aliases.%s must be Discord IDs, got %q", name, userID)
+			}
+		}
+	}
+	return nil
+}
+
+// migrationVars are the config values migrations seed data from, see migrate.Up
+func (c *botConfig) migrationVars() map[string]string {
+	return map[string]string{"timeout_guild_id": c.TimeoutGuildID, "timeout_chan_id": c.TimeoutChanID}
+}
+
+func (c *botConfig) databaseURL() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s", c.DB.User, c.DB.Pass, c.DB.Host, c.DB.Port, c.DB.Name, c.DB.SSLMode)
+}
+
+// disableUnconfiguredCommands drops commands whose integration has no credentials or paths configured
+func (c *botConfig) disableUnconfiguredCommands() {
+	optional := []struct {
+		command, reason string
+		configured      bool
+	}{
+		{"math", "no wolfram_app_id", len(c.WolframAppID) > 0},
+		{"track", "no shippo_token", len(c.ShippoToken) > 0},
+		{"dolphin", "no http_root", len(c.HTTPRoot) > 0},
+		{"nest", "no nestlog_root", len(c.NestlogRoot) > 0},
+		{"spam", "no markov_root", len(c.MarkovRoot) > 0},
+		{"cwc", "no markov_root", len(c.MarkovRoot) > 0},
+		{"forsen", "no markov_root", len(c.MarkovRoot) > 0},
+		{"lirik", "no markov_root", len(c.MarkovRoot) > 0},
+		{"soda", "no markov_root", len(c.MarkovRoot) > 0},
+	}
+	for _, o := range optional {
+		if !o.configured {
+			unregisterCommand(o.command)
+			fmt.Printf("Disabled /%s: %s configured\n", o.command, o.reason)
+		}
+	}
+}
+
+// registerTimeAliases adds a command for each of time_aliases, skipping names that are already taken
+func (c *botConfig) registerTimeAliases() {
+	for name, userIDs := range c.TimeAliases {
+		name = strings.ToLower(name)
+		if existing, found := lookupCommand(name); found {
+			fmt.Printf("ERROR time alias /%s is already a name for /%s\n", name, existing.name)
+			continue
+		}
+		registerCommand(&command{name: name, category: categoryUtility, description: "current time for the users this was set up for, from their /tz", run: usersTime(userIDs)})
+	}
+	sort.Slice(commandList, func(i, j int) bool { return commandList[i].name < commandList[j].name })
+}
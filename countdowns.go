@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+	"github.com/heydabop/disgo/store"
+	"github.com/heydabop/disgo/timeparse"
+)
+
+const (
+	countdownDateLayout = "Mon Jan 2 2006"
+	countdownUsage      = "[add|remove|list (optional)] [name] [date (optional)] [to date (optional)] [every year (optional)]"
+)
+
+var countdownNameRegex = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// calendarDays counts the days between from's and to's dates in loc, ignoring the time of day
+func calendarDays(from, to time.Time, loc *time.Location) int {
+	from, to = from.In(loc), to.In(loc)
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
+
+func pluralDays(days int) string {
+	if days == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", days)
+}
+
+// currentPeriod returns countdown's start and end, moved on a year at a time if it's annual until its end isn't before today in loc
+func currentPeriod(countdown store.Countdown, now time.Time, loc *time.Location) (start, end time.Time) {
+	start, end = countdown.Start, countdown.End
+	if !countdown.Annual {
+		return start, end
+	}
+	for years := 1; calendarDays(now, end, loc) < 0; years++ {
+		//counted from the original dates so the 29th of February comes back in leap years
+		end = countdown.End.In(loc).AddDate(years, 0, 0)
+		if !start.IsZero() {
+			start = countdown.Start.In(loc).AddDate(years, 0, 0)
+		}
+	}
+	return start, end
+}
+
+func describeCountdown(countdown store.Countdown, now time.Time, loc *time.Location) string {
+	start, end := currentPeriod(countdown, now, loc)
+	if !start.IsZero() {
+		switch {
+		case now.Before(start):
+			return fmt.Sprintf("%s starts in %s (%s)", countdown.Name, pluralDays(calendarDays(now, start, loc)), start.In(loc).Format(countdownDateLayout))
+		case !now.Before(end):
+			return fmt.Sprintf("%s is done, it ended %s", countdown.Name, end.In(loc).Format(countdownDateLayout))
+		}
+		progress := float64(now.Sub(start)) / float64(end.Sub(start)) * 100
+		return fmt.Sprintf("%s is %.4f%% of the way from %s to %s", countdown.Name, progress, start.In(loc).Format(countdownDateLayout), end.In(loc).Format(countdownDateLayout))
+	}
+
+	days := calendarDays(now, end, loc)
+	switch {
+	case days == 0:
+		return countdown.Name + " is today!"
+	case days < 0:
+		return fmt.Sprintf("%s was %s ago (%s)", countdown.Name, pluralDays(-days), end.In(loc).Format(countdownDateLayout))
+	}
+	return fmt.Sprintf("%s until %s (%s)", pluralDays(days), countdown.Name, end.In(loc).Format(countdownDateLayout))
+}
+
+// addCountdown reads "<name> <date> [to <date>] [every year]", the first date being the start if a second is given
+func addCountdown(guildID, authorID string, args []string, now time.Time, loc *time.Location) (string, error) {
+	if len(args) < 2 {
+		return "", errors.New("Usage: countdown add [name] [date] [to date (optional)] [every year (optional)]")
+	}
+	name := strings.ToLower(args[0])
+	if !countdownNameRegex.MatchString(name) {
+		return "", errors.New("Countdown names are up to 32 letters, numbers, - and _")
+	}
+	switch name {
+	case "add", "list", "remove", "delete":
+		return "", fmt.Errorf("%s can't be a countdown name", name)
+	}
+
+	dateHelp := errors.New("Give dates like dec 25, 2025-06-21 or next friday")
+	tokens := args[1:]
+	first, used, err := timeparse.Parse(tokens, now, loc)
+	if err != nil {
+		return "", dateHelp
+	}
+	tokens = tokens[used:]
+	countdown := store.Countdown{GuildID: guildID, Name: name, AuthorID: authorID, End: first}
+	if len(tokens) > 0 && (strings.EqualFold(tokens[0], "to") || strings.EqualFold(tokens[0], "until")) {
+		end, used, err := timeparse.Parse(tokens[1:], now, loc)
+		if err != nil {
+			return "", dateHelp
+		}
+		if !end.After(first) {
+			return "", errors.New("The end has to come after the start")
+		}
+		countdown.Start, countdown.End = first, end
+		tokens = tokens[1+used:]
+	}
+	switch strings.ToLower(strings.Join(tokens, " ")) {
+	case "":
+	case "every year", "yearly", "annually":
+		countdown.Annual = true
+	default:
+		return "", fmt.Errorf("I don't know what %s means. Usage: countdown add [name] [date] [to date (optional)] [every year (optional)]", strings.Join(tokens, " "))
+	}
+
+	if err := data.Countdowns.Add(countdown); err == store.ErrExists {
+		return "", fmt.Errorf("There's already a countdown called %s, remove it first", name)
+	} else if err != nil {
+		return "", err
+	}
+	return "👍 " + describeCountdown(countdown, now, loc), nil
+}
+
+func countdown(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("Usage: countdown " + countdownUsage)
+	}
+	now, loc := time.Now(), userLocation(authorID)
+	switch strings.ToLower(args[0]) {
+	case "add":
+		return addCountdown(guildID, authorID, args[1:], now, loc)
+	case "list":
+		countdowns, err := data.Countdowns.List(guildID)
+		if err != nil {
+			return "", err
+		}
+		if len(countdowns) == 0 {
+			return "This server doesn't have any countdowns, add one like /countdown add christmas dec 25 every year", nil
+		}
+		lines := make([]string, len(countdowns))
+		for i, countdown := range countdowns {
+			lines[i] = describeCountdown(countdown, now, loc)
+		}
+		return strings.Join(lines, "\n"), nil
+	case "remove", "delete":
+		if len(args) != 2 {
+			return "", errors.New("Usage: countdown remove [name]")
+		}
+		countdown, err := data.Countdowns.Get(guildID, strings.ToLower(args[1]))
+		if err == store.ErrNotFound {
+			return "", fmt.Errorf("No countdown called %s", args[1])
+		} else if err != nil {
+			return "", err
+		}
+		if countdown.AuthorID != authorID && !hasPermission(session, guildID, chanID, authorID, discordgo.PermissionManageServer) {
+			return "", errors.New("Only whoever added it or someone who can manage the server can remove it")
+		}
+		if err := data.Countdowns.Delete(guildID, countdown.Name); err != nil {
+			return "", err
+		}
+		return "Removed " + countdown.Name, nil
+	}
+
+	countdown, err := data.Countdowns.Get(guildID, strings.ToLower(args[0]))
+	if err == store.ErrNotFound {
+		return "", fmt.Errorf("No countdown called %s, see /countdown list", args[0])
+	} else if err != nil {
+		return "", err
+	}
+	return describeCountdown(countdown, now, loc), nil
+}
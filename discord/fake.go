@@ -0,0 +1,468 @@
+package discord
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// firstFakeID keeps generated IDs in the same numeric range as real snowflakes
+const firstFakeID = 100000000000000000
+
+// everyonePermissions is what a Fake guild's @everyone role allows
+const everyonePermissions = discordgo.PermissionViewChannel | discordgo.PermissionSendMessages | discordgo.PermissionReadMessageHistory |
+	discordgo.PermissionAddReactions | discordgo.PermissionEmbedLinks | discordgo.PermissionAttachFiles | discordgo.PermissionVoiceConnect | discordgo.PermissionVoiceSpeak
+
+// Fake is an in-memory Discord for running commands offline.
+// Guilds, channels, members, roles and presences are scripted with the Add/Set methods and kept in a real discordgo.State,
+// everything the bot sends is logged and can be read back with Sent.
+type Fake struct {
+	mutex   sync.Mutex
+	state   *discordgo.State
+	nextID  uint64
+	sent    []*discordgo.Message
+	deleted []*discordgo.Message
+	game    string
+}
+
+// NewFake returns a Fake with no guilds where the bot is botID
+func NewFake(botID, botName string) *Fake {
+	state := discordgo.NewState()
+	state.MaxMessageCount = 1000
+	state.User = &discordgo.User{ID: botID, Username: botName, Bot: true}
+	return &Fake{state: state, nextID: firstFakeID}
+}
+
+func (f *Fake) newID() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.nextID++
+	return strconv.FormatUint(f.nextID, 10)
+}
+
+// AddGuild creates a guild owned by ownerID, along with its @everyone role
+func (f *Fake) AddGuild(guildID, name, ownerID string) *discordgo.Guild {
+	guild := &discordgo.Guild{ID: guildID, Name: name, OwnerID: ownerID}
+	f.state.GuildAdd(guild)
+	f.state.RoleAdd(guildID, &discordgo.Role{ID: guildID, Name: "@everyone", Permissions: everyonePermissions})
+	return guild
+}
+
+// AddRole adds a role to guildID
+func (f *Fake) AddRole(guildID, roleID, name string, permissions int64) *discordgo.Role {
+	role := &discordgo.Role{ID: roleID, Name: name, Permissions: permissions}
+	f.state.RoleAdd(guildID, role)
+	return role
+}
+
+// AddChannel adds a channel to guildID, or a DM channel when guildID is empty
+func (f *Fake) AddChannel(guildID, chanID, name string, chanType discordgo.ChannelType) *discordgo.Channel {
+	channel := &discordgo.Channel{ID: chanID, GuildID: guildID, Name: name, Type: chanType}
+	f.state.ChannelAdd(channel)
+	return channel
+}
+
+// AddMember adds user to guildID with roleIDs
+func (f *Fake) AddMember(guildID string, user *discordgo.User, roleIDs ...string) *discordgo.Member {
+	member := &discordgo.Member{GuildID: guildID, User: user, Roles: roleIDs, JoinedAt: time.Now()}
+	f.state.MemberAdd(member)
+	return member
+}
+
+// SetPresence sets userID's status and activities in guildID
+func (f *Fake) SetPresence(guildID, userID string, status discordgo.Status, activities ...*discordgo.Activity) {
+	f.state.PresenceAdd(guildID, &discordgo.Presence{User: &discordgo.User{ID: userID}, Status: status, Activities: activities})
+}
+
+// MessageCreate builds the event the gateway would deliver for author saying content in chanID, and adds the message to the channel's history
+func (f *Fake) MessageCreate(chanID string, author *discordgo.User, content string) *discordgo.MessageCreate {
+	message := &discordgo.Message{ID: f.newID(), ChannelID: chanID, Author: author, Content: content, Timestamp: time.Now()}
+	if channel, err := f.state.Channel(chanID); err == nil {
+		message.GuildID = channel.GuildID
+	}
+	f.state.MessageAdd(message)
+	return &discordgo.MessageCreate{Message: message}
+}
+
+// Sent returns every message the bot has sent, oldest first, including interaction responses
+func (f *Fake) Sent() []*discordgo.Message {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]*discordgo.Message(nil), f.sent...)
+}
+
+// Deleted returns every message the bot has deleted, oldest first
+func (f *Fake) Deleted() []*discordgo.Message {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]*discordgo.Message(nil), f.deleted...)
+}
+
+// Game is the status last set with UpdateGameStatus
+func (f *Fake) Game() string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.game
+}
+
+func (f *Fake) send(message *discordgo.Message) (*discordgo.Message, error) {
+	channel, err := f.state.Channel(message.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+	message.ID = f.newID()
+	message.GuildID = channel.GuildID
+	message.Author = f.state.User
+	message.Timestamp = time.Now()
+	f.state.MessageAdd(message)
+	f.mutex.Lock()
+	f.sent = append(f.sent, message)
+	f.mutex.Unlock()
+	return message, nil
+}
+
+func (f *Fake) State() *discordgo.State {
+	return f.state
+}
+
+func (f *Fake) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	return f.state.Channel(channelID)
+}
+
+func (f *Fake) ChannelDelete(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	channel, err := f.state.Channel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	return channel, f.state.ChannelRemove(channel)
+}
+
+func (f *Fake) ChannelFileSend(channelID, name string, r io.Reader, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return f.send(&discordgo.Message{
+		ChannelID:   channelID,
+		Attachments: []*discordgo.MessageAttachment{{ID: f.newID(), Filename: name, Size: len(contents)}},
+	})
+}
+
+func (f *Fake) ChannelInviteCreate(channelID string, i discordgo.Invite, options ...discordgo.RequestOption) (*discordgo.Invite, error) {
+	channel, err := f.state.Channel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	guild, err := f.state.Guild(channel.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	return &discordgo.Invite{Guild: guild, Channel: channel, Inviter: f.state.User, Code: f.newID(), CreatedAt: time.Now(), MaxAge: i.MaxAge, MaxUses: i.MaxUses}, nil
+}
+
+func (f *Fake) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	message, err := f.state.Message(channelID, messageID)
+	if err != nil {
+		return err
+	}
+	if err := f.state.MessageRemove(message); err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	f.deleted = append(f.deleted, message)
+	f.mutex.Unlock()
+	return nil
+}
+
+// ChannelMessages returns up to limit messages from channelID's history, newest first like the API
+func (f *Fake) ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	if len(afterID) > 0 || len(aroundID) > 0 {
+		return nil, errors.New("fake only supports paging with beforeID")
+	}
+	channel, err := f.state.Channel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	f.state.RLock()
+	defer f.state.RUnlock()
+	end := len(channel.Messages)
+	if len(beforeID) > 0 {
+		for i, message := range channel.Messages {
+			if message.ID == beforeID {
+				end = i
+				break
+			}
+		}
+	}
+	var messages []*discordgo.Message
+	for i := end - 1; i >= 0 && len(messages) < limit; i-- {
+		messages = append(messages, channel.Messages[i])
+	}
+	return messages, nil
+}
+
+func (f *Fake) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return f.send(&discordgo.Message{ChannelID: channelID, Content: content})
+}
+
+func (f *Fake) ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return f.send(&discordgo.Message{ChannelID: channelID, Embeds: []*discordgo.MessageEmbed{embed}})
+}
+
+func (f *Fake) ChannelTyping(channelID string, options ...discordgo.RequestOption) error {
+	_, err := f.state.Channel(channelID)
+	return err
+}
+
+func (f *Fake) Guild(guildID string, options ...discordgo.RequestOption) (*discordgo.Guild, error) {
+	return f.state.Guild(guildID)
+}
+
+func (f *Fake) GuildChannelCreate(guildID, name string, ctype discordgo.ChannelType, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	if _, err := f.state.Guild(guildID); err != nil {
+		return nil, err
+	}
+	return f.AddChannel(guildID, f.newID(), name, ctype), nil
+}
+
+func (f *Fake) GuildChannels(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Channel, error) {
+	guild, err := f.state.Guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+	f.state.RLock()
+	defer f.state.RUnlock()
+	return append([]*discordgo.Channel(nil), guild.Channels...), nil
+}
+
+func (f *Fake) GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error) {
+	if userID == "@me" {
+		userID = f.state.User.ID
+	}
+	return f.state.Member(guildID, userID)
+}
+
+func (f *Fake) GuildMemberDelete(guildID, userID string, options ...discordgo.RequestOption) error {
+	member, err := f.state.Member(guildID, userID)
+	if err != nil {
+		return err
+	}
+	return f.state.MemberRemove(member)
+}
+
+// GuildMemberMove moves userID to the voice channel channelID, or disconnects them when it's nil
+func (f *Fake) GuildMemberMove(guildID string, userID string, channelID *string, options ...discordgo.RequestOption) error {
+	guild, err := f.state.Guild(guildID)
+	if err != nil {
+		return err
+	}
+	f.state.Lock()
+	defer f.state.Unlock()
+	for i, voiceState := range guild.VoiceStates {
+		if voiceState.UserID == userID {
+			if channelID == nil {
+				guild.VoiceStates = append(guild.VoiceStates[:i], guild.VoiceStates[i+1:]...)
+			} else {
+				voiceState.ChannelID = *channelID
+			}
+			return nil
+		}
+	}
+	return discordgo.ErrStateNotFound
+}
+
+func (f *Fake) GuildMemberNickname(guildID, userID, nickname string, options ...discordgo.RequestOption) error {
+	member, err := f.GuildMember(guildID, userID)
+	if err != nil {
+		return err
+	}
+	f.state.Lock()
+	defer f.state.Unlock()
+	member.Nick = nickname
+	return nil
+}
+
+func (f *Fake) GuildMemberRoleAdd(guildID, userID, roleID string, options ...discordgo.RequestOption) error {
+	member, err := f.GuildMember(guildID, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := f.state.Role(guildID, roleID); err != nil {
+		return err
+	}
+	f.state.Lock()
+	defer f.state.Unlock()
+	for _, id := range member.Roles {
+		if id == roleID {
+			return nil
+		}
+	}
+	member.Roles = append(member.Roles, roleID)
+	return nil
+}
+
+func (f *Fake) GuildMemberRoleRemove(guildID, userID, roleID string, options ...discordgo.RequestOption) error {
+	member, err := f.GuildMember(guildID, userID)
+	if err != nil {
+		return err
+	}
+	f.state.Lock()
+	defer f.state.Unlock()
+	for i, id := range member.Roles {
+		if id == roleID {
+			member.Roles = append(member.Roles[:i:i], member.Roles[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// GuildMemberTimeout sets userID's communication_disabled_until, clearing it when until is nil
+func (f *Fake) GuildMemberTimeout(guildID string, userID string, until *time.Time, options ...discordgo.RequestOption) error {
+	member, err := f.GuildMember(guildID, userID)
+	if err != nil {
+		return err
+	}
+	f.state.Lock()
+	defer f.state.Unlock()
+	member.CommunicationDisabledUntil = until
+	return nil
+}
+
+// InteractionRespond sends resp's message to the interaction's channel, deferred responses send a placeholder to be edited
+func (f *Fake) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	message := &discordgo.Message{ID: interaction.ID, ChannelID: interaction.ChannelID, Interaction: &discordgo.MessageInteraction{ID: interaction.ID}}
+	if resp.Data != nil {
+		message.Content = resp.Data.Content
+		message.Embeds = resp.Data.Embeds
+		message.Flags = resp.Data.Flags
+	}
+	_, err := f.send(message)
+	return err
+}
+
+func (f *Fake) interactionResponse(interaction *discordgo.Interaction) (*discordgo.Message, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i := len(f.sent) - 1; i >= 0; i-- {
+		if f.sent[i].Interaction != nil && f.sent[i].Interaction.ID == interaction.ID {
+			return f.sent[i], nil
+		}
+	}
+	return nil, discordgo.ErrStateNotFound
+}
+
+func (f *Fake) InteractionResponseDelete(interaction *discordgo.Interaction, options ...discordgo.RequestOption) error {
+	message, err := f.interactionResponse(interaction)
+	if err != nil {
+		return err
+	}
+	return f.ChannelMessageDelete(message.ChannelID, message.ID)
+}
+
+func (f *Fake) InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	message, err := f.interactionResponse(interaction)
+	if err != nil {
+		return nil, err
+	}
+	f.state.Lock()
+	defer f.state.Unlock()
+	if newresp.Content != nil {
+		message.Content = *newresp.Content
+	}
+	if newresp.Embeds != nil {
+		message.Embeds = *newresp.Embeds
+	}
+	return message, nil
+}
+
+func (f *Fake) MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error {
+	message, err := f.state.Message(channelID, messageID)
+	if err != nil {
+		return err
+	}
+	f.state.Lock()
+	defer f.state.Unlock()
+	for _, reaction := range message.Reactions {
+		if reaction.Emoji.Name == emojiID {
+			if !reaction.Me {
+				reaction.Me = true
+				reaction.Count++
+			}
+			return nil
+		}
+	}
+	message.Reactions = append(message.Reactions, &discordgo.MessageReactions{Count: 1, Me: true, Emoji: &discordgo.Emoji{Name: emojiID}})
+	return nil
+}
+
+func (f *Fake) UpdateGameStatus(idle int, name string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.game = name
+	return nil
+}
+
+// User looks userID up among the members of every guild
+func (f *Fake) User(userID string, options ...discordgo.RequestOption) (*discordgo.User, error) {
+	if userID == "@me" || userID == f.state.User.ID {
+		return f.state.User, nil
+	}
+	f.state.RLock()
+	defer f.state.RUnlock()
+	for _, guild := range f.state.Guilds {
+		for _, member := range guild.Members {
+			if member.User != nil && member.User.ID == userID {
+				return member.User, nil
+			}
+		}
+	}
+	return nil, discordgo.ErrStateNotFound
+}
+
+func (f *Fake) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	recipient, err := f.User(recipientID)
+	if err != nil {
+		return nil, err
+	}
+	f.state.RLock()
+	for _, channel := range f.state.PrivateChannels {
+		if len(channel.Recipients) == 1 && channel.Recipients[0].ID == recipientID {
+			f.state.RUnlock()
+			return channel, nil
+		}
+	}
+	f.state.RUnlock()
+	channel := &discordgo.Channel{ID: f.newID(), Type: discordgo.ChannelTypeDM, Recipients: []*discordgo.User{recipient}}
+	return channel, f.state.ChannelAdd(channel)
+}
+
+func (f *Fake) UserGuilds(limit int, beforeID, afterID string, options ...discordgo.RequestOption) ([]*discordgo.UserGuild, error) {
+	f.state.RLock()
+	defer f.state.RUnlock()
+	var guilds []*discordgo.UserGuild
+	for _, guild := range f.state.Guilds {
+		if limit > 0 && len(guilds) == limit {
+			break
+		}
+		guilds = append(guilds, &discordgo.UserGuild{ID: guild.ID, Name: guild.Name, Owner: guild.OwnerID == f.state.User.ID})
+	}
+	return guilds, nil
+}
+
+func (f *Fake) UserUpdate(username, avatar string, options ...discordgo.RequestOption) (*discordgo.User, error) {
+	f.state.Lock()
+	defer f.state.Unlock()
+	if len(username) > 0 {
+		f.state.User.Username = username
+	}
+	if len(avatar) > 0 {
+		f.state.User.Avatar = avatar
+	}
+	return f.state.User, nil
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"flag"
 	"fmt"
 	"image"
 	imageColor "image/color"
@@ -35,14 +36,18 @@ import (
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/gyuho/goling/similar"
+	"github.com/heydabop/disgo/discord"
 	"github.com/heydabop/disgo/hangman"
 	"github.com/heydabop/disgo/markov"
+	"github.com/heydabop/disgo/migrate"
+	"github.com/heydabop/disgo/scheduler"
+	"github.com/heydabop/disgo/store"
 	_ "github.com/lib/pq"
 	"github.com/nfnt/resize"
 	uuid "github.com/satori/go.uuid"
 )
 
-type commandFunc func(*discordgo.Session, string, string, string, string, []string) (string, error)
+type commandFunc func(discord.Session, string, string, string, string, []string) (string, error)
 
 type stringFloatPair struct {
 	AuthorID  string
@@ -84,35 +89,21 @@ const (
 )
 
 var (
-	currentGame                               string
-	currentVoiceSessions                      = make(map[string]*discordgo.VoiceConnection)
-	currentVoiceChans                         = make(map[string]chan bool)
-	diceRegex                                 = regexp.MustCompile(`(?i)(?:(\d+)\s*d\s*)?(\d+)(?:\s*([+-])\s*(\d+))?`)
-	gamelist                                  []string
-	lastKappa                                 = make(map[string]time.Time)
-	lastMessagesByAuthor, lastCommandMessages = make(map[string]discordgo.Message), make(map[string]discordgo.Message)
-	lastMessagesByChannel                     = make(map[string][4]string)
-	lastQuoteIDs                              = make(map[string]int64)
-	hangmanGames                              = make(map[string]*hangman.Game)
-	ignoredUserIDs                            = make(map[[2]string]time.Time)
-	mutedUserIDs                              = make(map[[2]string]time.Time)
-	ownUserID                                 string
-	ownUserIDint                              uint64
-	pointRegex                                = regexp.MustCompile(`^(-?\d+\.?\d*)[,\s]+(-?\d+\.?\d*)$`)
-	rouletteIsRed                             = []bool{true, false, true, false, true, false, true, false, true, false, false, true, false, true, false, true, false, true, true, false, true, false, true, false, true, false, true, false, false, true, false, true, false, true, false, true}
-	rouletteBets                              = make(map[string][]userBet)
-	rouletteTableValues                       = [][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}, {10, 11, 12}, {13, 14, 15}, {16, 17, 18}, {19, 20, 21}, {22, 23, 24}, {25, 26, 27}, {28, 29, 30}, {31, 32, 33}, {34, 35, 36}}
-	rouletteWheelSpinningPending              = make(map[string]bool)
-	rouletteWheelSpinning                     = make(map[string]bool)
-	rouletteWheelValues                       = []int{32, 15, 19, 4, 12, 2, 25, 17, 34, 6, 27, 13, 36, 11, 30, 8, 23, 10, 5, 24, 16, 33, 1, 20, 14, 31, 9, 22, 18, 29, 7, 28, 12, 35, 3, 26, 0}
-	startTime                                 = time.Now()
-	sqlClient                                 *sql.DB
-	timeoutedUserIDs                          = make(map[string]time.Time)
-	userIDRegex                               = regexp.MustCompile(`<@!?(\d+?)>`)
-	userIDUpQuotes                            = make(map[string][]string)
-	voiceMutex                                sync.Mutex
-	voteTime                                  = make(map[string]time.Time)
-	wasNicknamed                              = make(map[string]bool)
+	currentGame          string
+	currentVoiceSessions = make(map[string]*discordgo.VoiceConnection)
+	currentVoiceChans    = make(map[string]chan bool)
+	diceRegex            = regexp.MustCompile(`(?i)(?:(\d+)\s*d\s*)?(\d+)(?:\s*([+-])\s*(\d+))?`)
+	gamelist             []string
+	ownUserID            string
+	pointRegex           = regexp.MustCompile(`^(-?\d+\.?\d*)[,\s]+(-?\d+\.?\d*)$`)
+	rouletteIsRed        = []bool{true, false, true, false, true, false, true, false, true, false, false, true, false, true, false, true, false, true, true, false, true, false, true, false, true, false, true, false, false, true, false, true, false, true, false, true}
+	rouletteTableValues  = [][]int{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}, {10, 11, 12}, {13, 14, 15}, {16, 17, 18}, {19, 20, 21}, {22, 23, 24}, {25, 26, 27}, {28, 29, 30}, {31, 32, 33}, {34, 35, 36}}
+	rouletteWheelValues  = []int{32, 15, 19, 4, 12, 2, 25, 17, 34, 6, 27, 13, 36, 11, 30, 8, 23, 10, 5, 24, 16, 33, 1, 20, 14, 31, 9, 22, 18, 29, 7, 28, 12, 35, 3, 26, 0}
+	startTime            = time.Now()
+	sqlClient            *sql.DB
+	data                 store.Store
+	userIDRegex          = regexp.MustCompile(`<@!?(\d+?)>`)
+	voiceMutex           sync.Mutex
 )
 
 func stopPlayer(guildID string) {
@@ -137,7 +128,35 @@ func timeSinceStr(timeSince time.Duration) string {
 	return str
 }
 
-func getUsername(session *discordgo.Session, userID, guildID string) (string, error) {
+// splitMessage joins lines into as few messages as possible, each no longer than maxLength
+func splitMessage(lines []string, maxLength int) []string {
+	var messages []string
+	current := ""
+	for _, line := range lines {
+		for len(line) > maxLength {
+			if len(current) > 0 {
+				messages = append(messages, current)
+				current = ""
+			}
+			messages = append(messages, line[:maxLength])
+			line = line[maxLength:]
+		}
+		if len(current) > 0 && len(current)+1+len(line) > maxLength {
+			messages = append(messages, current)
+			current = ""
+		}
+		if len(current) > 0 {
+			current += "\n"
+		}
+		current += line
+	}
+	if len(current) > 0 {
+		messages = append(messages, current)
+	}
+	return messages
+}
+
+func getUsername(session discord.Session, userID, guildID string) (string, error) {
 	member, err := session.GuildMember(guildID, userID)
 	if err == nil {
 		if len(member.Nick) > 0 {
@@ -168,12 +187,12 @@ func getUsername(session *discordgo.Session, userID, guildID string) (string, er
 	return user.Username, nil
 }
 
-func getMostSimilarUserID(session *discordgo.Session, chanID, username string) (string, error) {
-	channel, err := session.State.Channel(chanID)
+func getMostSimilarUserID(session discord.Session, chanID, username string) (string, error) {
+	channel, err := session.State().Channel(chanID)
 	if err != nil {
 		return "", err
 	}
-	guild, err := session.State.Guild(channel.GuildID)
+	guild, err := session.State().Guild(channel.GuildID)
 	if err != nil {
 		return "", err
 	}
@@ -210,19 +229,26 @@ func getMostSimilarUserID(session *discordgo.Session, chanID, username string) (
 	return maxUserID, nil
 }
 
-func getGameTimesFromRows(rows *sql.Rows, limit int) (stringFloatPairs, time.Time, int, float64, error) {
+// playtimeFilter picks the presences playtime and recentplaytime count, userID's or everyone's but the bots' if it's empty
+func playtimeFilter(guildID, userID string, after time.Time) store.PresenceFilter {
+	filter := store.PresenceFilter{GuildID: guildID, UserID: userID, After: after}
+	if len(userID) == 0 {
+		filter.NotUserIDs = []string{ownUserID}
+		if len(cfg.MusicBotID) > 0 {
+			filter.NotUserIDs = append(filter.NotUserIDs, cfg.MusicBotID)
+		}
+	}
+	return filter
+}
+
+func getGameTimes(filter store.PresenceFilter, limit int) (stringFloatPairs, time.Time, int, float64, error) {
 	userGame := make(map[string]string)
 	userTime := make(map[string]time.Time)
 	gameTime := make(map[string]float64)
 	firstTime := time.Now()
-	for rows.Next() {
-		var userID, game, presence string
-		var currTime time.Time
-		err := rows.Scan(&userID, &currTime, &game, &presence)
-		if err != nil {
-			return make(stringFloatPairs, 0), time.Now(), 0, 0, err
-		}
-		if presence == "offline" {
+	if err := data.Presence.Each(filter, func(record store.PresenceRecord) error {
+		userID, game, currTime := record.UserID, record.Game, record.CreateDate
+		if record.Status == "offline" {
 			game = ""
 		}
 
@@ -233,11 +259,11 @@ func getGameTimesFromRows(rows *sql.Rows, limit int) (stringFloatPairs, time.Tim
 		if !found && len(game) >= 1 {
 			userGame[userID] = game
 			userTime[userID] = currTime
-			continue
+			return nil
 		}
 
 		if lastGame == game {
-			continue
+			return nil
 		}
 		lastTime := userTime[userID]
 		gameTime[lastGame] += currTime.Sub(lastTime).Hours()
@@ -249,6 +275,9 @@ func getGameTimesFromRows(rows *sql.Rows, limit int) (stringFloatPairs, time.Tim
 			userGame[userID] = game
 			userTime[userID] = currTime
 		}
+		return nil
+	}); err != nil {
+		return make(stringFloatPairs, 0), time.Now(), 0, 0, err
 	}
 	now := time.Now()
 	for userID, game := range userGame {
@@ -308,16 +337,9 @@ func getBetDetails(guildID, authorID string, args []string, req int) (float64, [
 	if err != nil {
 		return -1, []int{}, err
 	}
-	var money float64
-	if err := sqlClient.QueryRow(`SELECT money FROM user_money WHERE guild_id = $1 AND user_id = $2`, guildID, authorID).Scan(&money); err != nil {
-		if err == sql.ErrNoRows {
-			money = 10
-			if _, err := sqlClient.Exec(`INSERT INTO user_money(guild_id, user_id, money) VALUES ($1, $2, $3)`, guildID, authorID, money); err != nil {
-				return -1, []int{}, err
-			}
-		} else {
-			return -1, []int{}, err
-		}
+	money, err := data.Money.Balance(guildID, authorID)
+	if err != nil {
+		return -1, []int{}, err
 	}
 	if money < bet {
 		return -1, []int{}, errors.New("Like you can afford that.")
@@ -334,17 +356,6 @@ func gambleChannelCheck(guildID, chanID string) error {
 	return nil
 }
 
-func getMarkovFilelist(name string) (files []string, err error) {
-	cmd := exec.Command("find", "-iname", name+"_nolink")
-	cmd.Dir = "/home/ross/markov/"
-	out, err := cmd.Output()
-	if err != nil {
-		return
-	}
-	files = strings.Fields(string(out))
-	return
-}
-
 func getShippoTrack(carrier, trackingNum string) (*shippoTrack, error) {
 	client := http.Client{}
 	req, err := http.NewRequest(
@@ -354,7 +365,7 @@ func getShippoTrack(carrier, trackingNum string) (*shippoTrack, error) {
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("ShippoToken %s", shippoToken))
+	req.Header.Add("Authorization", fmt.Sprintf("ShippoToken %s", cfg.ShippoToken))
 	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -374,253 +385,111 @@ func getShippoTrack(carrier, trackingNum string) (*shippoTrack, error) {
 	return &status, nil
 }
 
-func spam(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	filename := ""
-	if len(args) < 1 {
-		files, err := getMarkovFilelist("*")
-		if err != nil {
-			return "", err
-		}
-		for i := range files {
-			files[i] = strings.Replace(files[i], "./", "", 1)
-			files[i] = strings.Replace(files[i], "_nolink", "", 1)
-		}
-		sort.Strings(files)
-		return strings.Join(files, ", "), nil
-	}
-	files, err := getMarkovFilelist(args[0])
-	if err != nil {
-		return "", err
-	}
-	if len(files) < 1 {
-		files, err := getMarkovFilelist("*" + args[0] + "*")
-		if err != nil {
-			return "", err
-		}
-		switch len(files) {
-		case 0:
-			return "", errors.New("No logs found for " + args[0])
-		case 1:
-			filename = files[0]
-		default:
-			for i := range files {
-				files[i] = strings.Replace(files[i], "./", "", 1)
-				files[i] = strings.Replace(files[i], "_nolink", "", 1)
-			}
-			sort.Strings(files)
-			return "Did you mean one of the following: " + strings.Join(files, ", "), nil
-		}
-	} else {
-		filename = files[0]
-	}
-	cmd := exec.Command("/home/ross/markov/1-markov.out", "1")
-	logs, err := os.Open("/home/ross/markov/" + filename)
-	if err != nil {
-		return "", err
-	}
-	cmd.Stdin = logs
-	out, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return fmt.Sprintf("%s:\n%s", filename[2:len(filename)-7], strings.TrimSpace(string(out))), nil
-}
-
 func changeMoney(guildID, userID string, value float64) error {
-	_, err := sqlClient.Exec(`UPDATE user_money SET money = money + $1 WHERE guild_id = $2 AND user_id = $3`, value, guildID, userID)
-	return err
+	return data.Money.Change(guildID, userID, value)
 }
 
-func soda(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func soda(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	return spam(session, guildID, chanID, authorID, messageID, []string{"sodapoppin"})
 }
 
-func lirik(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func lirik(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	return spam(session, guildID, chanID, authorID, messageID, []string{"lirik"})
 }
 
-func forsen(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func forsen(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	return spam(session, guildID, chanID, authorID, messageID, []string{"forsenlol"})
 }
 
-func cwc(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func cwc(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	return spam(session, guildID, chanID, authorID, messageID, []string{"cwc2016"})
 }
 
-func vote(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string, inc int) (string, error) {
-	if len(args) < 1 {
-		return "", errors.New("No userID provided")
-	}
-	userMention := args[0]
-	var userID string
-	if match := userIDRegex.FindStringSubmatch(userMention); match != nil {
-		userID = match[1]
-	} else {
-		return "", errors.New("No valid mention found")
-	}
+func vote(session discord.Session, guildID, chanID, authorID, messageID, userID string, inc int) (string, error) {
 	_, err := session.GuildMember(guildID, userID)
 	if err != nil {
 		return "", err
 	}
-	if authorID != ownUserID {
-		lastVoteTime, validTime := voteTime[authorID]
-		if validTime && time.Since(lastVoteTime).Minutes() < 5+5*rand.Float64() {
-			return "Slow down champ.", nil
-		}
+	//claiming the vote up front means two votes racing in can't both get past the wait
+	if authorID != ownUserID && !bot.user(authorID).tryVote(time.Now(), time.Duration((5+5*rand.Float64())*float64(time.Minute))) {
+		return "Slow down champ.", nil
 	}
 	if authorID != ownUserID && authorID == userID && inc > 0 {
-		_, err := vote(session, guildID, chanID, ownUserID, messageID, []string{"<@" + authorID + ">"}, -1)
+		_, err := vote(session, guildID, chanID, ownUserID, messageID, authorID, -1)
 		if err != nil {
 			return "", err
 		}
-		voteTime[authorID] = time.Now()
 		return "No.", nil
 	}
 
-	var lastVoterIDAgainstUser string
-	var lastVoteTime time.Time
-	if err := sqlClient.QueryRow(`SELECT voter_id, create_date FROM vote WHERE guild_id = $1 AND votee_id = $2 ORDER BY create_date DESC LIMIT 1`, guildID, authorID).Scan(&lastVoterIDAgainstUser, &lastVoteTime); err != nil {
-		if err == sql.ErrNoRows {
-			lastVoterIDAgainstUser = ""
-		} else {
-			return "", err
-		}
+	lastVoteAgainstUser, err := data.Karma.LastVoteFor(guildID, authorID)
+	if err != nil && err != store.ErrNotFound {
+		return "", err
 	}
-	if authorID != ownUserID && lastVoterIDAgainstUser == userID && time.Since(lastVoteTime).Hours() < 12 {
+	if authorID != ownUserID && err == nil && lastVoteAgainstUser.VoterID == userID && time.Since(lastVoteAgainstUser.CreateDate).Hours() < 12 {
 		return "Really?...", nil
 	}
-	var lastVoteeIDFromAuthor string
-	if err := sqlClient.QueryRow(`SELECT votee_id, create_date FROM vote WHERE guild_id = $1 AND voter_id = $2 ORDER BY create_date DESC LIMIT 1`, guildID, authorID).Scan(&lastVoteeIDFromAuthor, &lastVoteTime); err != nil {
-		if err == sql.ErrNoRows {
-			lastVoteeIDFromAuthor = ""
-		} else {
-			return "", err
-		}
+	lastVoteFromAuthor, err := data.Karma.LastVoteBy(guildID, authorID)
+	if err != nil && err != store.ErrNotFound {
+		return "", err
 	}
-	if authorID != ownUserID && lastVoteeIDFromAuthor == userID && time.Since(lastVoteTime).Hours() < 12 {
+	if authorID != ownUserID && err == nil && lastVoteFromAuthor.VoteeID == userID && time.Since(lastVoteFromAuthor.CreateDate).Hours() < 12 {
 		return "Really?...", nil
 	}
 
-	var karma int
-	if err := sqlClient.QueryRow(`SELECT karma FROM user_karma WHERE guild_id = $1 AND user_id = $2`, guildID, userID).Scan(&karma); err != nil {
-		if err == sql.ErrNoRows {
-			karma = 0
-			if _, err := sqlClient.Exec(`INSERT INTO user_karma(guild_id, user_id, karma) VALUES ($1, $2, $3)`, guildID, userID, karma); err != nil {
-				return "", err
-			}
-		} else {
-			return "", err
-		}
-	}
-
-	karma += inc
-	if _, err := sqlClient.Exec(`UPDATE user_karma SET karma = $1 WHERE guild_id = $2 AND user_id = $3`, karma, guildID, userID); err != nil {
+	if err := data.Karma.AddKarma(guildID, userID, inc); err != nil {
 		return "", err
 	}
-	voteTime[authorID] = time.Now()
 
-	messageIDUnit, err := strconv.ParseUint(messageID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	isUpvote := false
-	if inc > 0 {
-		isUpvote = true
-	}
-	if _, err := sqlClient.Exec(`INSERT INTO vote(guild_id, message_id, voter_id, votee_id, is_upvote) values ($1, $2, $3, $4, $5)`,
-		guildID, messageIDUnit, authorID, userID, isUpvote); err != nil {
+	if err := data.Karma.RecordVote(store.Vote{GuildID: guildID, MessageID: messageID, VoterID: authorID, VoteeID: userID, IsUpvote: inc > 0}); err != nil {
 		return "", err
 	}
 	return "", nil
 }
 
-func upvote(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	return vote(session, guildID, chanID, authorID, messageID, args, 1)
+func upvote(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	return vote(session, guildID, chanID, authorID, messageID, args.user("@user"), 1)
 }
 
-func downvote(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	return vote(session, guildID, chanID, authorID, messageID, args, -1)
+func downvote(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	return vote(session, guildID, chanID, authorID, messageID, args.user("@user"), -1)
 }
 
-func votes(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var limit int
-	if len(args) < 1 {
-		limit = 5
-	} else {
-		var err error
-		limit, err = strconv.Atoi(args[0])
-		if err != nil || limit < 0 {
-			return "", err
-		}
-	}
-	rows, err := sqlClient.Query(`SELECT user_id, karma FROM user_karma WHERE guild_id = $1 ORDER BY karma DESC LIMIT $2`, guildID, limit)
+func votes(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	limit := args.int("number")
+	karmas, err := data.Karma.TopKarma(guildID, limit)
 	if err != nil {
 		return "", err
 	}
-	defer rows.Close()
-	var votes []int
-	var users []string
-	for rows.Next() {
-		var userID string
-		var karma int
-		err := rows.Scan(&userID, &karma)
-		if err != nil {
-			return "", err
-		}
-		votes = append(votes, karma)
-		users = append(users, userID)
-	}
 	finalString := ""
-	for i, vote := range votes {
-		username, err := getUsername(session, users[i], guildID)
+	for _, karma := range karmas {
+		username, err := getUsername(session, karma.UserID, guildID)
 		if err != nil {
 			return "", err
 		}
-		finalString += fmt.Sprintf("%s — %d\n", username, vote)
+		finalString += fmt.Sprintf("%s — %d\n", username, karma.Karma)
 	}
 	return finalString, nil
 }
 
-func money(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var limit int
-	if len(args) < 1 {
-		limit = 5
-	} else {
-		var err error
-		limit, err = strconv.Atoi(args[0])
-		if err != nil || limit < 0 {
-			return "", err
-		}
-	}
-	rows, err := sqlClient.Query(`SELECT user_id, money FROM user_money WHERE guild_id = $1 ORDER BY money DESC LIMIT $2`, guildID, limit)
+func money(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	limit := args.int("number")
+	monies, err := data.Money.Top(guildID, limit)
 	if err != nil {
 		return "", err
 	}
-	defer rows.Close()
-	var monies []float64
-	var users []string
-	for rows.Next() {
-		var userID string
-		var money float64
-		err := rows.Scan(&userID, &money)
+	finalString := fmt.Sprintf("(Those not listed have %d)\n", store.StartingMoney)
+	for _, money := range monies {
+		username, err := getUsername(session, money.UserID, guildID)
 		if err != nil {
 			return "", err
 		}
-		monies = append(monies, money)
-		users = append(users, userID)
-	}
-	finalString := "(Those not listed have 10)\n"
-	for i, money := range monies {
-		username, err := getUsername(session, users[i], guildID)
-		if err != nil {
-			return "", err
-		}
-		finalString += fmt.Sprintf("%s — %.2f\n", username, money)
+		finalString += fmt.Sprintf("%s — %.2f\n", username, money.Money)
 	}
 	return finalString, nil
 }
 
-func roll(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func roll(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	var max big.Int
 	one := big.NewInt(1)
 	dice := uint64(1)
@@ -671,7 +540,7 @@ func roll(session *discordgo.Session, guildID, chanID, authorID, messageID strin
 	return message, nil
 }
 
-func uptime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func uptime(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	output, err := exec.Command("uptime").Output()
 	if err != nil {
 		return "", err
@@ -679,84 +548,37 @@ func uptime(session *discordgo.Session, guildID, chanID, authorID, messageID str
 	return strings.TrimSpace(string(output)), nil
 }
 
-func top(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var limit int
-	if len(args) < 1 {
-		limit = 5
-	} else {
-		var err error
-		limit, err = strconv.Atoi(args[0])
-		if err != nil || limit < 0 {
-			return "", err
-		}
-	}
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	rows, err := sqlClient.Query(`SELECT author_id, count(author_id) AS num_messages FROM message WHERE chan_id = $1 AND content NOT LIKE '/%' GROUP BY author_id ORDER BY count(author_id) DESC LIMIT $2`, chanIDint, limit)
+func top(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	limit := args.int("number")
+	counts, err := data.Messages.TopAuthors(chanID, limit)
 	if err != nil {
 		return "", err
 	}
-	defer rows.Close()
-	var counts []int64
-	var users []string
-	for rows.Next() {
-		var authorID string
-		var numMessages int64
-		err := rows.Scan(&authorID, &numMessages)
-		if err != nil {
-			return "", err
-		}
-		counts = append(counts, numMessages)
-		users = append(users, authorID)
-	}
 	finalString := ""
-	for i, count := range counts {
-		username, err := getUsername(session, users[i], guildID)
+	for _, count := range counts {
+		username, err := getUsername(session, count.UserID, guildID)
 		if err != nil {
 			return "", err
 		}
-		finalString += fmt.Sprintf("%s — %d\n", username, count)
+		finalString += fmt.Sprintf("%s — %d\n", username, count.Count)
 	}
 	return finalString, nil
 }
 
-func topLength(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var limit int
-	if len(args) < 1 {
-		limit = 5
-	} else {
-		var err error
-		limit, err = strconv.Atoi(args[0])
-		if err != nil || limit < 0 {
-			return "", err
-		}
-	}
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	rows, err := sqlClient.Query(`SELECT author_id, content FROM message WHERE chan_id = $1 AND content NOT LIKE '/%' AND trim(content) != ''`, chanIDint)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
+func topLength(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	limit := args.int("number")
 	messagesPerUser := make(map[string]uint)
 	wordsPerUser := make(map[string]uint)
 	urlRegex := regexp.MustCompile(`^https?:\/\/.*?\/[^[:space:]]*?$`)
-	for i := 0; rows.Next(); i++ {
-		var authorID string
-		var message string
-		err := rows.Scan(&authorID, &message)
-		if err != nil {
-			return "", err
-		}
-		if urlRegex.MatchString(message) {
-			continue
+	if err := data.Messages.Each(store.MessageFilter{ChanIDs: []string{chanID}, NoCommands: true, NoBlank: true}, func(message store.Message) error {
+		if urlRegex.MatchString(message.Content) {
+			return nil
 		}
-		messagesPerUser[authorID]++
-		wordsPerUser[authorID] += uint(len(strings.Fields(message)))
+		messagesPerUser[message.AuthorID]++
+		wordsPerUser[message.AuthorID] += uint(len(strings.Fields(message.Content)))
+		return nil
+	}); err != nil {
+		return "", err
 	}
 	avgLengths := make(stringFloatPairs, 0)
 	for userID, numMessages := range messagesPerUser {
@@ -777,31 +599,31 @@ func topLength(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return finalString, nil
 }
 
-func rename(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func rename(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	if len(args) < 1 {
 		return "", errors.New("No new username provided")
 	}
 	newUsername := strings.Join(args[0:], " ")
-	var lockedMinutes int
-	var lastChangeTime time.Time
 	now := time.Now()
-	if err := sqlClient.QueryRow(`SELECT create_date, locked_minutes FROM own_username WHERE guild_id = $1 ORDER BY create_date DESC LIMIT 1`, guildID).Scan(&lastChangeTime, &lockedMinutes); err != nil {
-		if err == sql.ErrNoRows {
-			lockedMinutes = 0
-		} else {
-			return "", err
-		}
+	last, err := data.Usernames.Latest(guildID)
+	if err != nil && err != store.ErrNotFound {
+		return "", err
 	}
 
-	if lockedMinutes == 0 || now.After(lastChangeTime.Add(time.Duration(lockedMinutes)*time.Minute)) {
-		wasNicknamed[guildID] = true
+	if last.LockedMinutes == 0 || now.After(last.CreateDate.Add(time.Duration(last.LockedMinutes)*time.Minute)) {
+		guild := bot.guild(guildID)
+		guild.Lock()
+		guild.wasNicknamed = true
+		guild.Unlock()
 		if err := session.GuildMemberNickname(guildID, "@me/nick", newUsername); err != nil {
-			wasNicknamed[guildID] = false
+			guild.Lock()
+			guild.wasNicknamed = false
+			guild.Unlock()
 			return "", err
 		}
 
-		var authorKarma int
-		if err := sqlClient.QueryRow(`SELECT karma FROM user_karma WHERE guild_id = $1 AND user_id = $2`, guildID, authorID).Scan(&authorKarma); err != nil {
+		authorKarma, err := data.Karma.Karma(guildID, authorID)
+		if err != nil {
 			authorKarma = 0
 		}
 		newLockedMinutes := rand.Intn(30) + 45 + 10*authorKarma
@@ -809,8 +631,7 @@ func rename(session *discordgo.Session, guildID, chanID, authorID, messageID str
 			newLockedMinutes = 30
 		}
 
-		if _, err := sqlClient.Exec(`INSERT INTO own_username (author_id, username, locked_minutes, guild_id) values ($1, $2, $3, $4)`,
-			authorID, newUsername, newLockedMinutes, guildID); err != nil {
+		if err := data.Usernames.Add(store.OwnUsername{GuildID: guildID, AuthorID: authorID, Username: newUsername, LockedMinutes: newLockedMinutes}); err != nil {
 			return "", err
 		}
 		username, err := getUsername(session, authorID, guildID)
@@ -828,25 +649,13 @@ func rename(session *discordgo.Session, guildID, chanID, authorID, messageID str
 	return "", nil
 }
 
-func lastseen(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if len(args) < 1 {
-		return "", errors.New("No username provided")
-	}
-	var userID string
-	var err error
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-		if err != nil {
-			return "", err
-		}
-	}
+func lastseen(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("username")
 	username, err := getUsername(session, userID, guildID)
 	if err != nil {
 		return "", err
 	}
-	guild, err := session.State.Guild(guildID)
+	guild, err := session.State().Guild(guildID)
 	if err != nil {
 		return "", err
 	}
@@ -860,36 +669,30 @@ func lastseen(session *discordgo.Session, guildID, chanID, authorID, messageID s
 	if online {
 		return fmt.Sprintf("%s is currently online", username), nil
 	}
-	var lastOnline time.Time
-	guildIDint, err := strconv.ParseUint(guild.ID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	userIDint, err := strconv.ParseUint(userID, 10, 64)
+	lastOnline, err := data.Presence.Last(store.PresenceFilter{GuildID: guild.ID, UserID: userID, Statuses: []string{"online"}})
 	if err != nil {
-		return "", err
-	}
-	if err := sqlClient.QueryRow(`SELECT create_date FROM user_presence WHERE guild_id = $1 AND user_id = $2 AND presence = 'online' ORDER BY create_date DESC LIMIT 1`, guildIDint, userIDint).Scan(&lastOnline); err != nil {
-		if err == sql.ErrNoRows {
+		if err == store.ErrNotFound {
 			return fmt.Sprintf("%s was last seen at least %.f days ago", username, time.Since(time.Date(2016, 4, 7, 1, 7, 0, 0, time.Local)).Hours()/24), nil
 		}
 		return "", err
 	}
-	var offline time.Time
-	if err := sqlClient.QueryRow(`SELECT create_date FROM user_presence WHERE guild_id = $1 AND user_id = $2 AND presence != 'online' AND create_date > $3 ORDER BY create_date ASC LIMIT 1`, guildIDint, userIDint, lastOnline).Scan(&offline); err != nil {
-		if err == sql.ErrNoRows {
+	offline, err := data.Presence.First(store.PresenceFilter{GuildID: guild.ID, UserID: userID, NotStatus: "online", After: lastOnline.CreateDate})
+	if err != nil {
+		if err == store.ErrNotFound {
 			return fmt.Sprintf("%s is currently online", username), nil
 		}
 		return "", err
 	}
-	lastSeenStr := timeSinceStr(time.Since(offline))
+	lastSeenStr := timeSinceStr(time.Since(offline.CreateDate))
 	return fmt.Sprintf("%s was last seen %s ago", username, lastSeenStr), nil
 }
 
-func deleteLastMessage(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	lastMessage, msgFound := lastMessagesByAuthor[authorID]
-	lastCommandMessage, cmdFound := lastCommandMessages[authorID]
-	if msgFound && cmdFound {
+func deleteLastMessage(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	user := bot.user(authorID)
+	user.Lock()
+	lastMessage, lastCommandMessage := user.lastMessage, user.lastCommandMessage
+	user.Unlock()
+	if len(lastMessage.ID) > 0 && len(lastCommandMessage.ID) > 0 {
 		session.ChannelMessageDelete(lastMessage.ChannelID, lastMessage.ID)
 		session.ChannelMessageDelete(lastCommandMessage.ChannelID, lastCommandMessage.ID)
 		session.ChannelMessageDelete(chanID, messageID)
@@ -897,8 +700,8 @@ func deleteLastMessage(session *discordgo.Session, guildID, chanID, authorID, me
 	return "", nil
 }
 
-func kickme(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	perm, err := session.State.UserChannelPermissions(ownUserID, chanID)
+func kickme(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	perm, err := session.State().UserChannelPermissions(ownUserID, chanID)
 	if err != nil {
 		return "", err
 	}
@@ -938,182 +741,12 @@ func kickme(session *discordgo.Session, guildID, chanID, authorID, messageID str
 	return "You wish.", nil
 }
 
-func spamuser(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string, markovOrder int) (string, error) {
-	if len(args) < 1 {
-		return "", errors.New("No username provided")
-	}
-	var userID string
-	var err error
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-		if err != nil {
-			return "", err
-		}
-	}
-	username, err := getUsername(session, userID, guildID)
-	if err != nil {
-		return "", err
-	}
-	realUserID, err := strconv.ParseUint(userID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-
-	var chanIDs uint64Array
-	guild, err := session.State.Guild(guildID)
-	if err != nil {
-		return "", err
-	}
-	for _, channel := range guild.Channels {
-		intChanID, err := strconv.ParseUint(channel.ID, 10, 64)
-		if err != nil {
-			return "", err
-		}
-		chanIDs = append(chanIDs, intChanID)
-	}
-
-	rows, err := sqlClient.Query(`SELECT content FROM message WHERE chan_id = ANY($1) AND author_id = $2`, chanIDs, realUserID)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
-	var corpus []string
-	for rows.Next() {
-		var line string
-		if err := rows.Scan(&line); err != nil {
-			return "", err
-		}
-		corpus = append(corpus, line)
-	}
-	if err := rows.Err(); err != nil {
-		return "", err
-	}
-
-	outStr := ""
-	numRows := int64(1)
-	for i := 0; i < 100 && numRows > 0; i++ {
-		switch markovOrder {
-		case 1:
-			outStr = markov.GenFirstOrder(corpus)
-		case 2:
-			outStr = markov.GenSecondOrder(corpus)
-		case 3:
-			outStr = markov.GenThirdOrder(corpus)
-		default:
-			return "", fmt.Errorf("Unrecognized markov order: %d", markovOrder)
-		}
-
-		if err != nil {
-			return "", err
-		}
-		if err := sqlClient.QueryRow(`SELECT count(id) FROM message WHERE content LIKE $1 AND author_id = $2`, fmt.Sprintf("%%%s%%", outStr), realUserID).Scan(&numRows); err != nil {
-			return "", err
-		}
-	}
-	var quoteID int64
-	if err := sqlClient.QueryRow(`INSERT INTO discord_quote(chan_id, author_id, content, score, is_fresh) VALUES ($1, $2, $3, 0, $4) RETURNING id`, chanID, userID, outStr, numRows == 0).Scan(&quoteID); err != nil {
-		fmt.Println("ERROR inserting into DiscordQuote ", err.Error())
-	} else {
-		lastQuoteIDs[chanID] = quoteID
-		userIDUpQuotes[chanID] = make([]string, 0)
-	}
-	return fmt.Sprintf("%s: %s", username, outStr), nil
-}
-
-func spamuser1(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	return spamuser(session, guildID, chanID, authorID, messageID, args, 1)
-}
-
-func spamuser2(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	return spamuser(session, guildID, chanID, authorID, messageID, args, 2)
-}
-
-func spamuser3(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	return spamuser(session, guildID, chanID, authorID, messageID, args, 3)
-}
-
-func spamdiscord(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string, markovOrder int) (string, error) {
-	var chanIDs uint64Array
-	guild, err := session.State.Guild(guildID)
-	if err != nil {
-		return "", err
-	}
-	for _, channel := range guild.Channels {
-		intChanID, err := strconv.ParseUint(channel.ID, 10, 64)
-		if err != nil {
-			return "", err
-		}
-		chanIDs = append(chanIDs, intChanID)
-	}
-
-	rows, err := sqlClient.Query(`SELECT content FROM message WHERE chan_id = ANY ($1) AND content != '' AND author_id != $2`, chanIDs, ownUserID)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
-	var corpus []string
-	for rows.Next() {
-		var line string
-		if err := rows.Scan(&line); err != nil {
-			return "", err
-		}
-		corpus = append(corpus, line)
-	}
-	if err := rows.Err(); err != nil {
-		return "", err
-	}
-
-	outStr := ""
-	numRows := int64(1)
-	for i := 0; i < 100 && numRows > 0; i++ {
-		switch markovOrder {
-		case 1:
-			outStr = markov.GenFirstOrder(corpus)
-		case 2:
-			outStr = markov.GenSecondOrder(corpus)
-		case 3:
-			outStr = markov.GenThirdOrder(corpus)
-		default:
-			return "", fmt.Errorf("Unrecognized markov order: %d", markovOrder)
-		}
-
-		if err != nil {
-			return "", err
-		}
-		if err := sqlClient.QueryRow(`SELECT count(id) FROM message WHERE content LIKE $1 AND chan_id = ANY ($2)`, fmt.Sprintf("%%%s%%", outStr), chanIDs).Scan(&numRows); err != nil {
-			return "", err
-		}
-	}
-	var quoteID int64
-	if err := sqlClient.QueryRow(`INSERT INTO discord_quote(chan_id, content, score, is_fresh) values ($1, $2, 0, $3) RETURNING id`, chanID, outStr, numRows == 0).Scan(&quoteID); err != nil {
-		fmt.Println("ERROR inserting into DiscordQuote ", err.Error())
-	} else {
-		lastQuoteIDs[chanID] = quoteID
-		userIDUpQuotes[chanID] = make([]string, 0)
-	}
-	return outStr, nil
-}
-
-func spamdiscord1(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	return spamdiscord(session, guildID, chanID, authorID, messageID, args, 1)
-}
-
-func spamdiscord2(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	return spamdiscord(session, guildID, chanID, authorID, messageID, args, 2)
-}
-
-func spamdiscord3(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	return spamdiscord(session, guildID, chanID, authorID, messageID, args, 3)
-}
-
-func maths(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func maths(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	if len(args) < 1 {
 		return "", errors.New("Can't do math without maths")
 	}
 	formula := strings.Join(args, " ")
-	res, err := http.Get(fmt.Sprintf("http://api.wolframalpha.com/v2/query?input=%s&appid=%s&format=plaintext", url.QueryEscape(formula), url.QueryEscape(wolframAppID)))
+	res, err := http.Get(fmt.Sprintf("http://api.wolframalpha.com/v2/query?input=%s&appid=%s&format=plaintext", url.QueryEscape(formula), url.QueryEscape(cfg.WolframAppID)))
 	if err != nil {
 		return "", err
 	}
@@ -1150,11 +783,11 @@ func maths(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 	return "", errors.New("No suitable answer found")
 }
 
-func define(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func define(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	return maths(session, guildID, chanID, authorID, messageID, append([]string{"define"}, args...))
 }
 
-func cputemp(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func cputemp(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	output, err := exec.Command("sensors", "coretemp-isa-0000").Output()
 	if err != nil {
 		return "", err
@@ -1163,11 +796,11 @@ func cputemp(session *discordgo.Session, guildID, chanID, authorID, messageID st
 	return fmt.Sprintf("```%s```", strings.Join(lines[2:], "\n")), nil
 }
 
-func ayy(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func ayy(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	return "lmao", nil
 }
 
-func ping(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func ping(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	output, err := exec.Command("ping", "-qc3", "discordapp.com").Output()
 	if err != nil {
 		return "", err
@@ -1176,105 +809,65 @@ func ping(session *discordgo.Session, guildID, chanID, authorID, messageID strin
 	return fmt.Sprintf("```%s```", strings.Join(lines[len(lines)-3:], "\n")), nil
 }
 
-func xd(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func xd(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	return "PUCK FALMER", nil
 }
 
-func upquote(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	lastQuoteID, found := lastQuoteIDs[chanID]
-	if !found {
+func upquote(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	channel := bot.channel(chanID)
+	channel.Lock()
+	defer channel.Unlock()
+	if channel.lastQuoteID == 0 {
 		return "I can't find what I spammed last.", nil
 	}
-	for _, userID := range userIDUpQuotes[chanID] {
-		if userID == authorID {
-			return "You've already upquoted my last spam", nil
-		}
+	if containsString(channel.upQuoters, authorID) {
+		return "You've already upquoted my last spam", nil
 	}
-	if _, err := sqlClient.Exec(`UPDATE discord_quote SET score = score + 1 WHERE id = $1`, lastQuoteID); err != nil {
+	if err := data.Quotes.Upvote(channel.lastQuoteID); err != nil {
 		return "", err
 	}
-	userIDUpQuotes[chanID] = append(userIDUpQuotes[chanID], authorID)
+	channel.upQuoters = append(channel.upQuoters, authorID)
 	return "", nil
 }
 
-func topquote(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var limit int
-	if len(args) < 1 {
-		limit = 5
-	} else {
-		var err error
-		limit, err = strconv.Atoi(args[0])
-		if err != nil || limit < 0 {
-			return "", err
-		}
-	}
-	rows, err := sqlClient.Query(`SELECT author_id, content, score FROM discord_quote WHERE chan_id = $1 AND score > 0 ORDER BY score DESC LIMIT $2`, chanID, limit)
+func topquote(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	limit := args.int("number")
+	quotes, err := data.Quotes.Top(chanID, limit)
 	if err != nil {
 		return "", err
 	}
-	defer rows.Close()
-	channel, err := session.State.Channel(chanID)
+	channel, err := session.State().Channel(chanID)
 	if err != nil {
 		return "", err
 	}
-	messages := make([]string, limit)
-	var i int
-	for i = 0; rows.Next(); i++ {
-		var authorID sql.NullString
-		var content string
-		var score int
-		err = rows.Scan(&authorID, &content, &score)
-		if err != nil {
-			return "", err
-		}
+	messages := make([]string, len(quotes))
+	for i, quote := range quotes {
 		authorName := `#` + channel.Name
-		if authorID.Valid {
-			username, err := getUsername(session, authorID.String, guildID)
+		if len(quote.AuthorID) > 0 {
+			username, err := getUsername(session, quote.AuthorID, guildID)
 			if err != nil {
 				return "", err
 			}
 			authorName = username
 		}
-		messages[i] = fmt.Sprintf("%s (%d): %s", authorName, score, content)
+		messages[i] = fmt.Sprintf("%s (%d): %s", authorName, quote.Score, quote.Content)
 	}
-	return strings.Join(messages[:i], "\n"), nil
+	return strings.Join(messages, "\n"), nil
 }
 
-func eightball(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func eightball(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	responses := []string{"It is certain", "It is decidedly so", "Without a doubt", "Yes, definitely", "You may rely on it", "As I see it, yes", "Most likely", "Outlook good", "Yes", "Signs point to yes", "Reply hazy try again", "Ask again later", "Better not tell you now", "Cannot predict now", "Concentrate and ask again", "Don't count on it", "My reply is no", "My sources say no", "Outlook not so good", "Very doubtful"}
 	return responses[rand.Intn(len(responses))], nil
 }
 
-func wlist(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var limit int
-	if len(args) < 1 {
-		limit = 5
-	} else {
-		var err error
-		limit, err = strconv.Atoi(args[0])
-		if err != nil || limit < 0 {
-			return "", err
-		}
-	}
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	rows, err := sqlClient.Query(`SELECT author_id, content FROM message WHERE chan_id = $1 AND content NOT LIKE '/%'`, chanIDint)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
+func wlist(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	limit := args.int("number")
 	countMap := make(map[string]int64)
-	for rows.Next() {
-		var authorID, message string
-		err := rows.Scan(&authorID, &message)
-		if err != nil {
-			return "", err
-		}
-		messageWords := strings.Fields(message)
+	if err := data.Messages.Each(store.MessageFilter{ChanIDs: []string{chanID}, NoCommands: true}, func(message store.Message) error {
+		authorID := message.AuthorID
+		messageWords := strings.Fields(message.Content)
 		for i, word := range messageWords {
-			_, found := wlWords[word]
+			_, found := cfg.watchlist[word]
 			if found {
 				countMap[authorID]++
 				continue
@@ -1282,7 +875,7 @@ func wlist(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 			if i+2 > len(messageWords) {
 				continue
 			}
-			_, found = wlWords[strings.Join(messageWords[i:i+2], " ")]
+			_, found = cfg.watchlist[strings.Join(messageWords[i:i+2], " ")]
 			if found {
 				countMap[authorID]++
 				continue
@@ -1290,7 +883,7 @@ func wlist(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 			if i+3 > len(messageWords) {
 				continue
 			}
-			_, found = wlWords[strings.Join(messageWords[i:i+3], " ")]
+			_, found = cfg.watchlist[strings.Join(messageWords[i:i+3], " ")]
 			if found {
 				countMap[authorID]++
 				continue
@@ -1298,27 +891,22 @@ func wlist(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 			if i+4 > len(messageWords) {
 				continue
 			}
-			_, found = wlWords[strings.Join(messageWords[i:i+4], " ")]
+			_, found = cfg.watchlist[strings.Join(messageWords[i:i+4], " ")]
 			if found {
 				countMap[authorID]++
 				continue
 			}
 		}
+		return nil
+	}); err != nil {
+		return "", err
 	}
 	var counts stringFloatPairs
 	for authorID, score := range countMap {
-		var numMessages int64
-		chanIDint, err := strconv.ParseUint(chanID, 10, 64)
-		if err != nil {
-			return "", err
-		}
-		authorIDint, err := strconv.ParseUint(authorID, 10, 64)
+		numMessages, err := data.Messages.Count(store.MessageFilter{ChanIDs: []string{chanID}, AuthorID: authorID, NoCommands: true})
 		if err != nil {
 			return "", err
 		}
-		if err := sqlClient.QueryRow(`SELECT count(id) FROM message WHERE chan_id = $1 AND author_id = $2 AND content NOT LIKE '/%'`, chanIDint, authorIDint).Scan(&numMessages); err != nil {
-			return "", err
-		}
 		counts = append(counts, stringFloatPair{authorID, float64(score) / float64(numMessages)})
 	}
 	if len(counts) == 0 {
@@ -1340,104 +928,16 @@ func wlist(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 	return strings.Join(output, "\n"), nil
 }
 
-func remindme(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	arg := strings.Join(args, " ")
-	fmt.Println(arg)
-	atTimeRegex := regexp.MustCompile(`(?i)(?:at\s+)?(\d{4}-\d{2}-\d{2}\s+\d{2}:\d{2}:\d{2}\s+[\+-]\d{4})\s+(?:to\s+)?(.*)`)
-	inTimeRegex := regexp.MustCompile(`(?i)(?:in)?\s*(?:(?:(?:(\d+)\s+years?)|(?:(\d+)\s+months?)|(?:(\d+)\s+weeks?)|(?:(\d+)\s+days?)|(?:(\d+)\s+hours?)|(?:(\d+)\s+minutes?)|(?:(\d+)\s+seconds?))(?:\sand)?\s?)+(?:to)?\s+(.*)`)
-	atMatch := atTimeRegex.FindStringSubmatch(arg)
-	inMatch := inTimeRegex.FindStringSubmatch(arg)
-	fmt.Printf("%#v\n", atMatch)
-	fmt.Printf("%#v\n", inMatch)
-	if atMatch == nil && inMatch == nil {
-		return "What?", nil
-	}
-	content := ""
-	now := time.Now()
-	var remindTime time.Time
-	var err error
-	if atMatch != nil {
-		remindTime, err = time.Parse(`2006-01-02 15:04:05 -0700`, atMatch[1])
+func meme(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	for {
+		links, err := data.Messages.Random(store.MessageFilter{ChanIDs: []string{chanID}, NotAuthorIDs: []string{ownUserID}, Links: true}, 1)
 		if err != nil {
 			return "", err
 		}
-		content = atMatch[2]
-	} else {
-		content = inMatch[8]
-		var years, months, weeks, days int
-		var hours, minutes, seconds int64
-		var err error
-		if len(inMatch[1]) > 0 {
-			years, err = strconv.Atoi(inMatch[1])
-			if err != nil {
-				return "", err
-			}
-		}
-		if len(inMatch[2]) > 0 {
-			months, err = strconv.Atoi(inMatch[2])
-			if err != nil {
-				return "", err
-			}
-		}
-		if len(inMatch[3]) > 0 {
-			weeks, err = strconv.Atoi(inMatch[3])
-			if err != nil {
-				return "", err
-			}
-		}
-		if len(inMatch[4]) > 0 {
-			days, err = strconv.Atoi(inMatch[4])
-			if err != nil {
-				return "", err
-			}
-		}
-		if len(inMatch[5]) > 0 {
-			hours, err = strconv.ParseInt(inMatch[5], 10, 64)
-			if err != nil {
-				return "", err
-			}
-		}
-		if len(inMatch[6]) > 0 {
-			minutes, err = strconv.ParseInt(inMatch[6], 10, 64)
-			if err != nil {
-				return "", err
-			}
-		}
-		if len(inMatch[7]) > 0 {
-			seconds, err = strconv.ParseInt(inMatch[7], 10, 64)
-			if err != nil {
-				return "", err
-			}
-		}
-		fmt.Printf("%dy %dm %dw %dd %dh %dm %ds\n", years, months, weeks, days, hours, minutes, seconds)
-		remindTime = now.AddDate(years, months, weeks*7+days).Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second)
-	}
-	fmt.Println(remindTime.Format(time.RFC3339))
-	if remindTime.Before(now) {
-		responses := []string{"Sorry, I lost my Delorean.", "Hold on, gotta hit 88MPH first.", "Too late.", "I'm sorry Dave, I can't do that.", ":|", "Time is a one-way street."}
-		return responses[rand.Intn(len(responses))], nil
-	}
-	var reminderId int32
-	if err := sqlClient.QueryRow(`INSERT INTO reminder (chan_id, author_id, send_time, content) VALUES ($1, $2, $3, $4) RETURNING id`, chanID, authorID, remindTime.In(time.FixedZone("UTC", 0)), content).Scan(&reminderId); err != nil {
-		return "", err
-	}
-	time.AfterFunc(remindTime.Sub(now), func() {
-		session.ChannelMessageSend(chanID, fmt.Sprintf("<@%s> %s", authorID, content))
-		sqlClient.Exec(`UPDATE reminder SET sent_at = now() WHERE id = $1`, reminderId)
-	})
-	return fmt.Sprintf("👍 %s", remindTime.Format(time.RFC1123Z)), nil
-}
-
-func meme(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var opID, link string
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	for {
-		if err := sqlClient.QueryRow(`SELECT author_id, content FROM message WHERE chan_id = $1 AND (content LIKE 'http://%' OR content LIKE 'https://%') AND author_id != $2 ORDER BY random() LIMIT 1`, chanIDint, ownUserIDint).Scan(&opID, &link); err != nil {
-			return "", err
+		if len(links) == 0 {
+			return "", errors.New("No links found")
 		}
+		opID, link := links[0].AuthorID, links[0].Content
 		res, err := http.Head(link)
 		if err != nil {
 			return "", err
@@ -1455,7 +955,7 @@ func meme(session *discordgo.Session, guildID, chanID, authorID, messageID strin
 	}
 }
 
-func bitrate(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func bitrate(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	guildChans, err := session.GuildChannels(guildID)
 	if err != nil {
 		return "", err
@@ -1478,20 +978,8 @@ func bitrate(session *discordgo.Session, guildID, chanID, authorID, messageID st
 	return fmt.Sprintf("```%s```", message), nil
 }
 
-func age(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if len(args) < 1 {
-		return "", errors.New("No username provided")
-	}
-	var userID string
-	var err error
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-		if err != nil {
-			return "", err
-		}
-	}
+func age(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("username")
 	member, err := session.GuildMember(guildID, userID)
 	if err != nil {
 		return "", err
@@ -1499,28 +987,13 @@ func age(session *discordgo.Session, guildID, chanID, authorID, messageID string
 	if member.User == nil {
 		return "", errors.New("No user found")
 	}
-	timeJoined, err := member.JoinedAt.Parse()
-	if err != nil {
-		return "", err
-	}
+	timeJoined := member.JoinedAt
 	timeSince := timeSinceStr(time.Since(timeJoined))
 	return fmt.Sprintf("%s joined this server %s ago on %s", member.User.Username, timeSince, timeJoined.Format("Jan _2, 2006")), nil
 }
 
-func userage(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if len(args) < 1 {
-		return "", errors.New("No username provided")
-	}
-	var userID string
-	var err error
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-		if err != nil {
-			return "", err
-		}
-	}
+func userage(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("username")
 	username, err := getUsername(session, userID, guildID)
 	if err != nil {
 		return "", err
@@ -1534,47 +1007,27 @@ func userage(session *discordgo.Session, guildID, chanID, authorID, messageID st
 	return fmt.Sprintf("%s joined Discord %s ago", username, timeSince), nil
 }
 
-func lastUserMessage(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if len(args) < 1 {
-		return "", errors.New("No username provided")
-	}
-	var userID string
-	var err error
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-		if err != nil {
-			return "", err
-		}
-	}
-	member, err := session.State.Member(guildID, userID)
+func lastUserMessage(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("username")
+	member, err := session.State().Member(guildID, userID)
 	if err != nil {
 		return "", err
 	}
 	if member.User == nil {
 		return "", errors.New("No user found")
 	}
-	var timeSent time.Time
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	userIDint, err := strconv.ParseUint(userID, 10, 64)
+	last, err := data.Messages.Last(store.MessageFilter{ChanIDs: []string{chanID}, AuthorID: userID})
 	if err != nil {
-		return "", err
-	}
-	if err := sqlClient.QueryRow("SELECT create_date FROM message WHERE chan_id = $1 AND author_id = $2 ORDER BY create_date DESC LIMIT 1", chanIDint, userIDint).Scan(&timeSent); err != nil {
-		if err == sql.ErrNoRows {
+		if err == store.ErrNotFound {
 			return fmt.Sprintf("I've never seen %s say anything.", member.User.Username), nil
 		}
 		return "", err
 	}
-	timeSince := timeSinceStr(time.Since(timeSent))
+	timeSince := timeSinceStr(time.Since(last.CreateDate))
 	return fmt.Sprintf("%s sent their last message %s ago", member.User.Username, timeSince), nil
 }
 
-func color(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func color(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	if len(args) < 1 {
 		return "", errors.New("No color specificed")
 	}
@@ -1616,75 +1069,38 @@ func color(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 	if err != nil {
 		return "", errors.New("Error parsing blue value")
 	}
-	red, green, blue = uint8(red64), uint8(green64), uint8(blue64)
-
-	x, y := 500, 250
-	nrgbaImage := image.NewNRGBA(image.Rectangle{image.Point{0, 0}, image.Point{x, y}})
-	for i := 0; i < x; i++ {
-		for j := 0; j < y; j++ {
-			nrgbaImage.SetNRGBA(i, j, imageColor.NRGBA{red, green, blue, alpha})
-		}
-	}
-	imageBuffer := bytes.NewBuffer(make([]byte, 0, x*y))
-	png.Encode(imageBuffer, nrgbaImage)
-
-	_, err = session.ChannelFileSend(chanID, color+".png", imageBuffer)
-	if err != nil {
-		return "", err
-	}
-	return "", nil
-}
-
-func playtime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var limit int
-	var userID, username string
-	var rows *sql.Rows
-	guildIDint, err := strconv.ParseUint(guildID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	if len(args) < 1 {
-		limit = 10
-	} else {
-		var err error
-		limit, err = strconv.Atoi(args[0])
-		if limit < 0 {
-			return "", nil
-		}
-		if err != nil { //try user mention
-			limit = 10
-			if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-				userID = match[1]
-			} else {
-				userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-				if err != nil {
-					return "", err
-				}
-			}
-			username, err = getUsername(session, userID, guildID)
-			if err != nil {
-				return "", err
-			}
-			userIDint, err := strconv.ParseUint(userID, 10, 64)
-			if err != nil {
-				return "", err
-			}
-			rows, err = sqlClient.Query(`SELECT user_id, create_date, game, presence FROM user_presence WHERE guild_id = $1 AND user_id = $2 ORDER BY create_date ASC`, guildIDint, userIDint)
-			if err != nil {
-				return "", err
-			}
+	red, green, blue = uint8(red64), uint8(green64), uint8(blue64)
+
+	x, y := 500, 250
+	nrgbaImage := image.NewNRGBA(image.Rectangle{image.Point{0, 0}, image.Point{x, y}})
+	for i := 0; i < x; i++ {
+		for j := 0; j < y; j++ {
+			nrgbaImage.SetNRGBA(i, j, imageColor.NRGBA{red, green, blue, alpha})
 		}
 	}
-	if rows == nil {
+	imageBuffer := bytes.NewBuffer(make([]byte, 0, x*y))
+	png.Encode(imageBuffer, nrgbaImage)
+
+	_, err = session.ChannelFileSend(chanID, color+".png", imageBuffer)
+	if err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+func playtime(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	limit := args.int("number")
+	var username, userID string
+	if args.has("username") {
+		userID = args.user("username")
 		var err error
-		rows, err = sqlClient.Query(`SELECT user_id, create_date, game, presence FROM user_presence WHERE guild_id = $1 AND user_id != $2 AND user_id != $3 ORDER BY create_date ASC`, guildIDint, ownUserIDint, musicBotID)
+		username, err = getUsername(session, userID, guildID)
 		if err != nil {
 			return "", err
 		}
 	}
-	defer rows.Close()
 
-	gameTimes, firstTime, longestGameLength, totalTime, err := getGameTimesFromRows(rows, limit)
+	gameTimes, firstTime, longestGameLength, totalTime, err := getGameTimes(playtimeFilter(guildID, userID, time.Time{}), limit)
 	if err != nil {
 		return "", err
 	}
@@ -1702,99 +1118,20 @@ func playtime(session *discordgo.Session, guildID, chanID, authorID, messageID s
 	return fmt.Sprintf("```%s```", message), nil
 }
 
-func recentPlaytime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	now := time.Now()
-
-	inTimeRegex := regexp.MustCompile(`(?i)(?:(?:(?:(\d+)\s+years?)|(?:(\d+)\s+months?)|(?:(\d+)\s+weeks?)|(?:(\d+)\s+days?)|(?:(\d+)\s+hours?)|(?:(\d+)\s+minutes?)|(?:(\d+)\s+seconds?))\s?)+(?:\s*(.*))?`)
-	match := inTimeRegex.FindStringSubmatch(strings.Join(args, " "))
-	if match == nil {
-		return "What?", nil
-	}
-	fmt.Printf("%#v\n", match)
-	selectionArg := match[8]
-	var err error
-	var years, months, weeks, days int
-	var hours, minutes, seconds int64
-	years, err = strconv.Atoi(match[1])
-	if err != nil {
-		days = 0
-	}
-	months, err = strconv.Atoi(match[2])
-	if err != nil {
-		days = 0
-	}
-	weeks, err = strconv.Atoi(match[3])
-	if err != nil {
-		days = 0
-	}
-	days, err = strconv.Atoi(match[4])
-	if err != nil {
-		days = 0
-	}
-	hours, err = strconv.ParseInt(match[5], 10, 64)
-	if err != nil {
-		hours = 0
-	}
-	minutes, err = strconv.ParseInt(match[6], 10, 64)
-	if err != nil {
-		minutes = 0
-	}
-	seconds, err = strconv.ParseInt(match[7], 10, 64)
-	if err != nil {
-		seconds = 0
-	}
-	err = nil
-	fmt.Printf("%dy %dm %dw %dd %dh %dm %ds\n", years, months, weeks, days, hours, minutes, seconds)
-	startTime := now.AddDate(-years, -months, -weeks*7-days).Add(time.Duration(-hours)*time.Hour + time.Duration(-minutes)*time.Minute + time.Duration(-seconds)*time.Second)
-	fmt.Println(startTime.Format(time.RFC3339))
-
-	guildIDint, err := strconv.ParseUint(guildID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	limit := 10
-	var userID, username string
-	var rows *sql.Rows
-	if len(strings.Fields(selectionArg)) >= 1 {
+func recentPlaytime(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	startTime := args.duration("duration").Before(time.Now())
+	limit := args.int("number")
+	var username, userID string
+	if args.has("username") {
+		userID = args.user("username")
 		var err error
-		limit, err = strconv.Atoi(selectionArg)
-		if limit < 0 {
-			return "", nil
-		}
-		if err != nil { //try user mention
-			err = nil
-			limit = 10
-			if match := userIDRegex.FindStringSubmatch(selectionArg); match != nil {
-				userID = match[1]
-			} else {
-				userID, err = getMostSimilarUserID(session, chanID, selectionArg)
-				if err != nil {
-					return "", err
-				}
-			}
-			username, err = getUsername(session, userID, guildID)
-			if err != nil {
-				return "", err
-			}
-			userIDint, err := strconv.ParseUint(userID, 10, 64)
-			if err != nil {
-				return "", err
-			}
-			rows, err = sqlClient.Query(`SELECT user_id, create_date, game, presence FROM user_presence WHERE guild_id = $1 AND user_id = $2 AND create_date > $3 ORDER BY create_date ASC`, guildIDint, userIDint, startTime)
-			if err != nil {
-				return "", err
-			}
+		username, err = getUsername(session, userID, guildID)
+		if err != nil {
+			return "", err
 		}
 	}
-	if rows == nil {
-		rows, err = sqlClient.Query(`SELECT user_id, create_date, game, presence FROM user_presence WHERE guild_id = $1 AND user_id != $2 AND user_id != $3 AND create_date > $4 ORDER BY create_date ASC`, guildIDint, ownUserIDint, musicBotID, startTime)
-	}
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
 
-	gameTimes, _, longestGameLength, totalTime, err := getGameTimesFromRows(rows, limit)
+	gameTimes, _, longestGameLength, totalTime, err := getGameTimes(playtimeFilter(guildID, userID, startTime), limit)
 	if err != nil {
 		return "", err
 	}
@@ -1812,68 +1149,33 @@ func recentPlaytime(session *discordgo.Session, guildID, chanID, authorID, messa
 	return fmt.Sprintf("```%s```", message), nil
 }
 
-func activity(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var rows *sql.Rows
-	var err error
+func activity(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
 	var username string
-	channel, err := session.State.Channel(chanID)
-	if err != nil {
-		return "", err
-	}
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
+	channel, err := session.State().Channel(chanID)
 	if err != nil {
 		return "", err
 	}
-	if len(args) > 0 {
-		var userID string
-		if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-			userID = match[1]
-		} else {
-			userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-			if err != nil {
-				return "", err
-			}
-		}
+	filter := store.MessageFilter{ChanIDs: []string{chanID}, NotAuthorIDs: []string{ownUserID}}
+	if args.has("username") {
+		userID := args.user("username")
 		username, err = getUsername(session, userID, guildID)
 		if err != nil {
 			return "", err
 		}
-		var userIDint uint64
-		userIDint, err = strconv.ParseUint(userID, 10, 64)
-		if err != nil {
-			return "", err
-		}
-		rows, err = sqlClient.Query(`SELECT create_date FROM message WHERE chan_id = $1 AND author_id = $2 ORDER BY create_date ASC`, chanIDint, userIDint)
-	} else {
-		rows, err = sqlClient.Query(`SELECT create_date FROM message WHERE chan_id = $1 AND author_id != $2 ORDER BY create_date ASC`, chanIDint, ownUserIDint)
-	}
-	if err != nil {
-		return "", err
+		filter = store.MessageFilter{ChanIDs: []string{chanID}, AuthorID: userID}
 	}
-	defer rows.Close()
+	loc := userLocation(authorID) //chart hours in the asker's zone
 	hourCount := make([]uint64, 24)
-	var firstTime, msgTime time.Time
-	if rows.Next() {
-		err = rows.Scan(&firstTime)
-		if err != nil {
-			return "", err
-		}
-		firstTime = firstTime.Local()
-		if err != nil {
-			return "", err
-		}
-		hourCount[firstTime.Hour()]++
-	}
-	for rows.Next() {
-		err = rows.Scan(&msgTime)
-		if err != nil {
-			return "", err
-		}
-		msgTime = msgTime.Local()
-		if err != nil {
-			return "", err
+	var firstTime time.Time
+	if err := data.Messages.Each(filter, func(message store.Message) error {
+		msgTime := message.CreateDate.In(loc)
+		if firstTime.IsZero() {
+			firstTime = msgTime
 		}
 		hourCount[msgTime.Hour()]++
+		return nil
+	}); err != nil {
+		return "", err
 	}
 
 	datapoints := ""
@@ -1917,68 +1219,33 @@ func activity(session *discordgo.Session, guildID, chanID, authorID, messageID s
 	return "", nil
 }
 
-func activityDay(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var rows *sql.Rows
-	var err error
+func activityDay(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
 	var username string
-	channel, err := session.State.Channel(chanID)
-	if err != nil {
-		return "", err
-	}
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
+	channel, err := session.State().Channel(chanID)
 	if err != nil {
 		return "", err
 	}
-	if len(args) > 0 {
-		var userID string
-		if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-			userID = match[1]
-		} else {
-			userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-			if err != nil {
-				return "", err
-			}
-		}
+	filter := store.MessageFilter{ChanIDs: []string{chanID}, NotAuthorIDs: []string{ownUserID}}
+	if args.has("username") {
+		userID := args.user("username")
 		username, err = getUsername(session, userID, guildID)
 		if err != nil {
 			return "", err
 		}
-		var userIDint uint64
-		userIDint, err = strconv.ParseUint(userID, 10, 64)
-		if err != nil {
-			return "", err
-		}
-		rows, err = sqlClient.Query(`SELECT create_date FROM message WHERE chan_id = $1 AND author_id = $2 ORDER BY create_date ASC`, chanIDint, userIDint)
-	} else {
-		rows, err = sqlClient.Query(`SELECT create_date FROM message WHERE chan_id = $1 AND author_id != $2 ORDER BY create_date ASC`, chanIDint, ownUserIDint)
-	}
-	if err != nil {
-		return "", err
+		filter = store.MessageFilter{ChanIDs: []string{chanID}, AuthorID: userID}
 	}
-	defer rows.Close()
+	loc := userLocation(authorID) //chart days in the asker's zone
 	dayCount := make([]uint64, 7)
-	var firstTime, msgTime time.Time
-	if rows.Next() {
-		err = rows.Scan(&firstTime)
-		if err != nil {
-			return "", err
-		}
-		firstTime = firstTime.Local()
-		if err != nil {
-			return "", err
-		}
-		dayCount[firstTime.Weekday()]++
-	}
-	for rows.Next() {
-		err = rows.Scan(&msgTime)
-		if err != nil {
-			return "", err
-		}
-		msgTime = msgTime.Local()
-		if err != nil {
-			return "", err
+	var firstTime time.Time
+	if err := data.Messages.Each(filter, func(message store.Message) error {
+		msgTime := message.CreateDate.In(loc)
+		if firstTime.IsZero() {
+			firstTime = msgTime
 		}
 		dayCount[msgTime.Weekday()]++
+		return nil
+	}); err != nil {
+		return "", err
 	}
 
 	datapoints := ""
@@ -2022,7 +1289,7 @@ func activityDay(session *discordgo.Session, guildID, chanID, authorID, messageI
 	return "", nil
 }
 
-func botuptime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func botuptime(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	uptime := time.Since(startTime)
 	days := "days"
 	if math.Floor(uptime.Hours()/24) == 1 {
@@ -2031,7 +1298,7 @@ func botuptime(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return fmt.Sprintf("%.f %s %02d:%02d", math.Floor(uptime.Hours()/24), days, uint64(math.Floor(uptime.Hours()))%24, uint64(math.Floor(uptime.Minutes()))%60), nil
 }
 
-func nest(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func nest(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	dateStr := time.Now().Format("20060102")
 	cmd := exec.Command("/home/ross/.gocode/src/github.com/heydabop/nesttracking/graph/graph", dateStr)
 	cmd.Dir = "/home/ross/.gocode/src/github.com/heydabop/nesttracking/graph/"
@@ -2039,22 +1306,18 @@ func nest(session *discordgo.Session, guildID, chanID, authorID, messageID strin
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("%s/%s.png", nestlogRoot, dateStr), nil
+	return fmt.Sprintf("%s/%s.png", cfg.NestlogRoot, dateStr), nil
 }
 
-func roulette(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func roulette(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	err := gambleChannelCheck(guildID, chanID)
 	if err != nil {
 		return "Please don't do that in here. Try <#190518994875318272>", nil
 	}
-	if rouletteWheelSpinningPending[guildID] {
-		return "I'm working on it.", nil
-	}
-	rouletteWheelSpinningPending[guildID] = true
-	defer func() {
-		rouletteWheelSpinningPending[guildID] = false
-	}()
-	if rouletteWheelSpinning[guildID] {
+	guild := bot.guild(guildID)
+	guild.Lock()
+	defer guild.Unlock()
+	if guild.rouletteSpinning {
 		return "Wheel is already spinning, place a bet", nil
 	}
 	value := rouletteWheelValues[rand.Intn(36)]
@@ -2065,13 +1328,14 @@ func roulette(session *discordgo.Session, guildID, chanID, authorID, messageID s
 	time.AfterFunc(40*time.Second, func() {
 		session.ChannelTyping(chanID)
 		time.Sleep(5 * time.Second)
+		bets := guild.takeBets()
 		if value == 0 {
 			session.ChannelMessageSend(chanID, "Landed on 0")
 		} else {
 			session.ChannelMessageSend(chanID, fmt.Sprintf("%s %d", colorStr, value))
 		}
 		winner := false
-		for _, bet := range rouletteBets[guildID] {
+		for _, bet := range bets {
 			betWin := false
 			for _, betSpace := range bet.WinningNumbers {
 				if betSpace == value {
@@ -2091,17 +1355,15 @@ func roulette(session *discordgo.Session, guildID, chanID, authorID, messageID s
 				}
 			}
 		}
-		if len(rouletteBets[guildID]) > 0 && !winner {
+		if len(bets) > 0 && !winner {
 			session.ChannelMessageSend(chanID, "Everyone loses!")
 		}
-		rouletteBets[guildID] = make([]userBet, 0)
-		rouletteWheelSpinning[guildID] = false
 	})
-	rouletteWheelSpinning[guildID] = true
+	guild.rouletteSpinning = true
 	return "Spinning...", nil
 }
 
-func bet(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func bet(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	err := gambleChannelCheck(guildID, chanID)
 	if err != nil {
 		return "Please don't do that in here. Try <#190518994875318272>", nil
@@ -2165,11 +1427,16 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 		}
 		return "", nil
 	}
-	if !rouletteWheelSpinning[guildID] {
+	guild := bot.guild(guildID)
+	guild.Lock()
+	spinning := guild.rouletteSpinning
+	guild.Unlock()
+	if !spinning {
 		return "The wheel must be spinning to place a bet. Try /spin", nil
 	}
 	var bet float64
 	var spaces []int
+	var placed userBet
 	betArgs := make([]string, len(args)-1)
 	betArgs[0] = args[0]
 	for i := 1; i < len(betArgs); i++ {
@@ -2181,7 +1448,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 		if err != nil {
 			return "", err
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, spaces, 35, bet})
+		placed = userBet{authorID, spaces, 35, bet}
 	case "split":
 		if len(args) < 3 {
 			return "", errors.New("Missing number(s) in split bet")
@@ -2191,7 +1458,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 			return "", err
 		}
 		if (spaces[0] != spaces[1]) && (((spaces[0]-1)/3 == (spaces[1]-1)/3 && int(math.Abs(float64(spaces[1]-spaces[0]))) == 1) || int(math.Abs(float64(spaces[1]-spaces[0]))) == 3 || ((spaces[0] == 0 || spaces[1] == 0) && int(math.Abs(float64(spaces[1]-spaces[0]))) <= 3)) {
-			rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, spaces, 17, bet})
+			placed = userBet{authorID, spaces, 17, bet}
 		} else {
 			return "", fmt.Errorf("Spaces %v aren't adjacent", spaces)
 		}
@@ -2214,7 +1481,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 				}
 			}
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, spaces, 11, bet})
+		placed = userBet{authorID, spaces, 11, bet}
 	case "corner":
 		bet, spaces, err = getBetDetails(guildID, authorID, betArgs, 4)
 		if err != nil {
@@ -2228,7 +1495,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 		if spaces[1]-spaces[0] != 1 || spaces[3]-spaces[2] != 1 || (spaces[0]-1)/3 != (spaces[1]-1)/3 || (spaces[2]-1)/3 != (spaces[3]-1)/3 || ((spaces[2]-1)/3)-((spaces[0]-1)/3) != 1 || ((spaces[3]-1)/3)-((spaces[1]-1)/3) != 1 {
 			return "", fmt.Errorf("Spaces %v aren't all adjacent. Note that spaces should be entered in ascending order. 16 17 19 20 isn't treated the same as 19 20 16 17", spaces)
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, spaces, 8, bet})
+		placed = userBet{authorID, spaces, 8, bet}
 	case "six":
 		bet, spaces, err = getBetDetails(guildID, authorID, betArgs, 2)
 		if err != nil {
@@ -2250,7 +1517,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 				}
 			}
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, betSpaces, 5, bet})
+		placed = userBet{authorID, betSpaces, 5, bet}
 	case "trio":
 		bet, spaces, err = getBetDetails(guildID, authorID, betArgs, 2)
 		if err != nil {
@@ -2260,7 +1527,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 			return "", errors.New("Trio bet is only valid with 1 and 2 or 2 and 3")
 		}
 		spaces = append(spaces, 0)
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, spaces, 11, bet})
+		placed = userBet{authorID, spaces, 11, bet}
 	case "low":
 		bet, _, err = getBetDetails(guildID, authorID, betArgs, 0)
 		if err != nil {
@@ -2270,7 +1537,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 		for i := 0; i < 18; i++ {
 			betSpaces[i] = i + 1
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, betSpaces, 1, bet})
+		placed = userBet{authorID, betSpaces, 1, bet}
 	case "high":
 		bet, _, err = getBetDetails(guildID, authorID, betArgs, 0)
 		if err != nil {
@@ -2280,7 +1547,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 		for i := 0; i < 18; i++ {
 			betSpaces[i] = i + 19
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, betSpaces, 1, bet})
+		placed = userBet{authorID, betSpaces, 1, bet}
 	case "red":
 		bet, _, err = getBetDetails(guildID, authorID, betArgs, 0)
 		if err != nil {
@@ -2292,7 +1559,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 				betSpaces = append(betSpaces, i+1)
 			}
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, betSpaces, 1, bet})
+		placed = userBet{authorID, betSpaces, 1, bet}
 	case "black":
 		bet, _, err = getBetDetails(guildID, authorID, betArgs, 0)
 		if err != nil {
@@ -2304,7 +1571,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 				betSpaces = append(betSpaces, i+1)
 			}
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, betSpaces, 1, bet})
+		placed = userBet{authorID, betSpaces, 1, bet}
 	case "even":
 		bet, _, err = getBetDetails(guildID, authorID, betArgs, 0)
 		if err != nil {
@@ -2316,7 +1583,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 				betSpaces = append(betSpaces, i)
 			}
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, betSpaces, 1, bet})
+		placed = userBet{authorID, betSpaces, 1, bet}
 	case "odd":
 		bet, _, err = getBetDetails(guildID, authorID, betArgs, 0)
 		if err != nil {
@@ -2328,7 +1595,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 				betSpaces = append(betSpaces, i)
 			}
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, betSpaces, 1, bet})
+		placed = userBet{authorID, betSpaces, 1, bet}
 	case "dozen":
 		bet, spaces, err = getBetDetails(guildID, authorID, betArgs, 1)
 		if err != nil {
@@ -2341,7 +1608,7 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 		for i := 12 * (spaces[0] - 1); i < 12*spaces[0]; i++ {
 			betSpaces = append(betSpaces, i+1)
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, betSpaces, 2, bet})
+		placed = userBet{authorID, betSpaces, 2, bet}
 	case "column":
 		bet, spaces, err = getBetDetails(guildID, authorID, betArgs, 1)
 		if err != nil {
@@ -2354,16 +1621,19 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 		for i, row := range rouletteTableValues {
 			betSpaces[i] = row[spaces[0]-1]
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, betSpaces, 2, bet})
+		placed = userBet{authorID, betSpaces, 2, bet}
 	case "snake":
 		bet, _, err = getBetDetails(guildID, authorID, betArgs, 0)
 		if err != nil {
 			return "", err
 		}
-		rouletteBets[guildID] = append(rouletteBets[guildID], userBet{authorID, []int{1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, 34}, 2, bet})
+		placed = userBet{authorID, []int{1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, 34}, 2, bet}
 	default:
 		return "", errors.New("Unrecognized bet type")
 	}
+	if !guild.placeBet(placed) {
+		return "The wheel stopped before your bet was placed", nil
+	}
 	err = changeMoney(guildID, authorID, -bet)
 	if err != nil {
 		return "", err
@@ -2371,38 +1641,14 @@ Snake - snake - on 1, 5, 9, 12, 14, 16, 19, 23, 27, 30, 32, or 34`+"```")
 	return "", nil
 }
 
-func give(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if len(args) < 2 {
-		return "", errors.New("/give <user> <amount>")
-	}
-
-	var giveeID string
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		giveeID = match[1]
-	} else {
-		return "", errors.New("No valid mention found")
-	}
+func give(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	giveeID := args.user("@user")
+	amount := args.amount("amount")
 
-	var amount float64
-	var err error
-	if amount, err = strconv.ParseFloat(args[1], 64); err != nil {
+	money, err := data.Money.Balance(guildID, authorID)
+	if err != nil {
 		return "", err
 	}
-	if amount < 0.1 {
-		return "", errors.New("Given amount must be at least 0.1")
-	}
-
-	var money float64
-	if err := sqlClient.QueryRow(`SELECT money FROM user_money WHERE guild_id = $1 AND user_id = $2`, guildID, authorID).Scan(&money); err != nil {
-		if err == sql.ErrNoRows {
-			money = 10
-			if _, err := sqlClient.Exec(`INSERT INTO user_money(guild_id, user_id, money) VALUES ($1, $2, $3)`, guildID, authorID, money); err != nil {
-				return "", err
-			}
-		} else {
-			return "", err
-		}
-	}
 	if money < amount {
 		return "", errors.New("Like you can afford that.")
 	}
@@ -2417,69 +1663,44 @@ func give(session *discordgo.Session, guildID, chanID, authorID, messageID strin
 	return fmt.Sprintf("Transaction complete. Don't spend it all in one place <@%s>...or do, whatever.", giveeID), nil
 }
 
-func topcommand(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func topcommand(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	if len(args) < 1 {
 		return "", errors.New("No command provided")
 	}
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	rows, err := sqlClient.Query(`SELECT author_id, count(author_id) FROM message WHERE content LIKE $1 AND chan_id = $2 GROUP BY author_id ORDER BY count(author_id) DESC`, fmt.Sprintf(`/%s%%`, args[0]), chanIDint)
+	counts, err := data.Messages.CountByAuthor(store.MessageFilter{ChanIDs: []string{chanID}, Prefix: "/" + args[0]})
 	if err != nil {
 		return "", err
 	}
-	defer rows.Close()
 	message := ""
-	for rows.Next() {
-		var userID string
-		var count int64
-		err := rows.Scan(&userID, &count)
-		if err != nil {
-			return "", err
-		}
-		username, err := getUsername(session, userID, guildID)
+	for _, count := range counts {
+		username, err := getUsername(session, count.UserID, guildID)
 		if err != nil {
 			return "", err
 		}
-		message += fmt.Sprintf("%s — %d\n", username, count)
+		message += fmt.Sprintf("%s — %d\n", username, count.Count)
 	}
 	return message, nil
 }
 
-func gameactivity(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	guild, err := session.State.Guild(guildID)
-	if err != nil {
-		return "", err
-	}
-	guildIDint, err := strconv.ParseUint(guild.ID, 10, 64)
+func gameactivity(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	guild, err := session.State().Guild(guildID)
 	if err != nil {
 		return "", err
 	}
-	var rows *sql.Rows
-	var enteredGame string
+	filter := store.PresenceFilter{GuildID: guild.ID, NotUserIDs: []string{ownUserID}}
+	enteredGame := "All Games"
 	if len(args) > 0 {
 		enteredGame = strings.Join(args, " ")
-		rows, err = sqlClient.Query(`SELECT user_id, create_date, game FROM user_presence  WHERE guild_id = $1 AND user_id != $2 AND (lower(game) = $3 OR game = '') ORDER BY create_date ASC`, guildIDint, ownUserIDint, strings.ToLower(enteredGame))
-	} else {
-		enteredGame = "All Games"
-		rows, err = sqlClient.Query(`SELECT user_id, create_date, game FROM user_presence WHERE guild_id = $1 AND user_id != $2 ORDER BY create_date ASC`, guildIDint, ownUserIDint)
-	}
-	if err != nil {
-		return "", err
+		filter.GameOrIdle = enteredGame
 	}
-	defer rows.Close()
+	loc := userLocation(authorID) //chart hours in the asker's zone
 	hourCount := make([]uint64, 24)
 	userStarted := make(map[string]time.Time)
 	userGame := make(map[string]string)
 	firstTime := time.Now()
-	for rows.Next() {
-		var userID, game string
-		var currTime time.Time
-		err := rows.Scan(&userID, &currTime, &game)
-		if err != nil {
-			return "", err
-		}
+	if err := data.Presence.Each(filter, func(record store.PresenceRecord) error {
+		userID, game := record.UserID, record.Game
+		currTime := record.CreateDate.In(loc)
 		if currTime.Before(firstTime) {
 			firstTime = currTime
 		}
@@ -2489,9 +1710,9 @@ func gameactivity(session *discordgo.Session, guildID, chanID, authorID, message
 		if !timeFound || (gameFound && len(lastGame) == 0) {
 			userStarted[userID] = currTime
 			userGame[userID] = game
-			continue
+			return nil
 		} else if game == lastGame {
-			continue
+			return nil
 		} else {
 			if currTime.Hour() == lastTime.Hour() {
 				hourCount[currTime.Hour()] += uint64(currTime.Minute() - lastTime.Minute())
@@ -2514,6 +1735,9 @@ func gameactivity(session *discordgo.Session, guildID, chanID, authorID, message
 			userStarted[userID] = currTime
 			userGame[userID] = game
 		}
+		return nil
+	}); err != nil {
+		return "", err
 	}
 
 	datapoints := ""
@@ -2558,7 +1782,7 @@ func gameactivity(session *discordgo.Session, guildID, chanID, authorID, message
 	return "", nil
 }
 
-func invite(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func invite(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	neededPermissions := discordgo.PermissionViewChannel |
 		discordgo.PermissionSendMessages |
 		discordgo.PermissionManageMessages |
@@ -2572,14 +1796,10 @@ func invite(session *discordgo.Session, guildID, chanID, authorID, messageID str
 		discordgo.PermissionKickMembers |
 		discordgo.PermissionManageChannels |
 		0x4000000
-	return fmt.Sprintf("https://discordapp.com/oauth2/authorize?client_id=%s&scope=bot&permissions=0x%X", appID, neededPermissions), nil
+	return fmt.Sprintf("https://discordapp.com/oauth2/authorize?client_id=%s&scope=bot%%20applications.commands&permissions=0x%X", cfg.AppID, neededPermissions), nil
 }
 
-func updateAvatar(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if authorID != adminID {
-		return "", nil
-	}
-
+func updateAvatar(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	avatar, err := os.Open("avatar.png")
 	if err != nil {
 		return "", err
@@ -2603,7 +1823,7 @@ func updateAvatar(session *discordgo.Session, guildID, chanID, authorID, message
 		return "", err
 	}
 
-	_, err = session.UserUpdate("", "", self.Username, avatarBase64, "")
+	_, err = session.UserUpdate(self.Username, avatarBase64)
 	if err != nil {
 		return "", err
 	}
@@ -2611,25 +1831,13 @@ func updateAvatar(session *discordgo.Session, guildID, chanID, authorID, message
 	return "", nil
 }
 
-func lastPlayed(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if len(args) < 1 {
-		return "", errors.New("No username provided")
-	}
-	var userID string
-	var err error
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-		if err != nil {
-			return "", err
-		}
-	}
+func lastPlayed(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("username")
 	username, err := getUsername(session, userID, guildID)
 	if err != nil {
 		return "", err
 	}
-	guild, err := session.State.Guild(guildID)
+	guild, err := session.State().Guild(guildID)
 	if err != nil {
 		return "", err
 	}
@@ -2645,27 +1853,18 @@ func lastPlayed(session *discordgo.Session, guildID, chanID, authorID, messageID
 			return fmt.Sprintf("%s is currently playing %s", username, g.Name), nil
 		}
 	}
-	guildIDint, err := strconv.ParseUint(guild.ID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	userIDint, err := strconv.ParseUint(userID, 10, 64)
+	lastPlayed, err := data.Presence.Last(store.PresenceFilter{GuildID: guild.ID, UserID: userID, Playing: true})
 	if err != nil {
-		return "", err
-	}
-	var lastPlayedGame string
-	var lastPlayed time.Time
-	if err := sqlClient.QueryRow(`SELECT create_date, game FROM user_presence WHERE guild_id = $1 AND user_id = $2 AND game != '' ORDER BY create_date DESC LIMIT 1`, guildIDint, userIDint).Scan(&lastPlayed, &lastPlayedGame); err != nil {
-		if err == sql.ErrNoRows {
+		if err == store.ErrNotFound {
 			return fmt.Sprintf("I've never seen %s play anything...", username), nil
 		}
 		return "", err
 	}
-	lastSeenStr := timeSinceStr(time.Since(lastPlayed))
-	return fmt.Sprintf("%s last played %s %s ago", username, lastPlayedGame, lastSeenStr), nil
+	lastSeenStr := timeSinceStr(time.Since(lastPlayed.CreateDate))
+	return fmt.Sprintf("%s last played %s %s ago", username, lastPlayed.Game, lastSeenStr), nil
 }
 
-func whois(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func whois(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	if len(args) < 1 {
 		return "", nil
 	}
@@ -2676,8 +1875,8 @@ func whois(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 	return username, nil
 }
 
-func permission(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	perm, err := session.State.UserChannelPermissions(ownUserID, chanID)
+func permission(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	perm, err := session.State().UserChannelPermissions(ownUserID, chanID)
 	if err != nil {
 		return "", err
 	}
@@ -2686,25 +1885,7 @@ func permission(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return "", nil
 }
 
-func voicekick(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	member, err := session.State.Member(guildID, authorID)
-	if err != nil {
-		return "", err
-	}
-	authorized := false
-	for _, roleID := range member.Roles {
-		role, err := session.State.Role(guildID, roleID)
-		if err != nil {
-			return "", err
-		}
-		if role.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator || role.Permissions&discordgo.PermissionVoiceMoveMembers == discordgo.PermissionVoiceMoveMembers {
-			authorized = true
-			break
-		}
-	}
-	if !authorized {
-		return "", nil
-	}
+func voicekick(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	if len(args) < 1 {
 		return "", errors.New("No userID provided")
 	}
@@ -2718,7 +1899,7 @@ func voicekick(session *discordgo.Session, guildID, chanID, authorID, messageID
 		return "", errors.New("No valid mentions found")
 	}
 
-	perm, err := session.State.UserChannelPermissions(ownUserID, chanID)
+	perm, err := session.State().UserChannelPermissions(ownUserID, chanID)
 	if err != nil {
 		return "", err
 	}
@@ -2744,82 +1925,42 @@ func voicekick(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return "", nil
 }
 
-func timeout(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	member, err := session.State.Member(guildID, authorID)
-	if err != nil {
-		return "", err
+func timeout(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	timeoutChanID := getGuildSettings(guildID).timeoutChanID
+	if len(timeoutChanID) == 0 {
+		return "", errors.New("This server has no timeout channel, set one with config timeout")
 	}
-	authorized := false
-	for _, roleID := range member.Roles {
-		role, err := session.State.Role(guildID, roleID)
-		if err != nil {
-			return "", err
-		}
-		if role.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator || role.Permissions&discordgo.PermissionVoiceMoveMembers == discordgo.PermissionVoiceMoveMembers {
-			authorized = true
-			break
-		}
-	}
-	if !authorized {
-		return "", nil
-	}
-	if len(args) < 1 {
-		return "", errors.New("No userID provided")
-	}
-	var userIDs []string
-	for _, arg := range args {
-		if match := userIDRegex.FindStringSubmatch(arg); match != nil {
-			userIDs = append(userIDs, match[1])
-		}
-	}
-	if len(userIDs) < 1 {
-		return "", errors.New("No valid mentions found")
+	if !hasPermission(session, guildID, timeoutChanID, ownUserID, discordgo.PermissionVoiceMoveMembers) {
+		return "I can't do that", nil
 	}
 
-	perm, err := session.State.UserChannelPermissions(ownUserID, chanID)
-	if err != nil {
-		return "", err
+	duration := defaultTimeout
+	if args.has("duration") {
+		now := time.Now()
+		duration = args.duration("duration").After(now).Sub(now)
 	}
-	if perm&discordgo.PermissionVoiceMoveMembers != discordgo.PermissionVoiceMoveMembers {
-		return "I can't do that", nil
+	userID := args.user("@user")
+	if err := moderate(session, guildID, userID, authorID, moderationTimeout, args.text("reason"), duration); err != nil {
+		return "", err
 	}
-
-	now := time.Now()
-	timeout := timeoutChanID
-	for _, userID := range userIDs {
-		err = session.GuildMemberMove(timeoutGuildID, userID, &timeout)
-		if err != nil {
-			fmt.Println("ERROR in timeout", err)
-		}
-		timeoutedUserIDs[userID] = now
+	//if they aren't in voice, handleVoiceUpdate moves them when they join
+	if err := session.GuildMemberMove(guildID, userID, &timeoutChanID); err != nil {
+		fmt.Println("ERROR in timeout", err)
 	}
 	return "", nil
 }
 
-func topOnline(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	guildIDint, err := strconv.ParseUint(guildID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	rows, err := sqlClient.Query(`SELECT user_id, presence = 'online' AS online, create_date FROM user_presence WHERE guild_id = $1 AND (presence = 'online' OR presence = 'offline') AND create_date > '2016-08-30'`, guildIDint)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
+func topOnline(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	filter := store.PresenceFilter{GuildID: guildID, Statuses: []string{"online", "offline"}, After: time.Date(2016, 8, 30, 0, 0, 0, 0, time.Local)}
 	userOnline := make(map[string]bool)
 	var usersOnline, maxOnline int
 	var maxTime time.Time
 	var maxUserOnline []string
-	for rows.Next() {
-		var userID string
-		var currTime time.Time
-		var online bool
-		if err := rows.Scan(&userID, &online, &currTime); err != nil {
-			return "", err
-		}
+	if err := data.Presence.Each(filter, func(record store.PresenceRecord) error {
+		userID, currTime, online := record.UserID, record.CreateDate, record.Status == "online"
 		if lastOnline, found := userOnline[userID]; found {
 			if lastOnline == online {
-				continue
+				return nil
 			}
 			if !lastOnline {
 				usersOnline++
@@ -2847,8 +1988,8 @@ func topOnline(session *discordgo.Session, guildID, chanID, authorID, messageID
 		if usersOnline < 0 {
 			fmt.Println("uh oh")
 		}
-	}
-	if err := rows.Err(); err != nil {
+		return nil
+	}); err != nil {
 		return "", err
 	}
 	onlineUsernames := make([]string, len(maxUserOnline))
@@ -2862,7 +2003,7 @@ func topOnline(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return fmt.Sprintf("The following %d users were online on %s\n%s", maxOnline, maxTime.Format("Jan _2, 2006"), strings.Join(onlineUsernames, ", ")), nil
 }
 
-func ooer(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func ooer(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	arg := strings.Join(args, " ")
 	var message []rune
 	for _, r := range arg {
@@ -2871,7 +2012,7 @@ func ooer(session *discordgo.Session, guildID, chanID, authorID, messageID strin
 	return string(message), nil
 }
 
-func superooer(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func superooer(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	message := strings.Join(args, " ")
 	var err error
 	for i := 0; i < 10; i++ {
@@ -2883,7 +2024,7 @@ func superooer(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return message, err
 }
 
-func serverAge(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func serverAge(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	intGuildID, err := strconv.ParseUint(guildID, 10, 64)
 	if err != nil {
 		return "", err
@@ -2893,7 +2034,7 @@ func serverAge(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return fmt.Sprintf("This server was created %s ago on %s", timeSinceStr(time.Since(creationTime)), creationTime.Format("Jan 02, 2006")), nil
 }
 
-func track(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func track(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	if len(args) < 2 {
 		return "", errors.New("Missing carrier or tracking number")
 	}
@@ -2905,7 +2046,7 @@ func track(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 	}
 
 	if status.TrackingStatus.Status != "DELIVERED" && status.TrackingStatus.Status != "FAILURE" {
-		if _, err := sqlClient.Exec(`INSERT INTO shipment(carrier, tracking_number, chan_id, author_id) VALUES ($1, $2, $3, $4)`, status.Carrier, status.TrackingNumber, chanID, authorID); err != nil {
+		if err := data.Shipments.Add(store.Shipment{Carrier: status.Carrier, TrackingNumber: status.TrackingNumber, ChanID: chanID, AuthorID: authorID}); err != nil {
 			fmt.Println("ERROR insert into Shipment", err)
 		}
 	}
@@ -2924,58 +2065,29 @@ func track(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 		}
 	case "DELIVERED":
 		message = fmt.Sprintf("Your shipment was delivered at %s", status.TrackingStatus.StatusDate.Format(time.RFC1123Z))
-	case "RETURNED":
-		message = "Your shipment is being or has been returned to sender"
-	case "FAILURE":
-		message = fmt.Sprintf("There was an issue with delivery: %s", status.TrackingStatus.StatusDetails)
-	default:
-		return "", fmt.Errorf("Unrecognized status: %s", status.TrackingStatus.Status)
-	}
-	return message, nil
-}
-
-func greentext(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	numMessages := rand.Intn(5) + 3
-	var rows *sql.Rows
-	var err error
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
-	if err != nil {
-		return "", err
-	}
-	if len(args) > 0 {
-		var userID string
-		if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-			userID = match[1]
-		} else {
-			userID, err = getMostSimilarUserID(session, chanID, strings.Join(args, " "))
-			if err != nil {
-				return "", err
-			}
-		}
-		var userIDint uint64
-		userIDint, err = strconv.ParseUint(userID, 10, 64)
-		if err != nil {
-			return "", err
-		}
-		rows, err = sqlClient.Query(`SELECT content FROM message WHERE chan_id = $1 AND author_id = $2 AND length(content) > 0 AND content NOT LIKE '%
-%' ORDER BY random() LIMIT $3`, chanIDint, userIDint, numMessages)
-	} else {
-		rows, err = sqlClient.Query(`SELECT content FROM message WHERE chan_id = $1 AND author_id != $2 AND length(content) > 0 AND content NOT LIKE '%
-%' ORDER BY random() LIMIT $3`, chanIDint, ownUserIDint, numMessages)
+	case "RETURNED":
+		message = "Your shipment is being or has been returned to sender"
+	case "FAILURE":
+		message = fmt.Sprintf("There was an issue with delivery: %s", status.TrackingStatus.StatusDetails)
+	default:
+		return "", fmt.Errorf("Unrecognized status: %s", status.TrackingStatus.Status)
+	}
+	return message, nil
+}
+
+func greentext(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	numMessages := rand.Intn(5) + 3
+	filter := store.MessageFilter{ChanIDs: []string{chanID}, NotAuthorIDs: []string{ownUserID}, NoBlank: true, SingleLine: true}
+	if args.has("username") {
+		filter = store.MessageFilter{ChanIDs: []string{chanID}, AuthorID: args.user("username"), NoBlank: true, SingleLine: true}
 	}
+	picked, err := data.Messages.Random(filter, numMessages)
 	if err != nil {
 		return "", err
 	}
 	messages := make([]string, 0, numMessages)
-	for rows.Next() {
-		var message string
-		if err := rows.Scan(&message); err != nil {
-			return "", err
-		}
-		messages = append(messages, strings.Replace(message, `'`, `\'`, -1))
-	}
-	if err := rows.Err(); err != nil {
-		return "", err
+	for _, message := range picked {
+		messages = append(messages, strings.Replace(message.Content, `'`, `\'`, -1))
 	}
 	drawArg := ""
 	for i, message := range messages {
@@ -3015,24 +2127,22 @@ func greentext(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return "", nil
 }
 
-func totalMessages(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	var messages uint64
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
+func totalMessages(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	filter := store.MessageFilter{ChanIDs: []string{chanID}}
+	messages, err := data.Messages.Count(filter)
 	if err != nil {
 		return "", err
 	}
-	if err := sqlClient.QueryRow(`SELECT count(id) FROM message WHERE chan_id = $1`, chanIDint).Scan(&messages); err != nil {
-		return "", err
-	}
-	var firstTime time.Time
-	if err := sqlClient.QueryRow(`SELECT create_date FROM message WHERE chan_id = $1 ORDER BY create_date ASC LIMIT 1`, chanIDint).Scan(&firstTime); err != nil {
+	first, err := data.Messages.First(filter)
+	if err != nil {
 		return "", err
 	}
+	firstTime := first.CreateDate
 	timeSince := time.Since(firstTime)
 	return fmt.Sprintf("%d messages have been sent in this channel since %s\nThat's %.2f per day or %.2f per hour", messages, firstTime.Format(time.RFC1123Z), float64(messages)/(timeSince.Hours()/24), float64(messages)/timeSince.Hours()), nil
 }
 
-func totalServers(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func totalServers(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	userGuilds, err := session.UserGuilds(100, "", "")
 	if err != nil {
 		return "", err
@@ -3040,11 +2150,11 @@ func totalServers(session *discordgo.Session, guildID, chanID, authorID, message
 	return fmt.Sprintf("I am currently a member of %d servers", len(userGuilds)), nil
 }
 
-func source(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func source(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	return "https://github.com/heydabop/disgo", nil
 }
 
-func jpg(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func jpg(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	messages, err := session.ChannelMessages(chanID, 50, messageID, "", "")
 	if err != nil {
 		return "", nil
@@ -3099,7 +2209,7 @@ func jpg(session *discordgo.Session, guildID, chanID, authorID, messageID string
 	return "I was unable to find a recently embedded image", nil
 }
 
-func giffy(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func giffy(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	messages, err := session.ChannelMessages(chanID, 50, messageID, "", "")
 	if err != nil {
 		return "", nil
@@ -3154,7 +2264,7 @@ func giffy(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 	return "I was unable to find a recently embedded image", nil
 }
 
-func ascii(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func ascii(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	return "```" + `
 ________________$$$$
 ______________$$____$$
@@ -3181,159 +2291,48 @@ __________$$$$$$$$$$$$$$$$$$$$
 ` + "```", nil
 }
 
-func ignore(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	member, err := session.State.Member(guildID, authorID)
-	if err != nil {
+func ignore(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("@user")
+	minutes := args.int("minutes")
+	if err := moderate(session, guildID, userID, authorID, moderationIgnore, args.text("reason"), time.Duration(minutes)*time.Minute); err != nil {
 		return "", err
 	}
-	admin := false
-	for _, roleID := range member.Roles {
-		role, err := session.State.Role(guildID, roleID)
-		if err != nil {
-			return "", err
-		}
-		if role.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator {
-			admin = true
-			break
-		}
-	}
-	if !admin {
-		return "I don't have to listen to you.", nil
-	}
-
-	if len(args) < 1 {
-		return "", errors.New("No user provided")
-	}
-	var userID string
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		return "", errors.New("No valid mention found")
-	}
-	minutes := 5
-	if len(args) > 1 {
-		argMinutes, err := strconv.Atoi(args[1])
-		if err != nil {
-			return "", err
-		}
-		minutes = argMinutes
-	}
-	ignoredUserIDs[[2]string{guildID, userID}] = time.Now().Add(time.Duration(minutes) * time.Minute)
 
 	return "", nil
 }
 
-func unignore(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	member, err := session.State.Member(guildID, authorID)
-	if err != nil {
+func unignore(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("@user")
+	if err := liftModeration(session, guildID, userID, moderationIgnore, authorID); err != nil {
 		return "", err
 	}
-	admin := false
-	for _, roleID := range member.Roles {
-		role, err := session.State.Role(guildID, roleID)
-		if err != nil {
-			return "", err
-		}
-		if role.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator {
-			admin = true
-			break
-		}
-	}
-	if !admin {
-		return "I don't have to listen to you.", nil
-	}
-
-	if len(args) < 1 {
-		return "", errors.New("No user provided")
-	}
-	var userID string
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		return "", errors.New("No valid mention found")
-	}
-	ignoredUserIDs[[2]string{guildID, userID}] = time.Now()
 
 	return "", nil
 }
 
-func mute(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	member, err := session.State.Member(guildID, authorID)
-	if err != nil {
-		return "", err
-	}
-	admin := false
-	for _, roleID := range member.Roles {
-		role, err := session.State.Role(guildID, roleID)
-		if err != nil {
-			return "", err
-		}
-		if role.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator {
-			admin = true
-			break
-		}
-	}
-	if !admin {
-		return "I don't have to listen to you.", nil
-	}
-
-	if len(args) < 1 {
-		return "", errors.New("No user provided")
-	}
-	var userID string
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		return "", errors.New("No valid mention found")
+func mute(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("@user")
+	minutes := args.int("minutes")
+	if getGuildSettings(guildID).muteBackend == muteByTimeout && time.Duration(minutes)*time.Minute > maxDiscordTimeout {
+		return "", errors.New("Discord timeouts can't be longer than 28 days")
 	}
-	minutes := 5
-	if len(args) > 1 {
-		argMinutes, err := strconv.Atoi(args[1])
-		if err != nil {
-			return "", err
-		}
-		minutes = argMinutes
+	if err := moderate(session, guildID, userID, authorID, moderationMute, args.text("reason"), time.Duration(minutes)*time.Minute); err != nil {
+		return "", err
 	}
-	mutedUserIDs[[2]string{guildID, userID}] = time.Now().Add(time.Duration(minutes) * time.Minute)
 
 	return "", nil
 }
 
-func unmute(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	member, err := session.State.Member(guildID, authorID)
-	if err != nil {
+func unmute(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("@user")
+	if err := liftModeration(session, guildID, userID, moderationMute, authorID); err != nil {
 		return "", err
 	}
-	admin := false
-	for _, roleID := range member.Roles {
-		role, err := session.State.Role(guildID, roleID)
-		if err != nil {
-			return "", err
-		}
-		if role.Permissions&discordgo.PermissionAdministrator == discordgo.PermissionAdministrator {
-			admin = true
-			break
-		}
-	}
-	if !admin {
-		return "I don't have to listen to you.", nil
-	}
-
-	if len(args) < 1 {
-		return "", errors.New("No user provided")
-	}
-	var userID string
-	if match := userIDRegex.FindStringSubmatch(args[0]); match != nil {
-		userID = match[1]
-	} else {
-		return "", errors.New("No valid mention found")
-	}
-	mutedUserIDs[[2]string{guildID, userID}] = time.Now()
 
 	return "", nil
 }
 
-func dolphin(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func dolphin(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	dolphinField := discordgo.MessageEmbedField{
 		Name:  "Dolphin 5.0 Download",
 		Value: "http://dl-mirror.dolphin-emu.org/5.0/dolphin-x64-5.0.exe",
@@ -3344,7 +2343,7 @@ func dolphin(session *discordgo.Session, guildID, chanID, authorID, messageID st
 	}
 	fields := []*discordgo.MessageEmbedField{&dolphinField, &configField}
 	thumbnail := discordgo.MessageEmbedThumbnail{
-		URL:    fmt.Sprintf("%s/dolphin.png", httpRoot),
+		URL:    fmt.Sprintf("%s/dolphin.png", cfg.HTTPRoot),
 		Width:  128,
 		Height: 71,
 	}
@@ -3361,7 +2360,7 @@ func dolphin(session *discordgo.Session, guildID, chanID, authorID, messageID st
 	return "", nil
 }
 
-func fortune(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func fortune(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	out, err := exec.Command("fortune", "-as").Output()
 	if err != nil {
 		return "", err
@@ -3369,24 +2368,11 @@ func fortune(session *discordgo.Session, guildID, chanID, authorID, messageID st
 	return string(out), nil
 }
 
-func topEmoji(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func topEmoji(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
 	emojiRegex := regexp.MustCompile(`<:(.+?):(\d+)>`)
-	var limit int
-	if len(args) < 1 {
-		limit = 10
-	} else {
-		var err error
-		limit, err = strconv.Atoi(args[0])
-		if err != nil || limit < 0 {
-			return "", err
-		}
-	}
-	chanIDint, err := strconv.ParseUint(chanID, 10, 64)
-	if err != nil {
-		return "", err
-	}
+	limit := args.int("number")
 
-	guild, err := session.State.Guild(guildID)
+	guild, err := session.State().Guild(guildID)
 	if err != nil {
 		return "", err
 	}
@@ -3395,25 +2381,18 @@ func topEmoji(session *discordgo.Session, guildID, chanID, authorID, messageID s
 		emojis[emoji.ID] = emoji.Name
 	}
 
-	rows, err := sqlClient.Query(`SELECT content FROM message WHERE chan_id = $1 AND author_id <> $2`, chanIDint, ownUserIDint)
-	if err != nil {
-		return "", err
-	}
-	defer rows.Close()
 	counts := make(map[string]uint, 50)
-	for rows.Next() {
-		var message string
-		err := rows.Scan(&message)
-		if err != nil {
-			return "", err
-		}
-		if matches := emojiRegex.FindAllStringSubmatch(message, -1); matches != nil {
+	if err := data.Messages.Each(store.MessageFilter{ChanIDs: []string{chanID}, NotAuthorIDs: []string{ownUserID}}, func(message store.Message) error {
+		if matches := emojiRegex.FindAllStringSubmatch(message.Content, -1); matches != nil {
 			for _, match := range matches {
 				if emojis[match[2]] == match[1] {
 					counts[match[2]] = counts[match[2]] + 1
 				}
 			}
 		}
+		return nil
+	}); err != nil {
+		return "", err
 	}
 	type emojiCount struct {
 		ID    string
@@ -3435,60 +2414,24 @@ func topEmoji(session *discordgo.Session, guildID, chanID, authorID, messageID s
 	return finalString, nil
 }
 
-func army(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	days := math.Ceil(time.Until(time.Date(2018, 6, 21, 0, 0, 0, 0, time.Local)).Hours() / 24)
-	return fmt.Sprintf("%.0f days until June 21, 2018", days), nil
-}
-
-func willGrad(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	days := math.Ceil(time.Until(time.Date(2018, 5, 11, 0, 0, 0, 0, time.Local)).Hours() / 24)
-	return fmt.Sprintf("%.0f days until May 11, 2018", days), nil
-}
-
-func grad(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	days := math.Ceil(time.Until(time.Date(2019, 5, 17, 0, 0, 0, 0, time.Local)).Hours() / 24)
-	return fmt.Sprintf("%.0f days until May 17, 2019", days), nil
-}
-
-func courtney(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	sinceStart := time.Since(time.Date(2018, 5, 14, 8, 0, 0, 0, time.Local))
-	untilEnd := time.Until(time.Date(2051, 4, 11, 17, 0, 0, 0, time.Local))
-	return fmt.Sprintf("%.4f%% of the way to retirement", (sinceStart.Minutes()/untilEnd.Minutes())*100), nil
-}
-
-func ross(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	sinceStart := time.Since(time.Date(2016, 1, 11, 9, 30, 0, 0, time.Local))
-	untilEnd := time.Until(time.Date(2048, 2, 28, 16, 0, 0, 0, time.Local))
-	return fmt.Sprintf("%.4f%% of the way to retirement", (sinceStart.Minutes()/untilEnd.Minutes())*100), nil
-}
-
-func christmas(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	now := time.Now()
-	if now.Month() != 12 || now.Day() > 25 {
-		return "It's too early to be asking that", nil
-	} else if now.Day() == 25 {
-		return "Merry Christmas!", nil
-	}
-	days := math.Ceil(time.Until(time.Date(now.Year(), 12, 25, 0, 0, 0, 0, time.Local)).Hours() / 24)
-	s := "s"
-	if days == 1 {
-		s = ""
-	}
-	return fmt.Sprintf("%.0f day%s until Christmas", days, s), nil
-}
-
-func hangmanCmd(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if _, found := hangmanGames[chanID]; found {
+func hangmanCmd(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	channel := bot.channel(chanID)
+	channel.Lock()
+	defer channel.Unlock()
+	if channel.hangmanGame != nil {
 		return "There's already a game going in here", nil
 	}
 	game := hangman.NewGame(authorID)
-	hangmanGames[chanID] = game
+	channel.hangmanGame = game
 	return fmt.Sprintf("```%s```\n`%s`", game.DrawMan(), game.GetGuessedWord()), nil
 }
 
-func guess(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	game, found := hangmanGames[chanID]
-	if !found {
+func guess(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	channel := bot.channel(chanID)
+	channel.Lock()
+	defer channel.Unlock()
+	game := channel.hangmanGame
+	if game == nil {
 		return "No game is going, start one with /hangman", nil
 	}
 	if len(args) != 1 || len(args[0]) != 1 {
@@ -3501,21 +2444,21 @@ func guess(session *discordgo.Session, guildID, chanID, authorID, messageID stri
 	if correct {
 		if game.IsVictory() {
 			answer := game.GetAnswer()
-			delete(hangmanGames, chanID)
+			channel.hangmanGame = nil
 			return fmt.Sprintf(":100: The word was %s", answer), nil
 		}
 		return fmt.Sprintf("`%s`\n%s", game.GetGuessedWord(), game.GetUsedLetters()), nil
 	}
 	if game.IsDefeat() {
 		man := game.DrawMan()
-		delete(hangmanGames, chanID)
+		channel.hangmanGame = nil
 		return fmt.Sprintf("```%s```\nGame over, you lose.", man), nil
 	}
 	return fmt.Sprintf("```%s```\n`%s`\n%s", game.DrawMan(), game.GetGuessedWord(), game.GetUsedLetters()), nil
 }
 
-func playing(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if authorID != adminID || len(args) < 1 {
+func playing(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	if len(args) < 1 {
 		return "", nil
 	}
 	if err := session.UpdateGameStatus(0, strings.Join(args[0:], " ")); err != nil {
@@ -3524,15 +2467,15 @@ func playing(session *discordgo.Session, guildID, chanID, authorID, messageID st
 	return "", nil
 }
 
-func pee(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	lastPeeDate := time.Now().Add(-24 * time.Hour)
-	if err := sqlClient.QueryRow(`SELECT create_date FROM pee_log WHERE user_id = $1 ORDER BY create_date DESC LIMIT 1`, authorID).Scan(&lastPeeDate); err != nil && err != sql.ErrNoRows {
+func pee(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	lastPeeDate, err := data.Pees.Last(authorID)
+	if err != nil && err != store.ErrNotFound {
 		return "", err
 	}
 	if lastPeeDate.After(time.Now().Add(-30 * time.Minute)) {
 		return "You *just* went, that doesn't count.", nil
 	}
-	if _, err := sqlClient.Exec(`INSERT INTO pee_log(user_id) VALUES ($1)`, authorID); err != nil {
+	if err := data.Pees.Add(authorID); err != nil {
 		return "", err
 	}
 	responses := []string{
@@ -3549,9 +2492,9 @@ func pee(session *discordgo.Session, guildID, chanID, authorID, messageID string
 	return responses[rand.Intn(len(responses))], nil
 }
 
-func peeCounter(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func peeCounter(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	counters := make(map[string]int)
-	guild, err := session.State.Guild(guildID)
+	guild, err := session.State().Guild(guildID)
 	if err != nil {
 		return "", err
 	}
@@ -3564,23 +2507,15 @@ func peeCounter(session *discordgo.Session, guildID, chanID, authorID, messageID
 	}
 
 	dayAgo := time.Now().Add(-24 * time.Hour)
-	rows, err := sqlClient.Query(`SELECT user_id FROM pee_log WHERE create_date >= $1`, dayAgo)
+	userIDs, err := data.Pees.Since(dayAgo)
 	if err != nil {
 		return "", err
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var userID string
-		if err := rows.Scan(&userID); err != nil {
-			return "", err
-		}
+	for _, userID := range userIDs {
 		if _, found := counters[userID]; found {
 			counters[userID]++
 		}
 	}
-	if err := rows.Err(); err != nil {
-		return "", err
-	}
 
 	type usernameCount struct {
 		Username string
@@ -3615,7 +2550,7 @@ func peeCounter(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return fmt.Sprintf("```%s```", message), nil
 }
 
-func poop(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+func poop(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	responses := []string{
 		"tmi",
 		"No thanks.",
@@ -3627,145 +2562,41 @@ func poop(session *discordgo.Session, guildID, chanID, authorID, messageID strin
 	return responses[rand.Intn(len(responses))], nil
 }
 
-func sebbiTime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	loc, err := time.LoadLocation("Europe/Copenhagen")
-	if err != nil {
-		return "", err
-	}
-	return time.Now().In(loc).Format("3:04 PM - Mon, Jan _2"), nil
-}
-
-func miroTime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	loc, err := time.LoadLocation("Europe/Helsinki")
-	if err != nil {
-		return "", err
-	}
-	return time.Now().In(loc).Format("3:04 PM - Mon, Jan _2"), nil
-}
-
-func nielTime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	loc, err := time.LoadLocation("Europe/Stockholm")
-	if err != nil {
-		return "", err
-	}
-	return time.Now().In(loc).Format("3:04 PM - Mon, Jan _2"), nil
-}
-
-func birdTime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	loc, err := time.LoadLocation("Europe/Oslo")
-	if err != nil {
-		return "", err
-	}
-	return time.Now().In(loc).Format("3:04 PM - Mon, Jan _2"), nil
-}
-
-func realTime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	loc, err := time.LoadLocation("America/Chicago")
-	if err != nil {
-		return "", err
-	}
-	return time.Now().In(loc).Format("15:04 - Mon, Jan _2"), nil
-}
-
-func euTime(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	loc1, err := time.LoadLocation("Europe/Lisbon")
-	if err != nil {
-		return "", err
-	}
-	loc2, err := time.LoadLocation("Asia/Nicosia")
-	if err != nil {
-		return "", err
+func help(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	var category commandCategory
+	if len(args) > 0 {
+		if cmd, found := lookupCommand(strings.TrimPrefix(args[0], "/")); found && !cmd.hidden {
+			return commandHelp(cmd), nil
+		}
+		category = commandCategory(strings.ToLower(args[0]))
+		if _, found := categoryTitles[category]; !found {
+			return "", fmt.Errorf("No command or category named %s", args[0])
+		}
 	}
-	now := time.Now()
-	return fmt.Sprintf("%s - %s", now.In(loc1).Format("3:04 PM"), now.In(loc2).Format("3:04 PM")), nil
-}
-
-func help(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	privateChannel, err := session.UserChannelCreate(authorID)
 	if err != nil {
 		return "", err
 	}
-	_, err = session.ChannelMessageSend(privateChannel.ID, `**activity** - shows messages per hour over lifetime of channel
-**age** [username] - displays how long [username] has been in this server
-**ayy**
-**bet** - place a roulette bet (type /bet for more help)
-**bitrate** - shows voice channels and their bitrates
-**botuptime** - shows time since bot last started
-**color** [hex color code] - generates a solid image of given color
-**cputemp** - displays CPU temperature
-**cwc** - alias for /spam cwc2016
-**delete** - deletes last message sent by bot (if you caused it)
-**downvote** [@user] - downvotes user
-**@[user]--** - downvotes user
-**forsen** - alias for /spam forsenlol
-**fortune** - get a "fortune"
-**gameactivity** [game (optional)] - shows played hours per hour of <game> (or all games if none provided) over lifetime of channel
-**gif** - looks for an embedded or linked image in the last 10 messages and reuploads it with modern GIF® compression`)
-	if err != nil {
-		return "", err
-	}
-	_, err = session.ChannelMessageSend(privateChannel.ID, `**greentext** - makes greentext with a couple messages from the channel's history
-**jpg** - looks for an embedded or linked image in the last 10 messages and reuploads it with modern JPEG™ compression
-**karma** [number (optional)] - displays top <number> users and their karma
-**lastplayed** [username] - displays game last played by <username>
-**lastseen** [username] - displays when <username> was last seen
-**lastmessage** [username] - displays when <username> last sent a message
-**lirik** - alias for /spam lirik
-**math** [math stuff] - does math
-**meme** - random meme from channel history
-**messages** - displays how many messages have been sent in this channel
-**money** [number (optional)] - displays top <number> users and their money
-**ooer** [message] - Ǫ̧̩͟͜H̝̼ ̡̳͖͑̇M̔́Aͤ̓Ńͮ ̛̔ͯ͌ͪĮ̷̒̀͠ ͦ͋̐̾͡Ḁ̶͗ͪ͡Mͧͪ ̧ͩN̴̫̳̚͢Ǫ͈̬̫̏T̢̟̭͎͈ ̷̳̜̦͆G̵͛O̿́O̯͇̎̋͝D͖̈ ̼̰W͙̦̿͞͝I̛̮̊ͦ̚T̘͑H̨͎̲̑͢ ̢̗͍̟̽C̀ͯ͊̀͡O̷͈ͯ͌ͅM̓̓P̢̬̋̃͊U̜̱̓͡͞T̀̇Ě̷R̈̎ ̨̭ͭ̿͠P̳ͯͩ̎͟Ľ̳͏̨̩Ž̯ ͇̜Ť̤̻͖͜O̤̲҉̑ͯ ͤ͊H̢̼̿͆ͥḀ̢̢ͮ̊L̫͈̳̪̀P̶̯͆̾͟
-**ping** - displays ping to discordapp.com
-**playtime** [number (optional)] OR [username (options)] - shows up to <number> summated (probably incorrect) playtimes in hours of every game across all users, or top 10 games of <username>
-**recentplaytime** [duration] [[number (optional)] OR [username (options)]] - same as playtime but with a duration (like remindme) before normal args, calculates only as far back as duration
-**remindme**
-	in [duration] to [x] - mentions user with <x> after <duration> (example: /remindme in 5 hours 10 minutes 3 seconds to order a pizza)
-	at [time] to [x] - mentions user with <x> at <time> (example: /remindme at 2016-05-04 13:37:00 -0500 to make a clever xd facebook status)
-**rename** [new username] - renames bot
-**roll** [x]d[y] - "rolls" <x> dice with <y> sides, or rolls 1-100 if no dice are specified`)
-	if err != nil {
-		return "", err
-	}
-	_, err = session.ChannelMessageSend(privateChannel.ID, `**serverAge** - displays how long ago this server was created
-**spam** [streamer (optional)] - generates a messages based on logs from <streamer>, shows all streamer logs if no streamer is specified
-**spamdiscord** - generates a message based on logs from this discord channel
-**spamdiscord2** - generates a message based on logs from this discord channel, less "creative" than spamdiscord but generally less nonsense
-**spamuser** [username] - generates a message based on discord logs of <username>
-**spamuser2** [username] - generates a message based on discord logs of <username>, less "creative" than spamuser but generally less nonsense
-**spin** or **roulette** - spin roulette wheel
-**soda** - alias for /spam sodapoppin
-**source** - link to bot source code on github
-**top** [number (optional)] - displays top <number> users sorted by messages sent
-**topCommand** [command] - displays who has issued <command> most
-**topEmoji** [number (optional)] - dispalys top <number> emojis sorted by times used
-**topLength** [number (optional)] - dispalys top <number> users sorted by average words/message
-**topOnline** - shows the maximum number of people that were ever simultaneously online
-**topQuote** [number (optional)] - dispalys top <number> of "quotes" from bot spam, sorted by votes from /upquote
-**track** [carrier] [tracking number] - displays current status of shipment and mentions you upon delivery
-**uptime** - displays bot's server uptime and load
-**upquote** - upvotes last statement generated by /spamuser or /spamdiscord
-**userage** [username] - displays how long since [username] joined discord
-**uq** - alias for /upquote
-**upvote** [@user] - upvotes user
-**@[user]++** - upvotes user
-**votes** [number (optional)] - displays top <number> users and their karma
-`+string([]byte{42, 42, 119, 97, 116, 99, 104, 108, 105, 115, 116, 42, 42, 32, 91, 110, 117, 109, 98, 101, 114, 32, 40, 111, 112, 116, 105, 111, 110, 97, 108, 41, 93, 32, 45, 32, 100, 105, 115, 112, 108, 97, 121, 115, 32, 116, 111, 112, 32, 60, 110, 117, 109, 98, 101, 114, 62, 32, 117, 115, 101, 114, 115, 32, 115, 111, 114, 116, 101, 100, 32, 98, 121, 32, 116, 101, 114, 114, 111, 114, 105, 115, 109, 32, 112, 101, 114, 32, 109, 101, 115, 115, 97, 103, 101})+`
-**xd**
-**zalgo** [message] - H̢͘͢È̛̛͡ ̴̛̛̀͏Ç̸O̕͝͏͏͡M̷̕E͘͘͡S̶̛`)
-	if err != nil {
-		return "", err
+	for _, message := range splitMessage(helpLines(session, guildID, chanID, authorID, category), 2000) {
+		if _, err := session.ChannelMessageSend(privateChannel.ID, message); err != nil {
+			return "", err
+		}
 	}
 	session.ChannelMessageDelete(chanID, messageID)
 	return "", nil
 }
 
-func kappa(session *discordgo.Session, chanID, authorID, messageID string) {
-	perm, err := session.State.UserChannelPermissions(ownUserID, chanID)
+func kappa(session discord.Session, chanID, authorID, messageID string) {
+	perm, err := session.State().UserChannelPermissions(ownUserID, chanID)
 	if err != nil {
 		return
 	}
-	if lastTime, found := lastKappa[authorID]; perm&discordgo.PermissionManageMessages == discordgo.PermissionManageMessages && (!found || time.Since(lastTime) > 30*time.Second) {
+	user := bot.user(authorID)
+	user.Lock()
+	lastTime := user.lastKappa
+	user.lastKappa = time.Now()
+	user.Unlock()
+	if perm&discordgo.PermissionManageMessages == discordgo.PermissionManageMessages && time.Since(lastTime) > 30*time.Second {
 		image, err := os.Open("kappa.png")
 		if err != nil {
 			return
@@ -3777,14 +2608,9 @@ func kappa(session *discordgo.Session, chanID, authorID, messageID string) {
 	} else {
 		session.ChannelMessageDelete(chanID, messageID)
 	}
-	lastKappa[authorID] = time.Now()
 }
 
-func speedtest(session *discordgo.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
-	if authorID != adminID {
-		return "", nil
-	}
-
+func speedtest(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
 	cmd := exec.Command("speedtest-cli", "--simple")
 	out, err := cmd.Output()
 	if err != nil {
@@ -3793,8 +2619,17 @@ func speedtest(session *discordgo.Session, guildID, chanID, authorID, messageID
 	return fmt.Sprintf("```%s```", out), nil
 }
 
-func makeMessageCreate() func(*discordgo.Session, *discordgo.MessageCreate) {
-	commandRegex := regexp.MustCompile(`^\/(.+)`)
+// recordCommandError logs a failed command to the error table and stdout
+func recordCommandError(commandName string, args []string, err error) {
+	if _, sqlErr := data.Errors.Record(commandName, strings.Join(args, " "), err.Error()); sqlErr != nil {
+		fmt.Println("ERROR recording error " + sqlErr.Error())
+	}
+	fmt.Println("ERROR in " + commandName)
+	fmt.Printf("ARGS: %v\n", args)
+	fmt.Println("ERROR: " + err.Error())
+}
+
+func makeMessageCreate() func(discord.Session, *discordgo.MessageCreate) {
 	upvoteRegex := regexp.MustCompile(`(<@!?\d+?>)\s*\+\+`)
 	downvoteRegex := regexp.MustCompile(`(<@!?\d+?>)\s*--`)
 	meanRegex := regexp.MustCompile(`(?i)((fuc)|(shit)|(garbage)|(garbo)).*bot($|[[:space:]])`)
@@ -3803,173 +2638,24 @@ func makeMessageCreate() func(*discordgo.Session, *discordgo.MessageCreate) {
 	inTheChatRegex := regexp.MustCompile(`(?i)can i get an?\s+(.*)\s+in the chat`)
 	kappaRegex := regexp.MustCompile(`(?i)^\s*kappa\s*$`)
 	//greenTextRegex := regexp.MustCompile(`(?i)^\s*>\s*([^:].+)$`)
-	funcMap := map[string]commandFunc{
-		"8ball":          commandFunc(eightball),
-		"activity":       commandFunc(activity),
-		"activityday":    commandFunc(activityDay),
-		"actualtime":     commandFunc(realTime),
-		"age":            commandFunc(age),
-		"army":           commandFunc(army),
-		"ascii":          commandFunc(ascii),
-		"ayy":            commandFunc(ayy),
-		"bet":            commandFunc(bet),
-		"birdtime":       commandFunc(birdTime),
-		"bitrate":        commandFunc(bitrate),
-		"botuptime":      commandFunc(botuptime),
-		"christmas":      commandFunc(christmas),
-		"color":          commandFunc(color),
-		"command":        commandFunc(help),
-		"commands":       commandFunc(help),
-		"courtney":       commandFunc(courtney),
-		"cputemp":        commandFunc(cputemp),
-		"cwc":            commandFunc(cwc),
-		"define":         commandFunc(define),
-		"delete":         commandFunc(deleteLastMessage),
-		"dolphin":        commandFunc(dolphin),
-		"downvote":       commandFunc(downvote),
-		"eutime":         commandFunc(euTime),
-		"forsen":         commandFunc(forsen),
-		"fortune":        commandFunc(fortune),
-		"g":              commandFunc(guess),
-		"gameactivity":   commandFunc(gameactivity),
-		"gif":            commandFunc(giffy),
-		"give":           commandFunc(give),
-		"goodtime":       commandFunc(realTime),
-		"grad":           commandFunc(grad),
-		"greentext":      commandFunc(greentext),
-		"guess":          commandFunc(guess),
-		"hangman":        commandFunc(hangmanCmd),
-		"help":           commandFunc(help),
-		"ignore":         commandFunc(ignore),
-		"invite":         commandFunc(invite),
-		"jpg":            commandFunc(jpg),
-		"karma":          commandFunc(votes),
-		"kickme":         commandFunc(kickme),
-		"kms":            commandFunc(kickme),
-		"lastmessage":    commandFunc(lastUserMessage),
-		"lastplayed":     commandFunc(lastPlayed),
-		"lastseen":       commandFunc(lastseen),
-		"lirik":          commandFunc(lirik),
-		"math":           commandFunc(maths),
-		"meme":           commandFunc(meme),
-		"messages":       commandFunc(totalMessages),
-		"mirotime":       commandFunc(miroTime),
-		"money":          commandFunc(money),
-		"mute":           commandFunc(mute),
-		"natime":         commandFunc(realTime),
-		"nest":           commandFunc(nest),
-		"nieltime":       commandFunc(nielTime),
-		"ooer":           commandFunc(ooer),
-		"pee":            commandFunc(pee),
-		"peecounter":     commandFunc(peeCounter),
-		"permission":     commandFunc(permission),
-		"ping":           commandFunc(ping),
-		"playing":        commandFunc(playing),
-		"playtime":       commandFunc(playtime),
-		"poo":            commandFunc(poop),
-		"poop":           commandFunc(poop),
-		"realtime":       commandFunc(realTime),
-		"recentplaytime": commandFunc(recentPlaytime),
-		"remindme":       commandFunc(remindme),
-		"rename":         commandFunc(rename),
-		"roll":           commandFunc(roll),
-		"ross":           commandFunc(ross),
-		"roulette":       commandFunc(roulette),
-		"sebbitime":      commandFunc(sebbiTime),
-		"serverage":      commandFunc(serverAge),
-		"servers":        commandFunc(totalServers),
-		"soda":           commandFunc(soda),
-		"source":         commandFunc(source),
-		"spam":           commandFunc(spam),
-		"spamdiscord":    commandFunc(spamdiscord1),
-		"spamdiscord2":   commandFunc(spamdiscord2),
-		"spamdiscord3":   commandFunc(spamdiscord3),
-		"spamuser":       commandFunc(spamuser1),
-		"spamuser2":      commandFunc(spamuser2),
-		"spamuser3":      commandFunc(spamuser3),
-		"speedtest":      commandFunc(speedtest),
-		"spin":           commandFunc(roulette),
-		"superooer":      commandFunc(superooer),
-		"timeout":        commandFunc(timeout),
-		"top":            commandFunc(top),
-		"topcommand":     commandFunc(topcommand),
-		"topemoji":       commandFunc(topEmoji),
-		"toplength":      commandFunc(topLength),
-		"toponline":      commandFunc(topOnline),
-		"topquote":       commandFunc(topquote),
-		"track":          commandFunc(track),
-		"twintime":       commandFunc(realTime),
-		"unignore":       commandFunc(unignore),
-		"unmute":         commandFunc(unmute),
-		"updateavatar":   commandFunc(updateAvatar),
-		"upquote":        commandFunc(upquote),
-		"uptime":         commandFunc(uptime),
-		"upvote":         commandFunc(upvote),
-		"uq":             commandFunc(upquote),
-		"userage":        commandFunc(userage),
-		"ustime":         commandFunc(realTime),
-		"voicekick":      commandFunc(voicekick),
-		"votes":          commandFunc(votes),
-		"whois":          commandFunc(whois),
-		"willgrad":       commandFunc(willGrad),
-		"xd":             commandFunc(xd),
-		"zalgo":          commandFunc(ooer),
-		string([]byte{119, 97, 116, 99, 104, 108, 105, 115, 116}): commandFunc(wlist),
-	}
-	newCommands := map[string]bool{
-		"birdtime":       true,
-		"fortune":        true,
-		"karma":          true,
-		"lastplayed":     true,
-		"lastseen":       true,
-		"math":           true,
-		"mirotime":       true,
-		"natime":         true,
-		"nieltime":       true,
-		"playtime":       true,
-		"realtime":       true,
-		"recentplaytime": true,
-		"roll":           true,
-		"sebbitime":      true,
-		"top":            true,
-		"topcommand":     true,
-		"toplength":      true,
-		"track":          true,
-		"twintime":       true,
-		"ustime":         true,
-		"votes":          true,
-		"whois":          true,
-	}
-
-	executeCommand := func(s *discordgo.Session, guildID string, m *discordgo.MessageCreate, command []string) bool {
+	executeCommand := func(s discord.Session, guildID string, m *discordgo.MessageCreate, command []string) bool {
 		commandName := strings.ToLower(command[0])
-		if cmd, valid := funcMap[commandName]; valid {
-			if guildID == "184428741450006528" || guildID == "161010139309015040" || guildID == "166762056828059648" {
-				if _, new := newCommands[commandName]; new {
-					return true
-				}
+		if cmd, valid := lookupCommand(commandName); valid {
+			if commandDisabled(guildID, cmd) {
+				return true
 			}
-			switch commandName {
-			case "upvote", "downvote", "help", "commands", "command", "rename", "delete", "uq", "uqquote", "bet", "permission", "voicekick", "timeout", "ignore", "mute", "unignore", "unmute", "pee":
-			default:
+			if !cmd.noTyping {
 				s.ChannelTyping(m.ChannelID)
 			}
-			reply, err := cmd(s, guildID, m.ChannelID, m.Author.ID, m.ID, command[1:])
+			reply, err := cmd.execute(s, getGuildSettings(guildID).prefix, guildID, m.ChannelID, m.Author.ID, m.ID, command[1:])
 			if err != nil {
-				var errorID uuid.UUID
-				if sqlErr := sqlClient.QueryRow(`INSERT INTO error(command, args, error) VALUES ($1, $2, $3) RETURNING id`, commandName, strings.Join(command[1:], " "), err.Error()).Scan(&errorID); sqlErr != nil {
-					fmt.Println("ERROR recording error " + sqlErr.Error())
-				}
+				recordCommandError(commandName, command[1:], err)
 				message, msgErr := s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("⚠ `%s`", err.Error()))
 				if msgErr != nil {
 					fmt.Println("ERROR SENDING ERROR MSG " + err.Error())
 				} else {
-					lastCommandMessages[m.Author.ID] = *m.Message
-					lastMessagesByAuthor[m.Author.ID] = *message
+					bot.user(m.Author.ID).setLastReply(*m.Message, *message)
 				}
-				fmt.Println("ERROR in " + commandName)
-				fmt.Printf("ARGS: %v\n", command[1:])
-				fmt.Println("ERROR: " + err.Error())
 				return true
 			}
 			if len(reply) > 0 {
@@ -3986,15 +2672,14 @@ func makeMessageCreate() func(*discordgo.Session, *discordgo.MessageCreate) {
 						}
 					}
 				}
-				lastCommandMessages[m.Author.ID] = *m.Message
-				lastMessagesByAuthor[m.Author.ID] = *message
+				bot.user(m.Author.ID).setLastReply(*m.Message, *message)
 			}
 			return true
 		}
 		return false
 	}
 
-	return func(s *discordgo.Session, m *discordgo.MessageCreate) {
+	return func(s discord.Session, m *discordgo.MessageCreate) {
 		defer func() {
 			if r := recover(); r != nil {
 				fmt.Println(string(debug.Stack()))
@@ -4002,32 +2687,17 @@ func makeMessageCreate() func(*discordgo.Session, *discordgo.MessageCreate) {
 			}
 		}()
 
-		messageID, err := strconv.ParseUint(m.ID, 10, 64)
-		if err != nil {
-			fmt.Println("ERROR parsing message ID " + err.Error())
-			return
-		}
-		chanID, err := strconv.ParseUint(m.ChannelID, 10, 64)
-		if err != nil {
-			fmt.Println("ERROR parsing channel ID " + err.Error())
-			return
-		}
-		authorID, err := strconv.ParseUint(m.Author.ID, 10, 64)
-		if err != nil {
-			fmt.Println("ERROR parsing author ID " + err.Error())
-			return
-		}
-		if _, err = sqlClient.Exec(`INSERT INTO message (id, chan_id, author_id, content) VALUES ($1, $2, $3, $4)`,
-			messageID, chanID, authorID, m.Content); err != nil {
+		if err := data.Messages.Insert(m.ID, m.ChannelID, m.Author.ID, m.Content); err != nil {
 			fmt.Println("ERROR inserting into Message")
 			fmt.Println(err.Error())
 		}
+		learnMessage(s, m)
 
 		if m.Author.ID == ownUserID {
 			return
 		}
 
-		channel, err := s.State.Channel(m.ChannelID)
+		channel, err := s.State().Channel(m.ChannelID)
 		if err != nil {
 			if channel, err = s.Channel(m.ChannelID); err != nil {
 				s.ChannelMessageSend(m.ChannelID, "⚠ `"+err.Error()+"`")
@@ -4035,8 +2705,11 @@ func makeMessageCreate() func(*discordgo.Session, *discordgo.MessageCreate) {
 				return
 			}
 		}
-		if mutedUntil, found := mutedUserIDs[[2]string{channel.GuildID, m.Author.ID}]; found && mutedUntil.After(time.Now()) {
-			s.ChannelMessageDelete(m.ChannelID, m.ID)
+		guild := bot.guild(channel.GuildID)
+		if backend := guild.muteBackend(m.Author.ID); len(backend) > 0 {
+			if backend == muteByDeleting {
+				s.ChannelMessageDelete(m.ChannelID, m.ID)
+			}
 			return
 		}
 
@@ -4049,14 +2722,15 @@ func makeMessageCreate() func(*discordgo.Session, *discordgo.MessageCreate) {
 			}
 		}*/
 
-		lastChanMessages := lastMessagesByChannel[m.ChannelID]
-		for i := 1; i < len(lastChanMessages); i++ {
-			lastChanMessages[i] = lastChanMessages[i-1]
+		chanState := bot.channel(m.ChannelID)
+		chanState.Lock()
+		for i := 1; i < len(chanState.lastMessages); i++ {
+			chanState.lastMessages[i] = chanState.lastMessages[i-1]
 		}
-		lastChanMessages[0] = m.Message.Content
-		lastMessagesByChannel[m.ChannelID] = lastChanMessages
+		chanState.lastMessages[0] = m.Message.Content
+		chanState.Unlock()
 
-		if ignoredUntil, found := ignoredUserIDs[[2]string{channel.GuildID, m.Author.ID}]; found && ignoredUntil.After(time.Now()) {
+		if guild.isIgnored(m.Author.ID) {
 			return
 		}
 
@@ -4081,7 +2755,8 @@ func makeMessageCreate() func(*discordgo.Session, *discordgo.MessageCreate) {
 				}
 			}
 		}
-		if blocked, found := guildReactBlacklist[channel.GuildID]; !found || !blocked {
+		settings := getGuildSettings(channel.GuildID)
+		if !settings.reactionsDisabled {
 			if match := botRegex.FindString(m.Content); match != "" {
 				if rand.Intn(8) == 0 {
 					go func() {
@@ -4092,8 +2767,8 @@ func makeMessageCreate() func(*discordgo.Session, *discordgo.MessageCreate) {
 			}
 		}
 
-		if match := commandRegex.FindStringSubmatch(m.Content); match != nil {
-			if executeCommand(s, channel.GuildID, m, strings.Fields(match[1])) {
+		if strings.HasPrefix(m.Content, settings.prefix) {
+			if command := strings.Fields(m.Content[len(settings.prefix):]); len(command) > 0 && executeCommand(s, channel.GuildID, m, command) {
 				return
 			}
 		}
@@ -4124,7 +2799,7 @@ func makeMessageCreate() func(*discordgo.Session, *discordgo.MessageCreate) {
 	}
 }
 
-func initGameUpdater(s *discordgo.Session) {
+func initGameUpdater(s discord.Session) {
 	res, err := http.Get("http://api.steampowered.com/ISteamApps/GetAppList/v2")
 	if err != nil {
 		fmt.Println(err.Error())
@@ -4159,11 +2834,12 @@ func initGameUpdater(s *discordgo.Session) {
 		gamelist[i] = app.Name
 	}
 
-	time.AfterFunc(time.Duration(960+rand.Intn(600))*time.Second, func() { updateGame(s) })
+	if err := jobs.Add(scheduler.Job{Name: "game status", Schedule: scheduler.Between(960*time.Second, 1560*time.Second), Run: func() { updateGame(s) }}); err != nil {
+		fmt.Println("ERROR scheduling game status", err)
+	}
 }
 
-func updateGame(s *discordgo.Session) {
-	defer time.AfterFunc(time.Duration(960+rand.Intn(600))*time.Second, func() { updateGame(s) })
+func updateGame(s discord.Session) {
 	if currentGame != "" {
 		changeGame := rand.Intn(3)
 		if changeGame != 0 {
@@ -4184,14 +2860,17 @@ func updateGame(s *discordgo.Session) {
 }
 
 func handleVoiceUpdate(s *discordgo.Session, v *discordgo.VoiceStateUpdate) {
-	if _, err := sqlClient.Exec(`INSERT INTO voice_state (guild_id, chan_id, user_id, session_id, deaf, mute, self_deaf, self_mute, suppress) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
-		v.GuildID, v.ChannelID, v.UserID, v.SessionID, v.Deaf, v.Mute, v.SelfDeaf, v.SelfMute, v.Suppress); err != nil {
+	if err := data.Voice.Insert(store.VoiceState{GuildID: v.GuildID, ChanID: v.ChannelID, UserID: v.UserID, SessionID: v.SessionID,
+		Deaf: v.Deaf, Mute: v.Mute, SelfDeaf: v.SelfDeaf, SelfMute: v.SelfMute, Suppress: v.Suppress}); err != nil {
 		fmt.Println("ERROR insert into VoiceState: ", err.Error())
 	}
-	if timeoutTime, found := timeoutedUserIDs[v.UserID]; found {
-		if v.ChannelID != timeoutChanID && v.GuildID == timeoutGuildID && timeoutTime.Add(30*time.Second).After(time.Now()) {
-			timeout := timeoutChanID
-			s.GuildMemberMove(timeoutGuildID, v.UserID, &timeout)
+	if len(v.GuildID) == 0 || len(v.ChannelID) == 0 {
+		return
+	}
+	timeoutChanID := getGuildSettings(v.GuildID).timeoutChanID
+	if len(timeoutChanID) > 0 && v.ChannelID != timeoutChanID && bot.guild(v.GuildID).isTimedOut(v.UserID) {
+		if err := s.GuildMemberMove(v.GuildID, v.UserID, &timeoutChanID); err != nil {
+			fmt.Println("ERROR moving back to timeout", err)
 		}
 	}
 }
@@ -4227,18 +2906,20 @@ func handleGuildMemberRemove(s *discordgo.Session, m *discordgo.GuildMemberRemov
 func handleGuildMemberUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
 	if m.User.ID == ownUserID {
 		fmt.Println("fixing self")
-		if justNicknamed, found := wasNicknamed[m.GuildID]; found && justNicknamed {
-			wasNicknamed[m.GuildID] = false
+		guild := bot.guild(m.GuildID)
+		guild.Lock()
+		justNicknamed := guild.wasNicknamed
+		guild.wasNicknamed = false
+		guild.Unlock()
+		if justNicknamed {
 			return
 		}
-		var lastUsername string
-		if err := sqlClient.QueryRow(`SELECT username FROM own_username WHERE guild_id = $1 ORDER BY create_date DESC LIMIT 1`, m.GuildID).Scan(&lastUsername); err != nil {
-			if err == sql.ErrNoRows {
-				lastUsername = "disgo"
-			} else {
-				fmt.Println("ERROR reverting update: getting old name", err)
-				return
-			}
+		lastUsername := "disgo"
+		if last, err := data.Usernames.Latest(m.GuildID); err == nil {
+			lastUsername = last.Username
+		} else if err != store.ErrNotFound {
+			fmt.Println("ERROR reverting update: getting old name", err)
+			return
 		}
 		if lastUsername == m.Nick {
 			return
@@ -4250,88 +2931,55 @@ func handleGuildMemberUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdat
 }
 
 func handleMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
-	messageID, err := strconv.ParseUint(m.ID, 10, 64)
-	if err != nil {
-		fmt.Println("ERROR parsing message ID " + err.Error())
-		return
-	}
-	if _, err = sqlClient.Exec(`DELETE FROM message WHERE id = $1`, messageID); err != nil {
+	if err := data.Messages.Delete(m.ID); err != nil {
 		fmt.Println("ERROR handling MessageDelete", err.Error())
 		return
 	}
 }
 
 func handleMessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
-	messageID, err := strconv.ParseUint(m.ID, 10, 64)
-	if err != nil {
-		fmt.Println("ERROR parsing message ID " + err.Error())
-		return
-	}
-	if _, err = sqlClient.Exec(`UPDATE message SET content = $1 WHERE id = $2`, m.Content, messageID); err != nil {
+	if err := data.Messages.Update(m.ID, m.Content); err != nil {
 		fmt.Println("ERROR handling MessageUpdate", err.Error())
 		return
 	}
 }
 
 func giveAllowance() {
-	now := time.Now()
-	nextRun := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.Local)
-	time.AfterFunc(nextRun.Sub(now), giveAllowance)
-	rows, err := sqlClient.Query(`SELECT guild_id, user_id FROM user_money`)
+	accounts, err := data.Money.Accounts()
 	if err != nil {
 		fmt.Println(err.Error())
 		return
 	}
-	defer rows.Close()
-	var karmas []int
-	var guildIDs, userIDs []string
-	for rows.Next() {
-		var guildID, userID string
-		err := rows.Scan(&guildID, &userID)
+	karmas := make([]int, len(accounts))
+	for i, account := range accounts {
+		karma, err := data.Karma.Karma(account.GuildID, account.UserID)
 		if err != nil {
 			fmt.Println(err.Error())
 			return
 		}
-		var karma int
-		if err := sqlClient.QueryRow(`SELECT karma FROM user_karma WHERE guild_id = $1 AND user_id = $2`, guildID, userID).Scan(&karma); err != nil {
-			if err == sql.ErrNoRows {
-				karma = 0
-			} else {
-				fmt.Println(err.Error())
-				return
-			}
-		}
 		if karma < 0 {
 			karma = 0
 		}
-		karmas = append(karmas, karma)
-		guildIDs = append(guildIDs, guildID)
-		userIDs = append(userIDs, userID)
+		karmas[i] = karma
 	}
-	for i := range karmas {
-		if _, err = sqlClient.Exec(`UPDATE user_money SET money = money + $1 WHERE guild_id = $2 AND user_id = $3`, math.Max(3, 3+0.2*float64(karmas[i])), guildIDs[i], userIDs[i]); err != nil {
+	for i, account := range accounts {
+		if err := data.Money.Change(account.GuildID, account.UserID, math.Max(3, 3+0.2*float64(karmas[i]))); err != nil {
 			fmt.Println(err.Error())
 			return
 		}
 	}
 }
 
-func checkShipments(s *discordgo.Session) {
-	defer time.AfterFunc(5*time.Minute, func() { checkShipments(s) })
-	rows, err := sqlClient.Query(`SELECT id, carrier, tracking_number, chan_id, author_id FROM shipment`)
+func checkShipments(s discord.Session) {
+	shipments, err := data.Shipments.All()
 	if err != nil {
 		fmt.Println("ERROR selecting from shipment", err)
+		return
 	}
-	defer rows.Close()
-	var toDelete []int
-	for rows.Next() {
-		var ID int
-		var carrier, trackingNum, chanID, authorID string
-		if err := rows.Scan(&ID, &carrier, &trackingNum, &chanID, &authorID); err != nil {
-			fmt.Println("ERROR scanning shipment", err)
-			continue
-		}
-		status, err := getShippoTrack(carrier, trackingNum)
+	var toDelete []int64
+	for _, shipment := range shipments {
+		chanID, authorID := shipment.ChanID, shipment.AuthorID
+		status, err := getShippoTrack(shipment.Carrier, shipment.TrackingNumber)
 		if err != nil {
 			fmt.Println("ERROR getting shipment status", err)
 			continue
@@ -4348,14 +2996,11 @@ func checkShipments(s *discordgo.Session) {
 				fmt.Println("ERROR sending shipment message", err)
 				continue
 			}
-			toDelete = append(toDelete, ID)
+			toDelete = append(toDelete, shipment.ID)
 		}
 	}
-	if err := rows.Err(); err != nil {
-		fmt.Println("ERROR calling next on rows", err)
-	}
 	for _, ID := range toDelete {
-		if _, err := sqlClient.Exec(`DELETE FROM shipment WHERE id = $1`, ID); err != nil {
+		if err := data.Shipments.Delete(ID); err != nil {
 			fmt.Println("ERROR removing shipment", err)
 			continue
 		}
@@ -4382,7 +3027,7 @@ func reportError(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	sourceIP := xForwardedFor[0]
-	if _, err := sqlClient.Exec(`INSERT INTO error_ip(error_id, ip) VALUES ($1, $2)`, errorID, sourceIP); err != nil {
+	if err := data.Errors.AddReporter(errorID.String(), sourceIP); err != nil {
 		fmt.Println(err)
 		if _, err := w.Write([]byte("Thank you!")); err != nil {
 			fmt.Println(err)
@@ -4390,7 +3035,7 @@ func reportError(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	if _, err := sqlClient.Exec(`UPDATE error SET reported_count = reported_count + 1 WHERE id = $1`, errorID); err != nil {
+	if err := data.Errors.IncrementReported(errorID.String()); err != nil {
 		fmt.Println(err)
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
@@ -4402,17 +3047,109 @@ func reportError(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// migrateCommand handles "disgo migrate [up | down [steps] | status]"
+func migrateCommand(args []string) error {
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	switch action {
+	case "up":
+		ran, err := migrate.Up(sqlClient, cfg.migrationVars())
+		for _, m := range ran {
+			fmt.Printf("Applied %d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(ran) == 0 {
+			fmt.Println("Already up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %s", args[1])
+			}
+		}
+		reverted, err := migrate.Down(sqlClient, steps)
+		for _, m := range reverted {
+			fmt.Printf("Reverted %d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrate.List(sqlClient)
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied"
+			}
+			fmt.Printf("%04d_%s %s\n", status.Version, status.Name, state)
+		}
+		return err
+	}
+	return fmt.Errorf("unknown action %s, expected up, down or status", action)
+}
+
 func main() {
+	configPath := flag.String("config", "config.json", "path to the JSON config file, DISGO_* environment variables override it")
+	flag.Parse()
+
 	var err error
-	sqlClient, err = sql.Open("postgres", fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s", dbUser, dbPass, dbHost, dbPort, dbName, dbSslMode))
+	if cfg, err = loadConfig(*configPath); err != nil {
+		fmt.Println("ERROR loading config: " + err.Error())
+		os.Exit(1)
+	}
+
+	//subcommands run before the bot's own config checks, needing only their part of it
+	switch flag.Arg(0) {
+	case "markov":
+		if err := markovCommand(flag.Args()[1:]); err != nil {
+			fmt.Println("ERROR training markov model: " + err.Error())
+			os.Exit(1)
+		}
+		return
+	case "migrate":
+		if err := cfg.validateDB(); err != nil {
+			fmt.Println("ERROR loading config: " + err.Error())
+			os.Exit(1)
+		}
+		if sqlClient, err = sql.Open("postgres", cfg.databaseURL()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := migrateCommand(flag.Args()[1:]); err != nil {
+			fmt.Println("ERROR migrating: " + err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := cfg.validate(); err != nil {
+		fmt.Println("ERROR loading config: " + err.Error())
+		os.Exit(1)
+	}
+	cfg.disableUnconfiguredCommands()
+	cfg.registerTimeAliases()
+
+	sqlClient, err = sql.Open("postgres", cfg.databaseURL())
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
+	data = store.NewPostgres(sqlClient)
+
+	if ran, err := migrate.Up(sqlClient, cfg.migrationVars()); err != nil {
+		fmt.Println("ERROR migrating: " + err.Error())
+		os.Exit(1)
+	} else {
+		for _, m := range ran {
+			fmt.Printf("Applied migration %d_%s\n", m.Version, m.Name)
+		}
+	}
 	rand.Seed(time.Now().UnixNano())
 
-	client, err := discordgo.New(botToken)
+	client, err := discordgo.New(cfg.BotToken)
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -4436,23 +3173,33 @@ func main() {
 		return
 	}
 	ownUserID = self.ID
-	ownUserIDint, err = strconv.ParseUint(self.ID, 10, 64)
-	if err != nil {
-		fmt.Println("Failed to parse own ID " + err.Error())
-		return
-	}
 
-	client.AddHandler(makeMessageCreate())
+	jobs = scheduler.New(data.Jobs)
+	chains = markov.NewCache(int64(cfg.MarkovCacheMB) << 20)
+	session := discord.Wrap(client)
+	if err := loadModeration(session); err != nil {
+		fmt.Println("ERROR loading moderation actions", err)
+	}
+	onMessageCreate := makeMessageCreate()
+	client.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) { onMessageCreate(session, m) })
 	client.AddHandler(handleVoiceUpdate)
 	client.AddHandler(handleGuildMemberAdd)
 	client.AddHandler(handleGuildMemberRemove)
 	client.AddHandler(handleGuildMemberUpdate)
 	client.AddHandler(handleMessageDelete)
 	client.AddHandler(handleMessageUpdate)
+	client.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) { handleInteractionCreate(session, i) })
+	client.AddHandler(handlePresenceUpdate)
+	client.AddHandler(handlePresenceGuildCreate)
+	client.AddHandler(func(s *discordgo.Session, g *discordgo.GuildCreate) { releaseMuteRoles(session, g.Guild) })
+	client.AddHandler(handlePresenceDisconnect)
+	client.AddHandler(handlePresenceResumed)
 	client.Open()
 	fmt.Println("Connected")
+	if _, err := client.ApplicationCommandBulkOverwrite(cfg.AppID, "", applicationCommands()); err != nil {
+		fmt.Println("ERROR registering application commands " + err.Error())
+	}
 	defer client.Close()
-	defer client.Logout()
 	defer func() {
 		voiceMutex.Lock()
 		defer voiceMutex.Unlock()
@@ -4482,46 +3229,15 @@ func main() {
 				}
 			}
 		}
-		client.Logout()
+		jobs.Stop()
 		client.Close()
 		os.Exit(0)
 	}()
 	signal.Notify(signals, os.Interrupt)
 
-	go initGameUpdater(client)
-
-	now := time.Now()
-	rows, err := sqlClient.Query("SELECT id, chan_id, author_id, send_time, content FROM reminder WHERE sent_at IS NULL")
-	if err != nil {
-		fmt.Println("ERROR setting reminders", err)
-	}
-	for rows.Next() {
-		var id int32
-		var chanID, authorID, content string
-		var reminderTime time.Time
-		err := rows.Scan(&id, &chanID, &authorID, &reminderTime, &content)
-		if err != nil {
-			fmt.Println("ERROR setting reminder", err)
-			continue
-		}
-		var after time.Duration
-		if reminderTime.Before(now) {
-			after = 0
-		} else {
-			after = reminderTime.Sub(now)
-		}
-		time.AfterFunc(after, func() {
-			client.ChannelMessageSend(chanID, fmt.Sprintf("<@%s> %s", authorID, content))
-			sqlClient.Exec(`UPDATE reminder SET sent_at = now() WHERE id = $1`, id)
-		})
-	}
-	rows.Close()
-
-	now = time.Now()
-	nextAllowance := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.Local)
-	time.AfterFunc(nextAllowance.Sub(now), giveAllowance)
+	go initGameUpdater(session)
 
-	time.AfterFunc(5*time.Minute, func() { checkShipments(client) })
+	startJobs(session)
 
 	http.HandleFunc("/disgo_error", reportError)
 	go func() {
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+	"github.com/heydabop/disgo/markov"
+	"github.com/heydabop/disgo/scheduler"
+	"github.com/heydabop/disgo/store"
+)
+
+const (
+	testBotID   = "1"
+	testGuildID = "2"
+	testChanID  = "3"
+)
+
+type testBot struct {
+	fake         *discord.Fake
+	handle       func(discord.Session, *discordgo.MessageCreate)
+	alice, bob   *discordgo.User
+	messagesSeen int
+}
+
+// newTestBot points the bot at a fake guild with one channel, alice and bob in it, and an empty in-memory store
+func newTestBot(t *testing.T) *testBot {
+	t.Helper()
+	ownUserID = testBotID
+	data = store.NewMemory()
+	chains = markov.NewCache(1 << 20)
+	jobs = scheduler.New(data.Jobs)
+	t.Cleanup(jobs.Stop)
+	bot = botState{guilds: make(map[string]*guildState), channels: make(map[string]*channelState), users: make(map[string]*userState)}
+	guildSettingsMutex.Lock()
+	guildSettingsCache = make(map[string]guildSettings)
+	guildSettingsMutex.Unlock()
+
+	fake := discord.NewFake(testBotID, "disgo")
+	fake.AddGuild(testGuildID, "test", testBotID)
+	fake.AddChannel(testGuildID, testChanID, "general", discordgo.ChannelTypeGuildText)
+	b := &testBot{
+		fake:   fake,
+		handle: makeMessageCreate(),
+		alice:  &discordgo.User{ID: "4", Username: "alice"},
+		bob:    &discordgo.User{ID: "5", Username: "bob"},
+	}
+	fake.AddMember(testGuildID, fake.State().User)
+	fake.AddMember(testGuildID, b.alice)
+	fake.AddMember(testGuildID, b.bob)
+	return b
+}
+
+// say delivers content from author to the test channel and returns what the bot sent in reply
+func (b *testBot) say(author *discordgo.User, content string) []string {
+	before := len(b.fake.Sent())
+	b.handle(b.fake, b.fake.MessageCreate(testChanID, author, content))
+	b.messagesSeen++
+	var replies []string
+	for _, message := range b.fake.Sent()[before:] {
+		replies = append(replies, message.Content)
+	}
+	return replies
+}
+
+func expectReplies(t *testing.T, content string, replies []string, want ...string) {
+	t.Helper()
+	if strings.Join(replies, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%q got replies %q, want %q", content, replies, want)
+	}
+}
+
+func TestRouletteBet(t *testing.T) {
+	b := newTestBot(t)
+	expectReplies(t, "/bet 1 red", b.say(b.alice, "/bet 1 red"), "The wheel must be spinning to place a bet. Try /spin")
+	expectReplies(t, "/roulette", b.say(b.alice, "/roulette"), "Spinning...")
+	expectReplies(t, "/spin", b.say(b.bob, "/spin"), "Wheel is already spinning, place a bet")
+	expectReplies(t, "/bet 1 red", b.say(b.alice, "/bet 1 red"))
+	expectReplies(t, "/bet 100 black", b.say(b.bob, "/bet 100 black"), "⚠ `Like you can afford that.`")
+
+	bets := bot.guild(testGuildID).takeBets() //stops the wheel, so the spin finishing later pays nobody
+	if len(bets) != 1 || bets[0].UserID != b.alice.ID || bets[0].Bet != 1 || bets[0].Payout != 1 || len(bets[0].WinningNumbers) != 18 {
+		t.Errorf("bets on the wheel are %+v, want alice's 1 on red", bets)
+	}
+	if money, err := data.Money.Balance(testGuildID, b.alice.ID); err != nil || money != store.StartingMoney-1 {
+		t.Errorf("alice has %v (%v), want %v", money, err, store.StartingMoney-1)
+	}
+	if money, err := data.Money.Balance(testGuildID, b.bob.ID); err != nil || money != store.StartingMoney {
+		t.Errorf("bob has %v (%v), want %v", money, err, store.StartingMoney)
+	}
+	if count, err := data.Messages.Count(store.MessageFilter{ChanIDs: []string{testChanID}}); err != nil || count != int64(b.messagesSeen) {
+		t.Errorf("stored %d messages (%v), want %d", count, err, b.messagesSeen)
+	}
+}
+
+func TestHangmanGuess(t *testing.T) {
+	b := newTestBot(t)
+	expectReplies(t, "/guess e", b.say(b.alice, "/guess e"), "No game is going, start one with /hangman")
+	if replies := b.say(b.alice, "/hangman"); len(replies) != 1 || !strings.HasPrefix(replies[0], "```") {
+		t.Fatalf("/hangman got replies %q, want the gallows", replies)
+	}
+	expectReplies(t, "/hangman", b.say(b.bob, "/hangman"), "There's already a game going in here")
+
+	channel := bot.channel(testChanID)
+	channel.Lock()
+	game := channel.hangmanGame
+	channel.Unlock()
+	if game == nil {
+		t.Fatal("no game stored for the channel")
+	}
+	answer := strings.ToLower(game.GetAnswer())
+
+	for letter := byte('a'); letter <= 'z'; letter++ {
+		if strings.IndexByte(answer, letter) < 0 {
+			replies := b.say(b.alice, "/guess "+string(letter))
+			if len(replies) != 1 || !strings.Contains(replies[0], "~~"+strings.ToUpper(string(letter))+"~~") {
+				t.Errorf("wrong guess %c got replies %q, want the used letters", letter, replies)
+			}
+			break
+		}
+	}
+	if replies := b.say(b.bob, "/g "+answer[:1]); len(replies) != 1 || !strings.HasPrefix(replies[0], "⚠") {
+		t.Errorf("bob guessing got replies %q, want an error since alice just guessed", replies)
+	}
+
+	var replies []string
+	for i := range answer {
+		if strings.IndexByte(answer[:i], answer[i]) < 0 {
+			replies = b.say(b.alice, "/guess "+answer[i:i+1])
+		}
+	}
+	expectReplies(t, "the last guess", replies, ":100: The word was "+strings.ToUpper(answer))
+	channel.Lock()
+	defer channel.Unlock()
+	if channel.hangmanGame != nil {
+		t.Error("the game is still stored after it was won")
+	}
+}
+
+func TestVote(t *testing.T) {
+	b := newTestBot(t)
+	expectReplies(t, "<@4> ++", b.say(b.bob, "<@4> ++"))
+	if karma, err := data.Karma.Karma(testGuildID, b.alice.ID); err != nil || karma != 1 {
+		t.Errorf("alice has %d karma (%v), want 1", karma, err)
+	}
+	vote, err := data.Karma.LastVoteBy(testGuildID, b.bob.ID)
+	if err != nil || vote.VoteeID != b.alice.ID || !vote.IsUpvote {
+		t.Errorf("bob's last vote is %+v (%v), want an upvote for alice", vote, err)
+	}
+
+	expectReplies(t, "/downvote <@4>", b.say(b.bob, "/downvote <@4>"), "Slow down champ.")
+	if karma, _ := data.Karma.Karma(testGuildID, b.alice.ID); karma != 1 {
+		t.Errorf("alice has %d karma after bob voted too soon, want 1", karma)
+	}
+
+	expectReplies(t, "<@!4>++", b.say(b.alice, "<@!4>++"), "No.")
+	if karma, _ := data.Karma.Karma(testGuildID, b.alice.ID); karma != 0 {
+		t.Errorf("alice has %d karma after upvoting themself, want 0", karma)
+	}
+	if vote, err := data.Karma.LastVoteFor(testGuildID, b.alice.ID); err != nil || vote.VoterID != testBotID || vote.IsUpvote {
+		t.Errorf("alice's last vote is %+v (%v), want a downvote from the bot", vote, err)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+)
+
+const (
+	maxApplicationCommands = 100 //discord's limit on global chat commands
+	maxDescriptionLength   = 100
+	freeformOptionName     = "args" //single option given to commands without an args spec
+)
+
+var optionNameRegex = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// optionName turns an argSpec name like "@user" into a valid application command option name
+func optionName(name string) string {
+	return optionNameRegex.ReplaceAllString(strings.ToLower(name), "")
+}
+
+func truncateDescription(description string) string {
+	if len(description) == 0 {
+		return "-"
+	}
+	if runes := []rune(description); len(runes) > maxDescriptionLength {
+		return string(runes[:maxDescriptionLength-1]) + "…"
+	}
+	return description
+}
+
+// commandOptions maps cmd's args spec onto application command options, falling back to one free text option
+func commandOptions(cmd *command) []*discordgo.ApplicationCommandOption {
+	if cmd.runArgs == nil {
+		description := cmd.usage
+		if len(description) == 0 {
+			description = "arguments"
+		}
+		return []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        freeformOptionName,
+			Description: truncateDescription(description),
+		}}
+	}
+	options := make([]*discordgo.ApplicationCommandOption, len(cmd.args))
+	for i, spec := range cmd.args {
+		option := &discordgo.ApplicationCommandOption{
+			Name:        optionName(spec.name),
+			Description: spec.name,
+			Required:    !spec.optional,
+		}
+		switch spec.kind {
+		case argUser:
+			option.Type = discordgo.ApplicationCommandOptionUser
+		case argChannel:
+			option.Type = discordgo.ApplicationCommandOptionChannel
+		case argInt:
+			option.Type = discordgo.ApplicationCommandOptionInteger
+			min := float64(spec.min)
+			option.MinValue = &min
+			option.MaxValue = float64(spec.max)
+		case argAmount:
+			option.Type = discordgo.ApplicationCommandOptionNumber
+			min := spec.minAmount
+			option.MinValue = &min
+		case argDuration:
+			option.Type = discordgo.ApplicationCommandOptionString
+			option.Description = spec.name + ", like 5 minutes or 2h30m"
+		default:
+			option.Type = discordgo.ApplicationCommandOptionString
+		}
+		options[i] = option
+	}
+	return options
+}
+
+// applicationCommands builds the chat commands to register with discord from the command registry
+func applicationCommands() []*discordgo.ApplicationCommand {
+	commands := make([]*discordgo.ApplicationCommand, 0, maxApplicationCommands)
+	for _, cmd := range commandList {
+		if cmd.hidden || cmd.ownerOnly {
+			continue
+		}
+		if len(commands) == maxApplicationCommands {
+			fmt.Println("ERROR too many application commands, not registering " + cmd.name)
+			continue
+		}
+		description := cmd.description
+		if len(description) == 0 {
+			description = cmd.name
+		}
+		commands = append(commands, &discordgo.ApplicationCommand{
+			Type:        discordgo.ChatApplicationCommand,
+			Name:        cmd.name,
+			Description: truncateDescription(description),
+			Options:     commandOptions(cmd),
+		})
+	}
+	return commands
+}
+
+// interactionTokens turns interaction options back into the tokens a text command would have had, in spec order
+func interactionTokens(cmd *command, options []*discordgo.ApplicationCommandInteractionDataOption) []string {
+	byName := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, option := range options {
+		byName[option.Name] = option
+	}
+	if cmd.runArgs == nil {
+		if option, found := byName[freeformOptionName]; found {
+			return strings.Fields(option.StringValue())
+		}
+		return nil
+	}
+	var tokens []string
+	for _, spec := range cmd.args {
+		option, found := byName[optionName(spec.name)]
+		if !found {
+			continue
+		}
+		if spec.flag != "" {
+			tokens = append(tokens, spec.flag)
+		}
+		switch option.Type {
+		case discordgo.ApplicationCommandOptionUser:
+			tokens = append(tokens, "<@"+option.UserValue(nil).ID+">")
+		case discordgo.ApplicationCommandOptionChannel:
+			tokens = append(tokens, "<#"+option.ChannelValue(nil).ID+">")
+		case discordgo.ApplicationCommandOptionInteger:
+			tokens = append(tokens, strconv.FormatInt(option.IntValue(), 10))
+		case discordgo.ApplicationCommandOptionNumber:
+			tokens = append(tokens, strconv.FormatFloat(option.FloatValue(), 'f', -1, 64))
+		default:
+			tokens = append(tokens, strings.Fields(option.StringValue())...)
+		}
+	}
+	return tokens
+}
+
+func respondEphemeral(s discord.Session, interaction *discordgo.Interaction, content string) {
+	if err := s.InteractionRespond(interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: content, Flags: discordgo.MessageFlagsEphemeral},
+	}); err != nil {
+		fmt.Println("ERROR responding to interaction " + err.Error())
+	}
+}
+
+// respondInteraction sends reply as the interaction's response, editing the placeholder if the response was deferred
+func respondInteraction(s discord.Session, interaction *discordgo.Interaction, deferred bool, reply string) {
+	if !deferred {
+		if len(reply) == 0 {
+			respondEphemeral(s, interaction, "👍")
+			return
+		}
+		if err := s.InteractionRespond(interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{Content: reply},
+		}); err != nil {
+			fmt.Println("ERROR responding to interaction " + err.Error())
+		}
+		return
+	}
+	//commands that reply with files or reactions leave nothing to put in the placeholder
+	if len(reply) == 0 {
+		if err := s.InteractionResponseDelete(interaction); err != nil {
+			fmt.Println("ERROR deleting interaction response " + err.Error())
+		}
+		return
+	}
+	if _, err := s.InteractionResponseEdit(interaction, &discordgo.WebhookEdit{Content: &reply}); err != nil {
+		fmt.Println("ERROR editing interaction response " + err.Error())
+	}
+}
+
+func handleInteractionCreate(s discord.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+	deferred := false
+	defer func() {
+		if r := recover(); r != nil {
+			//the panic stays in the log, the user just hears that the command failed
+			fmt.Printf("PANIC in interaction %+v\n%s\n", r, debug.Stack())
+			const failed = "⚠ Something went wrong running that command"
+			if !deferred {
+				respondEphemeral(s, i.Interaction, failed)
+				return
+			}
+			reply := failed
+			if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &reply}); err != nil {
+				fmt.Println("ERROR editing interaction response " + err.Error())
+			}
+		}
+	}()
+
+	commandData := i.ApplicationCommandData()
+	cmd, valid := lookupCommand(commandData.Name)
+	if !valid {
+		respondEphemeral(s, i.Interaction, "Unknown command")
+		return
+	}
+	author := i.User
+	if i.Member != nil {
+		author = i.Member.User
+	}
+	if commandDisabled(i.GuildID, cmd) {
+		respondEphemeral(s, i.Interaction, "That command isn't available here")
+		return
+	}
+	guild := bot.guild(i.GuildID)
+	if guild.isMuted(author.ID) {
+		respondEphemeral(s, i.Interaction, "You're muted")
+		return
+	}
+	if guild.isIgnored(author.ID) {
+		respondEphemeral(s, i.Interaction, "I'm ignoring you")
+		return
+	}
+
+	tokens := interactionTokens(cmd, commandData.Options)
+
+	//record the invocation like a message so stats and votes (which reference message IDs) see it
+	content := "/" + strings.Join(append([]string{cmd.name}, tokens...), " ")
+	if err := data.Messages.Insert(i.ID, i.ChannelID, author.ID, content); err != nil {
+		fmt.Println("ERROR inserting into Message")
+		fmt.Println(err.Error())
+	}
+
+	//discord wants a response within 3 seconds, so anything that would show typing gets a deferred response
+	deferred = !cmd.noTyping
+	if deferred {
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		}); err != nil {
+			fmt.Println("ERROR deferring interaction response " + err.Error())
+			return
+		}
+	}
+
+	reply, err := cmd.execute(s, "/", i.GuildID, i.ChannelID, author.ID, i.ID, tokens)
+	if err != nil {
+		recordCommandError(cmd.name, tokens, err)
+		reply = fmt.Sprintf("⚠ `%s`", err.Error())
+	}
+	respondInteraction(s, i.Interaction, deferred, reply)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+)
+
+func TestInteractionPanic(t *testing.T) {
+	for _, noTyping := range []bool{true, false} {
+		b := newTestBot(t)
+		registerCommand(&command{name: "explode", category: categoryUtility, noTyping: noTyping,
+			run: func(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+				panic("secret internals")
+			}})
+		handleInteractionCreate(b.fake, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			ID: "100", Type: discordgo.InteractionApplicationCommand, GuildID: testGuildID, ChannelID: testChanID,
+			Member: &discordgo.Member{User: b.alice}, Data: discordgo.ApplicationCommandInteractionData{Name: "explode"},
+		}})
+		unregisterCommand("explode")
+
+		sent := b.fake.Sent()
+		if len(sent) != 1 || sent[0].Interaction == nil || sent[0].Interaction.ID != "100" {
+			t.Fatalf("noTyping %v: bot sent %+v, want only the interaction's response", noTyping, sent)
+		}
+		if strings.Contains(sent[0].Content, "secret internals") || !strings.HasPrefix(sent[0].Content, "⚠") {
+			t.Errorf("noTyping %v: response is %q, want an error without the panic", noTyping, sent[0].Content)
+		}
+		if noTyping && sent[0].Flags&discordgo.MessageFlagsEphemeral == 0 {
+			t.Error("immediate response to a panic isn't ephemeral")
+		}
+	}
+}
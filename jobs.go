@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/heydabop/disgo/discord"
+	"github.com/heydabop/disgo/scheduler"
+)
+
+const jobsListLimit = 25
+
+// jobs runs everything disgo does on a timer, it's started in main once the database is migrated
+var jobs *scheduler.Scheduler
+
+// startJobs schedules the recurring jobs and any reminders that haven't been sent
+func startJobs(session discord.Session) {
+	recurring := []scheduler.Job{
+		{Name: "allowance", Schedule: scheduler.MustCron("0 0 * * *"), CatchUp: true, Run: giveAllowance},
+	}
+	if len(cfg.ShippoToken) > 0 {
+		recurring = append(recurring, scheduler.Job{Name: "shipments", Schedule: scheduler.Every(5 * time.Minute), CatchUp: true, Run: func() { checkShipments(session) }})
+	}
+	for _, job := range recurring {
+		if err := jobs.Add(job); err != nil {
+			fmt.Println("ERROR scheduling "+job.Name, err)
+		}
+	}
+
+	reminders, err := data.Reminders.Pending()
+	if err != nil {
+		fmt.Println("ERROR setting reminders", err)
+	}
+	for _, reminder := range reminders {
+		if err := scheduleReminder(session, reminder); err != nil {
+			fmt.Println("ERROR setting reminder", err)
+		}
+	}
+}
+
+func listJobs(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	statuses := jobs.Jobs()
+	if len(statuses) == 0 {
+		return "Nothing's scheduled", nil
+	}
+	now := time.Now()
+	lines := []string{"**Jobs**"}
+	for i, job := range statuses {
+		if i == jobsListLimit {
+			lines = append(lines, fmt.Sprintf("...and %d more", len(statuses)-i))
+			break
+		}
+		var next string
+		switch {
+		case job.Running:
+			next = "running now"
+		case job.Next.IsZero():
+			next = "not scheduled"
+		case job.Next.After(now):
+			next = "next in " + timeSinceStr(job.Next.Sub(now))
+		default:
+			next = "due now"
+		}
+		line := fmt.Sprintf("`%s` %s", job.Name, next)
+		if !job.LastRun.IsZero() {
+			line += ", last ran " + timeSinceStr(now.Sub(job.LastRun)) + " ago"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
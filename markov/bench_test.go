@@ -0,0 +1,187 @@
+package markov
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sliceChain is the layout chains had before successors were counted: every word seen after a state is appended again,
+// and states are the words themselves joined by spaces. It's kept here to benchmark against.
+type sliceChain struct {
+	order int
+	graph map[string][]string
+}
+
+func newSliceChain(order int) *sliceChain {
+	return &sliceChain{order: order, graph: make(map[string][]string)}
+}
+
+func (c *sliceChain) add(line string) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return
+	}
+	state := make([]string, c.order)
+	for i := range state {
+		state[i] = start
+	}
+	for _, word := range append(words, end) {
+		key := strings.Join(state, " ")
+		c.graph[key] = append(c.graph[key], word)
+		state = append(state[1:], word)
+	}
+}
+
+func (c *sliceChain) generate(r *rand.Rand) string {
+	state := make([]string, c.order)
+	for i := range state {
+		state[i] = start
+	}
+	var words []string
+	for len(words) < maxWords {
+		next := c.graph[strings.Join(state, " ")]
+		if len(next) == 0 {
+			break
+		}
+		word := next[r.Intn(len(next))]
+		if word == end {
+			break
+		}
+		words = append(words, word)
+		state = append(state[1:], word)
+	}
+	return strings.Join(words, " ")
+}
+
+var (
+	corpus     []string
+	corpusOnce sync.Once
+)
+
+// benchCorpus is every line comment in the Go standard library, a few hundred thousand lines of real sentences
+func benchCorpus(b *testing.B) []string {
+	corpusOnce.Do(func() {
+		filepath.Walk(filepath.Join(runtime.GOROOT(), "src"), func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !strings.HasSuffix(path, ".go") {
+				return nil
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				return nil
+			}
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				if line := strings.TrimSpace(scanner.Text()); strings.HasPrefix(line, "//") {
+					corpus = append(corpus, strings.TrimSpace(line[2:]))
+				}
+			}
+			return nil
+		})
+	})
+	if len(corpus) == 0 {
+		b.Skip("no Go source under GOROOT to read comments from")
+	}
+	return corpus
+}
+
+func BenchmarkAdd(b *testing.B) {
+	corpus := benchCorpus(b)
+	for order := 1; order <= 3; order++ {
+		b.Run(fmt.Sprintf("slices/order%d", order), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				chain := newSliceChain(order)
+				for _, line := range corpus {
+					chain.add(line)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("counts/order%d", order), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				chain := NewChain(order)
+				for _, line := range corpus {
+					chain.Add(line)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	corpus := benchCorpus(b)
+	for order := 1; order <= 3; order++ {
+		slices := newSliceChain(order)
+		counts := NewChain(order)
+		for _, line := range corpus {
+			slices.add(line)
+			counts.Add(line)
+		}
+		b.Run(fmt.Sprintf("slices/order%d", order), func(b *testing.B) {
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < b.N; i++ {
+				slices.generate(r)
+			}
+		})
+		b.Run(fmt.Sprintf("counts/order%d", order), func(b *testing.B) {
+			opts := Options{Rand: rand.New(rand.NewSource(1))}
+			for i := 0; i < b.N; i++ {
+				counts.Generate(opts)
+			}
+		})
+	}
+}
+
+// heapAfter returns how many bytes of heap build leaves in use, with what it returns still alive
+func heapAfter(build func() interface{}) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	kept := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(kept)
+	return after.HeapAlloc - before.HeapAlloc
+}
+
+// BenchmarkMemory reports the heap each layout holds for the corpus as MB, along with Size's estimate for the counted one
+func BenchmarkMemory(b *testing.B) {
+	corpus := benchCorpus(b)
+	for order := 1; order <= 3; order++ {
+		b.Run(fmt.Sprintf("slices/order%d", order), func(b *testing.B) {
+			var heap uint64
+			for i := 0; i < b.N; i++ {
+				heap = heapAfter(func() interface{} {
+					chain := newSliceChain(order)
+					for _, line := range corpus {
+						chain.add(string([]byte(line))) //a fresh copy, like a line read from the database
+					}
+					return chain
+				})
+			}
+			b.ReportMetric(float64(heap)/(1<<20), "MB")
+		})
+		b.Run(fmt.Sprintf("counts/order%d", order), func(b *testing.B) {
+			var heap uint64
+			var size int64
+			for i := 0; i < b.N; i++ {
+				heap = heapAfter(func() interface{} {
+					chain := NewChain(order)
+					for _, line := range corpus {
+						chain.Add(string([]byte(line)))
+					}
+					size = chain.Size()
+					return chain
+				})
+			}
+			b.ReportMetric(float64(heap)/(1<<20), "MB")
+			b.ReportMetric(float64(size)/(1<<20), "sizeMB")
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package markov
+
+import (
+	"container/list"
+	"sync"
+)
+
+type cacheEntry struct {
+	key     string
+	version string //which version of key's model this is, see GetVersion
+	model   *Model
+	err     error
+	size    int64         //model's size as of the last time the cache counted it, guarded by the cache's mutex
+	ready   chan struct{} //closed once model or err is set
+}
+
+func (e *cacheEntry) built() bool {
+	select {
+	case <-e.ready:
+		return e.err == nil
+	default:
+		return false
+	}
+}
+
+// Cache keeps models by key up to a memory budget, dropping the least recently used ones when it's over
+type Cache struct {
+	mutex   sync.Mutex
+	budget  int64
+	size    int64      //total size of the built models
+	recent  *list.List //*cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+// NewCache returns a cache that holds about budget bytes of models
+func NewCache(budget int64) *Cache {
+	return &Cache{budget: budget, recent: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get returns the model cached as key, building it first if it isn't cached.
+// Callers asking for a key that's being built wait for that build rather than starting their own.
+func (c *Cache) Get(key string, build func() (*Model, error)) (*Model, error) {
+	return c.GetVersion(key, "", build)
+}
+
+// GetVersion is Get for a key whose model can change, like one saved to a file, version telling them apart.
+// A model cached as key at any other version is dropped and built again.
+func (c *Cache) GetVersion(key, version string, build func() (*Model, error)) (*Model, error) {
+	c.mutex.Lock()
+	if element, found := c.entries[key]; found {
+		e := element.Value.(*cacheEntry)
+		if e.version == version {
+			c.recent.MoveToFront(element)
+			c.mutex.Unlock()
+			<-e.ready
+			return e.model, e.err
+		}
+		c.remove(element) //anyone still building or using it keeps their copy
+	}
+	e := &cacheEntry{key: key, version: version, ready: make(chan struct{})}
+	c.entries[key] = c.recent.PushFront(e)
+	c.mutex.Unlock()
+
+	e.model, e.err = build()
+	var size int64
+	if e.err == nil {
+		size = e.model.Size()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	close(e.ready)
+	element, found := c.entries[key]
+	if !found || element.Value != e {
+		return e.model, e.err //replaced while it was built, so its size isn't counted
+	}
+	if e.err != nil {
+		c.remove(element)
+		return nil, e.err
+	}
+	e.size = size
+	c.size += size
+	c.trim()
+	return e.model, nil
+}
+
+// Learn adds line to the model cached as key, if there is one, so it doesn't need rebuilding to include it.
+// Only that model is locked while line is added, so learning doesn't hold up the rest of the cache.
+func (c *Cache) Learn(key, line string) {
+	c.mutex.Lock()
+	element, found := c.entries[key]
+	if !found || !element.Value.(*cacheEntry).built() {
+		c.mutex.Unlock()
+		return
+	}
+	e := element.Value.(*cacheEntry)
+	c.mutex.Unlock()
+
+	grown := e.model.grow(line)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if current, found := c.entries[key]; !found || current != element {
+		return //dropped while line was being added, so its size is no longer counted
+	}
+	e.size += grown
+	c.size += grown
+	c.trim()
+}
+
+// Size is roughly how many bytes the cached models hold
+func (c *Cache) Size() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.size
+}
+
+func (c *Cache) remove(element *list.Element) {
+	e := element.Value.(*cacheEntry)
+	c.recent.Remove(element)
+	delete(c.entries, e.key)
+	c.size -= e.size
+}
+
+// trim drops least recently used models until the cache fits its budget, always keeping the most recent one.
+// Models still being built are left alone. Callers hold the mutex.
+func (c *Cache) trim() {
+	for element := c.recent.Back(); element != nil && element != c.recent.Front() && c.size > c.budget; {
+		prev := element.Prev()
+		if element.Value.(*cacheEntry).built() {
+			c.remove(element)
+		}
+		element = prev
+	}
+}
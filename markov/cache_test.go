@@ -0,0 +1,99 @@
+package markov
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func buildFrom(lines ...string) func() (*Model, error) {
+	return func() (*Model, error) {
+		model := NewModel(2)
+		for _, line := range lines {
+			model.Add(line)
+		}
+		return model, nil
+	}
+}
+
+func TestCacheSizeFollowsLearn(t *testing.T) {
+	c := NewCache(1 << 20)
+	a, _ := c.Get("a", buildFrom("the cat sat on the mat"))
+	b, _ := c.Get("b", buildFrom("a dog ate my homework"))
+	if _, err := c.Get("broken", func() (*Model, error) { return nil, errors.New("no") }); err == nil {
+		t.Error("Get returned no error from a failed build")
+	}
+	c.Learn("a", "the cat ate the dog")
+	c.Learn("b", "my dog sat")
+	c.Learn("missing", "nobody has this model")
+	if want := a.Size() + b.Size(); c.Size() != want {
+		t.Errorf("cache size %d, want %d", c.Size(), want)
+	}
+}
+
+func TestCacheGetVersion(t *testing.T) {
+	c := NewCache(1 << 20)
+	old, _ := c.GetVersion("file", "1", buildFrom("the cat sat on the mat"))
+	if same, _ := c.GetVersion("file", "1", nil); same != old {
+		t.Error("the same version was built again")
+	}
+	retrained, _ := c.GetVersion("file", "2", buildFrom("a dog ate my homework", "my dog sat"))
+	if retrained == old {
+		t.Fatal("a new version returned the old model")
+	}
+	if latest, _ := c.GetVersion("file", "2", nil); latest != retrained {
+		t.Error("the new version wasn't kept")
+	}
+	if c.Size() != retrained.Size() {
+		t.Errorf("cache size %d, want only the new version's %d", c.Size(), retrained.Size())
+	}
+}
+
+func TestCacheTrimsLeastRecentlyUsed(t *testing.T) {
+	first, _ := buildFrom("one two three four")()
+	c := NewCache(first.Size() * 5 / 2)
+	c.Get("a", buildFrom("one two three four"))
+	c.Get("b", buildFrom("five six seven eight"))
+	c.Get("a", nil) //used more recently than b now
+	c.Get("c", buildFrom("nine ten eleven twelve"))
+
+	built := 0
+	for _, key := range []string{"a", "c", "b"} { //b last, since building it again evicts another
+		if _, err := c.Get(key, func() (*Model, error) { built++; return first, nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if built != 1 {
+		t.Errorf("rebuilt %d models, want only b", built)
+	}
+	if c.Size() > first.Size()*5/2 {
+		t.Errorf("cache size %d is over its budget %d", c.Size(), first.Size()*5/2)
+	}
+}
+
+func TestCacheConcurrentLearn(t *testing.T) {
+	c := NewCache(1 << 20)
+	models := make([]*Model, 4)
+	for i := range models {
+		models[i], _ = c.Get(fmt.Sprint(i), buildFrom("start"))
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				c.Learn(fmt.Sprint((i+j)%len(models)), fmt.Sprintf("word%d word%d end", i, j))
+			}
+		}(i)
+	}
+	wg.Wait()
+	var want int64
+	for _, model := range models {
+		want += model.Size()
+	}
+	if c.Size() != want {
+		t.Errorf("cache size %d after concurrent learning, want %d", c.Size(), want)
+	}
+}
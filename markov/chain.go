@@ -0,0 +1,380 @@
+package markov
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+const (
+	startID uint32 = iota //the state before a line's first word is made of these
+	endID                 //follows a line's last word
+)
+
+const (
+	wideSuccessors = 32   //how many words can follow a state before finding one by scanning is slower than indexing them
+	defaultTries   = 100  //sentences Generate tries when Options.Tries isn't set
+	maxWords       = 1000 //where walks give up, in case they're going around a loop in the chain
+)
+
+// ErrNoSentence is returned by Generate when none of the sentences it tried met its options
+var ErrNoSentence = errors.New("markov: no sentence meets the options")
+
+// transitions counts the words seen on one side of each state
+type transitions struct {
+	counts map[string]successors       //states, packed by stateKey, to the words seen beside them
+	wide   map[string]map[uint32]int32 //positions in counts of the words beside states with more than wideSuccessors of them
+}
+
+func newTransitions() transitions {
+	return transitions{counts: make(map[string]successors), wide: make(map[string]map[uint32]int32)}
+}
+
+// Chain is a markov chain where each word depends on the order words before it.
+// Words are interned and counted rather than stored per occurrence, so it grows with the vocabulary rather than the corpus.
+// It isn't safe for concurrent use, Model wraps one that is.
+type Chain struct {
+	order  int
+	words  []string            //word IDs to words
+	ids    map[string]uint32   //words to IDs
+	folded map[string][]uint32 //lower case words to the IDs of every word that matches them ignoring case, for Options.Seed
+	next   transitions         //the last order word IDs to the words seen after them
+	ending map[uint32][]string //word IDs to the states in next ending with them, in the order they were first seen, for Options.Seed
+	prev   transitions         //order whole words to the word seen before them, or startID, for generating backward from a seed
+	lines  map[uint64]struct{} //lineHash of every line added, for Options.Novel
+	size   int64
+}
+
+// Options tune Generate
+type Options struct {
+	Rand     *rand.Rand //source of randomness, nil for math/rand's shared one, set with a fixed seed for repeatable output
+	Seed     []string   //words the sentence has to contain together, matched ignoring case; it's generated backward and forward from them
+	MinWords int        //fewest words the sentence can have, 0 for no limit
+	MaxWords int        //most words the sentence can have, 0 for no limit
+	Novel    bool       //whether to reject sentences that are exactly a line the chain was given; ones that are only part of a line still count as novel
+	Tries    int        //how many sentences to try for one meeting the options, 0 for 100
+}
+
+func (o Options) intn(n int) int {
+	if o.Rand != nil {
+		return o.Rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+// NewChain returns an empty chain of order, at least 1
+func NewChain(order int) *Chain {
+	if order < 1 {
+		order = 1
+	}
+	return &Chain{
+		order:  order,
+		words:  []string{start, end},
+		ids:    make(map[string]uint32),
+		folded: make(map[string][]uint32),
+		next:   newTransitions(),
+		ending: make(map[uint32][]string),
+		prev:   newTransitions(),
+		lines:  make(map[uint64]struct{}),
+	}
+}
+
+func (c *Chain) Order() int {
+	return c.order
+}
+
+// Size is roughly how many bytes the chain holds
+func (c *Chain) Size() int64 {
+	return c.size
+}
+
+// startState is the state before the first word of a line
+func (c *Chain) startState() []uint32 {
+	state := make([]uint32, c.order)
+	for i := range state {
+		state[i] = startID
+	}
+	return state
+}
+
+// stateKey packs state into buf, reusing its memory, to look it up in transitions
+func stateKey(buf []byte, state []uint32) []byte {
+	buf = buf[:0]
+	for _, id := range state {
+		buf = append(buf, byte(id), byte(id>>8), byte(id>>16), byte(id>>24))
+	}
+	return buf
+}
+
+// readState unpacks key, made by stateKey, into state
+func readState(state []uint32, key string) {
+	for i := range state {
+		state[i] = uint32(key[4*i]) | uint32(key[4*i+1])<<8 | uint32(key[4*i+2])<<16 | uint32(key[4*i+3])<<24
+	}
+}
+
+// lineHash is the 64-bit FNV-1a hash of a line's word IDs
+func lineHash(ids []uint32) uint64 {
+	hash := uint64(14695981039346656037)
+	for _, id := range ids {
+		for shift := uint(0); shift < 32; shift += 8 {
+			hash ^= uint64(byte(id >> shift))
+			hash *= 1099511628211
+		}
+	}
+	return hash
+}
+
+func contains(ids []uint32, id uint32) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// id interns word
+func (c *Chain) id(word string) uint32 {
+	if id, found := c.ids[word]; found {
+		return id
+	}
+	word = string([]byte(word)) //a copy, so the chain doesn't keep the whole line word was cut from
+	id := uint32(len(c.words))
+	c.words = append(c.words, word)
+	c.ids[word] = id
+	c.size += int64(len(word) + vocabOverhead)
+	lower := strings.ToLower(word)
+	if _, found := c.folded[lower]; !found && lower != word {
+		c.size += int64(len(lower)) //word itself is shared when it's already lower case
+	}
+	c.folded[lower] = append(c.folded[lower], id)
+	return id
+}
+
+// Add adds the words of line to the chain
+func (c *Chain) Add(line string) {
+	words := prune(strings.Fields(line))
+	if len(words) == 0 {
+		return
+	}
+	ids := make([]uint32, len(words))
+	state := c.startState()
+	key := make([]byte, 0, 4*c.order)
+	for i, word := range words {
+		ids[i] = c.id(word)
+		c.observe(&c.next, stateKey(key, state), ids[i], 1)
+		state = append(state[1:], ids[i])
+	}
+	c.observe(&c.next, stateKey(key, state), endID, 1)
+	for i := 0; i+c.order <= len(ids); i++ {
+		before := startID
+		if i > 0 {
+			before = ids[i-1]
+		}
+		c.observe(&c.prev, stateKey(key, ids[i:i+c.order]), before, 1)
+	}
+	if hash := lineHash(ids); !c.hasLine(hash) {
+		c.lines[hash] = struct{}{}
+		c.size += lineOverhead
+	}
+}
+
+func (c *Chain) hasLine(hash uint64) bool {
+	_, found := c.lines[hash]
+	return found
+}
+
+// observe counts the word id seen n more times beside the state packed in key
+func (c *Chain) observe(t *transitions, key []byte, id, n uint32) {
+	seen, found := t.counts[string(key)]
+	if !found {
+		c.size += int64(len(key) + stateOverhead)
+		if t == &c.next {
+			//stored now, so counts and ending share the key's bytes
+			state := string(key)
+			t.counts[state] = seen
+			last := uint32(key[len(key)-4]) | uint32(key[len(key)-3])<<8 | uint32(key[len(key)-2])<<16 | uint32(key[len(key)-1])<<24
+			c.ending[last] = append(c.ending[last], state)
+		}
+	}
+	index := t.wide[string(key)]
+	position := -1
+	if index != nil {
+		if i, found := index[id]; found {
+			position = int(i)
+		}
+	} else {
+		position = seen.find(id)
+	}
+	if position >= 0 {
+		seen.add(position, n)
+		return //the slice didn't grow, so seen needn't be stored back
+	}
+
+	position = seen.push(id)
+	seen.add(position, n-1)
+	t.counts[string(key)] = seen
+	c.size += successorOverhead
+	if index != nil {
+		index[id] = int32(position)
+		c.size += indexOverhead
+	} else if seen.len() > wideSuccessors {
+		index = make(map[uint32]int32, seen.len())
+		for i := 0; i < seen.len(); i++ {
+			index[seen[2*i]] = int32(i)
+		}
+		t.wide[string(key)] = index
+		c.size += int64(seen.len()) * indexOverhead
+	}
+}
+
+// Generate returns a random sentence meeting opts, walked from the start of a line to its end,
+// or backward and forward from opts.Seed if it's set, or ErrNoSentence if none turned up in opts.Tries
+func (c *Chain) Generate(opts Options) (string, error) {
+	seed, found := c.seedIDs(opts.Seed)
+	if !found {
+		return "", ErrNoSentence
+	}
+	starts, totals := c.seedStates(seed)
+	if len(starts) == 0 {
+		return "", ErrNoSentence
+	}
+	if len(seed) > c.order {
+		seed = seed[c.order:] //the rest of the seed has to follow the start state
+	} else {
+		seed = nil
+	}
+	tries := opts.Tries
+	if tries <= 0 {
+		tries = defaultTries
+	}
+	limit := maxWords
+	if opts.MaxWords > 0 && opts.MaxWords < limit {
+		limit = opts.MaxWords
+	}
+
+	state := make([]uint32, c.order)
+	for try := 0; try < tries; try++ {
+		r := opts.intn(totals[len(totals)-1])
+		readState(state, starts[sort.Search(len(totals), func(i int) bool { return totals[i] > r })])
+		ids, ok := c.walk(state, seed, limit, opts)
+		if !ok || len(ids) == 0 || len(ids) < opts.MinWords || (opts.Novel && c.hasLine(lineHash(ids))) {
+			continue
+		}
+		words := make([]string, len(ids))
+		for i, id := range ids {
+			words[i] = c.words[id]
+		}
+		return strings.Join(words, " "), nil
+	}
+	return "", ErrNoSentence
+}
+
+// seedIDs returns the IDs of the words each word of seed matches ignoring case, or false if one of them matches none
+func (c *Chain) seedIDs(seed []string) ([][]uint32, bool) {
+	var seedIDs [][]uint32
+	for _, word := range prune(strings.Fields(strings.Join(seed, " "))) {
+		ids := c.folded[strings.ToLower(word)]
+		if len(ids) == 0 {
+			return nil, false
+		}
+		seedIDs = append(seedIDs, ids)
+	}
+	return seedIDs, true
+}
+
+// seedStates returns the packed states that end with the first order words of seed, or the start state with no seed,
+// with running totals of how often each was seen to pick one by
+func (c *Chain) seedStates(seed [][]uint32) ([]string, []int) {
+	if len(seed) == 0 {
+		return []string{string(stateKey(nil, c.startState()))}, []int{1}
+	}
+	if len(seed) > c.order {
+		seed = seed[:c.order]
+	}
+	var keys []string
+	state := make([]uint32, c.order)
+	for _, last := range seed[len(seed)-1] {
+		for _, key := range c.ending[last] {
+			readState(state, key)
+			matched := true
+			for i, ids := range seed[:len(seed)-1] {
+				if !contains(ids, state[c.order-len(seed)+i]) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys) //so output with the same Options.Rand depends only on what the chain holds, not the order it was added or loaded in
+
+	totals := make([]int, len(keys))
+	sum := 0
+	for i, key := range keys {
+		sum += int(c.next.counts[key].total())
+		totals[i] = sum
+	}
+	return keys, totals
+}
+
+// walk returns a sentence through state, whose words are followed by the rest of the seed, generated backward from state to the start of a line
+// and forward to its end, or false if the seed can't follow state or the sentence runs past limit words
+func (c *Chain) walk(state []uint32, seed [][]uint32, limit int, opts Options) ([]uint32, bool) {
+	key := make([]byte, 0, 4*c.order)
+	var before []uint32 //in reverse
+	back := append([]uint32(nil), state...)
+	for back[0] != startID {
+		prev := c.prev.counts[string(stateKey(key, back))]
+		total := prev.total()
+		if total == 0 {
+			break
+		}
+		id := prev.pick(uint32(opts.intn(int(total))))
+		if id == startID {
+			break
+		}
+		if before = append(before, id); len(before) > limit {
+			return nil, false
+		}
+		copy(back[1:], back)
+		back[0] = id
+	}
+
+	ids := make([]uint32, 0, len(before)+c.order)
+	for i := len(before) - 1; i >= 0; i-- {
+		ids = append(ids, before[i])
+	}
+	for _, id := range state {
+		if id != startID {
+			ids = append(ids, id)
+		}
+	}
+	state = append([]uint32(nil), state...)
+	for _, allowed := range seed {
+		id, found := c.next.counts[string(stateKey(key, state))].pickFrom(allowed, opts)
+		if !found {
+			return nil, false
+		}
+		ids = append(ids, id)
+		state = append(state[1:], id)
+	}
+	for len(ids) <= limit {
+		next := c.next.counts[string(stateKey(key, state))]
+		total := next.total()
+		if total == 0 {
+			return ids, true
+		}
+		id := next.pick(uint32(opts.intn(int(total))))
+		if id == endID {
+			return ids, true
+		}
+		ids = append(ids, id)
+		state = append(state[1:], id)
+	}
+	return nil, false
+}
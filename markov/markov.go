@@ -1,172 +1,103 @@
+// Package markov generates sentences from markov chains over a corpus of lines.
 package markov
 
 import (
-	mrand "math/rand"
+	"io"
 	"strings"
-	"time"
+	"sync"
 )
 
 const (
-	zero      = "\x00"
 	start     = "\x02"
 	end       = "\x03"
 	zeroRune  = rune('\x00')
 	startRune = rune('\x02')
-	endRune   = rune('\x02')
-)
-
-var (
-	rand        = mrand.New(mrand.NewSource(time.Now().UnixNano()))
-	startPair   = [2]string{zero, start}
-	startTriple = [3]string{zero, start, start}
+	endRune   = rune('\x03')
+
+	//rough bytes a new word, state, successor, successor index entry and line hash cost, on top of their strings,
+	//including a word's place in Chain.folded and a state's in Chain.ending
+	vocabOverhead     = 80
+	stateOverhead     = 96
+	successorOverhead = 8
+	indexOverhead     = 16
+	lineOverhead      = 16
 )
 
+// prune strips the runes the chain uses as markers out of words, dropping words that were nothing but them
 func prune(words []string) []string {
-	for i, word := range words {
-		words[i] = strings.Map(func(r rune) rune {
+	kept := words[:0]
+	for _, word := range words {
+		word = strings.Map(func(r rune) rune {
 			if r == startRune || r == endRune || r == zeroRune {
 				return -1
 			}
 			return r
 		}, word)
+		if len(word) > 0 {
+			kept = append(kept, word)
+		}
 	}
 
-	return words
+	return kept
 }
 
-//GenFirstOrder returns a random sentence generated from first-order markov chains made from the input corpus
-func GenFirstOrder(corpus []string) string {
-	graph := make(map[string][]string)
-	graph[start] = make([]string, 0)
-
-	for _, line := range corpus {
-		words := prune(strings.Fields(line))
-
-		for i, word := range words {
-			if i == 0 {
-				graph[start] = append(graph[start], word)
-			}
-			if _, found := graph[word]; !found {
-				graph[word] = make([]string, 0)
-			}
-
-			if i == len(words)-1 {
-				graph[word] = append(graph[word], end)
-			} else {
-				graph[word] = append(graph[word], words[i+1])
-			}
-		}
-	}
-
-	var words []string
-	word := start
-	for {
-		max := len(graph[word])
-		if max < 1 {
-			break
-		}
-		word = graph[word][rand.Intn(max)]
-		if word == end {
-			break
-		}
-		words = append(words, word)
-	}
-	return strings.Join(words, " ")
+// Model is a Chain that's safe for concurrent use, what Cache holds
+type Model struct {
+	mutex sync.RWMutex
+	chain *Chain
 }
 
-//GenSecondOrder returns a random sentence generated from first-order markov chains made from the input corpus
-func GenSecondOrder(corpus []string) string {
-	graph := make(map[[2]string][][2]string)
-	graph[startPair] = make([][2]string, 0)
+// NewModel returns an empty model of order, at least 1
+func NewModel(order int) *Model {
+	return &Model{chain: NewChain(order)}
+}
 
-	for _, line := range corpus {
-		words := prune(strings.Fields(line))
+func (m *Model) Order() int {
+	return m.chain.Order()
+}
 
-		for i, word := range words {
-			prevWord := start
-			nextWord := end
-			if i > 0 {
-				prevWord = words[i-1]
-			}
-			if i < len(words)-1 {
-				nextWord = words[i+1]
-			}
-			prevPair := [2]string{prevWord, word}
-			nextPair := [2]string{word, nextWord}
+// Size is roughly how many bytes the model holds
+func (m *Model) Size() int64 {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.chain.Size()
+}
 
-			if _, found := graph[prevPair]; !found {
-				graph[prevPair] = make([][2]string, 0)
-			}
+// Add adds the words of line to the model
+func (m *Model) Add(line string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.chain.Add(line)
+}
 
-			if i == 0 {
-				graph[startPair] = append(graph[startPair], prevPair)
-			}
-			graph[prevPair] = append(graph[prevPair], nextPair)
-		}
-	}
+// grow adds the words of line to the model like Add, returning how many bytes that added to its Size
+func (m *Model) grow(line string) int64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	before := m.chain.Size()
+	m.chain.Add(line)
+	return m.chain.Size() - before
+}
 
-	var words []string
-	pair := startPair
-	for {
-		max := len(graph[pair])
-		if max < 1 {
-			break
-		}
-		pair = graph[pair][rand.Intn(max)]
-		if pair[1] == end {
-			break
-		}
-		words = append(words, pair[1])
+// LoadModel reads a model written by Model.Save
+func LoadModel(r io.Reader) (*Model, error) {
+	chain, err := LoadChain(r)
+	if err != nil {
+		return nil, err
 	}
-	return strings.Join(words, " ")
+	return &Model{chain: chain}, nil
 }
 
-//GenThirdOrder returns a random sentence generated from first-order markov chains made from the input corpus
-func GenThirdOrder(corpus []string) string {
-	graph := make(map[[3]string][][3]string)
-	graph[startTriple] = make([][3]string, 0)
-
-	for _, line := range corpus {
-		words := prune(strings.Fields(line))
-
-		for i, word := range words {
-			prevWords := []string{start, start}
-			nextWord := end
-			if i > 0 {
-				prevWords[1] = words[i-1]
-				if i > 1 {
-					prevWords[0] = words[i-2]
-				}
-			}
-			if i < len(words)-1 {
-				nextWord = words[i+1]
-			}
-			prevTriple := [3]string{prevWords[0], prevWords[1], word}
-			nextTriple := [3]string{prevWords[1], word, nextWord}
-
-			if _, found := graph[prevTriple]; !found {
-				graph[prevTriple] = make([][3]string, 0)
-			}
-
-			if i == 0 {
-				graph[startTriple] = append(graph[startTriple], prevTriple)
-			}
-			graph[prevTriple] = append(graph[prevTriple], nextTriple)
-		}
-	}
+// Save writes the model to w for LoadModel
+func (m *Model) Save(w io.Writer) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.chain.Save(w)
+}
 
-	var words []string
-	triple := startTriple
-	for {
-		max := len(graph[triple])
-		if max < 1 {
-			break
-		}
-		triple = graph[triple][rand.Intn(max)]
-		if triple[2] == end {
-			break
-		}
-		words = append(words, triple[2])
-	}
-	return strings.Join(words, " ")
+// Generate returns a random sentence from the model meeting opts, whose Rand mustn't be shared with other goroutines
+func (m *Model) Generate(opts Options) (string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.chain.Generate(opts)
 }
@@ -0,0 +1,207 @@
+package markov
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Chains are saved as fileMagic and fileVersion followed by uvarints:
+// the order, the vocabulary as length-prefixed words, then the forward and backward transitions, each as a count of states
+// followed by every state's order word IDs and its successors as a count of (word ID, times seen) pairs,
+// and last the line hashes as a count followed by little-endian uint64s.
+// States and hashes are written sorted so the same chain always saves to the same bytes.
+const (
+	fileMagic   = "disgo markov"
+	fileVersion = 1
+
+	maxFileOrder = 64      //far past anything useful, so a corrupt file can't make states huge
+	maxFileWord  = 1 << 20 //longer than a message can be
+)
+
+// errCorrupt is what LoadChain returns, maybe wrapped with the details, for anything that isn't a whole chain file
+var errCorrupt = errors.New("markov: corrupt chain file")
+
+type encoder struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func (e *encoder) uvarint(v uint64) {
+	if e.err == nil {
+		_, e.err = e.w.Write(e.buf[:binary.PutUvarint(e.buf[:], v)])
+	}
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+func (e *encoder) transitions(t transitions, order int) {
+	keys := make([]string, 0, len(t.counts))
+	for key := range t.counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	e.uvarint(uint64(len(keys)))
+	state := make([]uint32, order)
+	for _, key := range keys {
+		readState(state, key)
+		for _, id := range state {
+			e.uvarint(uint64(id))
+		}
+		seen := t.counts[key]
+		e.uvarint(uint64(seen.len()))
+		for i := 0; i < seen.len(); i++ {
+			e.uvarint(uint64(seen[2*i]))
+			e.uvarint(uint64(seen.count(i)))
+		}
+	}
+}
+
+// Save writes the chain to w in a compact binary form that LoadChain reads back
+func (c *Chain) Save(w io.Writer) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+	e.string(fileMagic)
+	e.uvarint(fileVersion)
+	e.uvarint(uint64(c.order))
+	e.uvarint(uint64(len(c.words) - 2)) //start and end are always there
+	for _, word := range c.words[2:] {
+		e.string(word)
+	}
+	e.transitions(c.next, c.order)
+	e.transitions(c.prev, c.order)
+
+	hashes := make([]uint64, 0, len(c.lines))
+	for hash := range c.lines {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	e.uvarint(uint64(len(hashes)))
+	for _, hash := range hashes {
+		if e.err == nil {
+			binary.LittleEndian.PutUint64(e.buf[:8], hash)
+			_, e.err = e.w.Write(e.buf[:8])
+		}
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type decoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.err = err
+	}
+	return v
+}
+
+// id reads a word ID, checking it's in the vocabulary
+func (d *decoder) id(c *Chain) uint32 {
+	id := d.uvarint()
+	if d.err == nil && id >= uint64(len(c.words)) {
+		d.err = errCorrupt
+	}
+	return uint32(id)
+}
+
+func (d *decoder) string() string {
+	n := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	if n > maxFileWord {
+		d.err = errCorrupt
+		return ""
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		d.err = err
+	}
+	return string(buf)
+}
+
+func (d *decoder) transitions(c *Chain, t *transitions) {
+	states := d.uvarint()
+	state := make([]uint32, c.order)
+	key := make([]byte, 0, 4*c.order)
+	for ; states > 0 && d.err == nil; states-- {
+		for i := range state {
+			state[i] = d.id(c)
+		}
+		key = stateKey(key, state)
+		for successors := d.uvarint(); successors > 0 && d.err == nil; successors-- {
+			id := d.id(c)
+			count := d.uvarint()
+			if d.err == nil && (count == 0 || count > 1<<32-1) {
+				d.err = errCorrupt
+			}
+			if d.err == nil {
+				c.observe(t, key, id, uint32(count))
+			}
+		}
+	}
+}
+
+// LoadChain reads a chain written by Chain.Save
+func LoadChain(r io.Reader) (*Chain, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+	if magic := d.string(); d.err == nil && magic != fileMagic {
+		return nil, fmt.Errorf("%w: not a chain file", errCorrupt)
+	}
+	if version := d.uvarint(); d.err == nil && version != fileVersion {
+		return nil, fmt.Errorf("%w: version %d, expected %d", errCorrupt, version, fileVersion)
+	}
+	order := d.uvarint()
+	if d.err == nil && (order < 1 || order > maxFileOrder) {
+		return nil, errCorrupt
+	}
+	c := NewChain(int(order))
+	for words := d.uvarint(); words > 0 && d.err == nil; words-- {
+		word := d.string()
+		if _, found := c.ids[word]; found {
+			return nil, errCorrupt //a repeated word would shift every ID after it
+		}
+		if d.err == nil {
+			c.id(word)
+		}
+	}
+	d.transitions(c, &c.next)
+	d.transitions(c, &c.prev)
+	buf := make([]byte, 8)
+	for hashes := d.uvarint(); hashes > 0 && d.err == nil; hashes-- {
+		if _, err := io.ReadFull(d.r, buf); err != nil {
+			d.err = err
+			break
+		}
+		if hash := binary.LittleEndian.Uint64(buf); !c.hasLine(hash) {
+			c.lines[hash] = struct{}{}
+			c.size += lineOverhead
+		}
+	}
+
+	if d.err == io.EOF || d.err == io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("%w: %v", errCorrupt, io.ErrUnexpectedEOF)
+	} else if d.err != nil {
+		return nil, d.err
+	}
+	return c, nil
+}
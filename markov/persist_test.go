@@ -0,0 +1,96 @@
+package markov
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// encode writes a chain file by hand, uint64s as uvarints and strings length-prefixed, to build broken ones
+func encode(parts ...interface{}) []byte {
+	var buf bytes.Buffer
+	e := &encoder{w: bufio.NewWriter(&buf)}
+	for _, part := range parts {
+		switch part := part.(type) {
+		case uint64:
+			e.uvarint(part)
+		case int:
+			e.uvarint(uint64(part))
+		case string:
+			e.string(part)
+		}
+	}
+	e.w.Flush()
+	return buf.Bytes()
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	for order := 1; order <= 3; order++ {
+		chain := testChain(order, append([]string{"The Dog barked", ""}, testCorpus...)...)
+		var saved bytes.Buffer
+		if err := chain.Save(&saved); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadChain(bytes.NewReader(saved.Bytes()))
+		if err != nil {
+			t.Fatalf("order %d: %v", order, err)
+		}
+		if loaded.Order() != order || loaded.Size() != chain.Size() {
+			t.Errorf("order %d: loaded chain has order %d and size %d, want %d and %d", order, loaded.Order(), loaded.Size(), order, chain.Size())
+		}
+		var resaved bytes.Buffer
+		if err := loaded.Save(&resaved); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(saved.Bytes(), resaved.Bytes()) {
+			t.Errorf("order %d: loaded chain saved differently", order)
+		}
+	}
+}
+
+func TestLoadChainCorrupt(t *testing.T) {
+	var valid bytes.Buffer
+	if err := testChain(2, testCorpus...).Save(&valid); err != nil {
+		t.Fatal(err)
+	}
+	//an order 1 chain of just "a", IDs 0 to 2 being start, end and a
+	header := []interface{}{fileMagic, fileVersion, 1, 1, "a"}
+	chain := func(parts ...interface{}) []byte {
+		return encode(append(append([]interface{}{}, header...), parts...)...)
+	}
+	tests := []struct {
+		name string
+		file []byte
+	}{
+		{"empty", nil},
+		{"truncated", valid.Bytes()[:valid.Len()/2]},
+		{"missing hashes", valid.Bytes()[:valid.Len()-1]},
+		{"bad magic", encode("disgo markox", fileVersion, 1, 0, 0, 0, 0)},
+		{"not a chain", []byte("hello there, this is a text file\n")},
+		{"newer version", encode(fileMagic, fileVersion+1, 1, 0, 0, 0, 0)},
+		{"order 0", encode(fileMagic, fileVersion, 0, 0, 0, 0, 0)},
+		{"order too big", encode(fileMagic, fileVersion, maxFileOrder+1, 0, 0, 0, 0)},
+		{"magic too long", encode(uint64(maxFileWord + 1))},
+		{"word too long", encode(fileMagic, fileVersion, 1, 1, uint64(maxFileWord+1))},
+		{"repeated word", encode(fileMagic, fileVersion, 1, 2, "a", "a", 0, 0, 0)},
+		{"state ID out of range", chain(1, 3, 1, 2, 1, 0, 0)},
+		{"successor ID out of range", chain(1, 0, 1, 3, 1, 0, 0)},
+		{"zero count", chain(1, 0, 1, 2, 0, 0, 0)},
+		{"count too big", chain(1, 0, 1, 2, uint64(1<<32), 0, 0)},
+		{"backward ID out of range", chain(0, 1, 2, 1, 99, 1, 0)},
+		{"huge counts", chain(uint64(1<<62), 0)},
+	}
+	for _, test := range tests {
+		if c, err := LoadChain(bytes.NewReader(test.file)); !errors.Is(err, errCorrupt) {
+			t.Errorf("%s: LoadChain = %v, %v, want errCorrupt", test.name, c, err)
+		}
+	}
+
+	//however a file is cut short, it's corrupt rather than a panic or a partial chain
+	for n := 0; n < valid.Len(); n++ {
+		if c, err := LoadChain(bytes.NewReader(valid.Bytes()[:n])); !errors.Is(err, errCorrupt) {
+			t.Fatalf("first %d of %d bytes: LoadChain = %v, %v, want errCorrupt", n, valid.Len(), c, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package markov
+
+// successors counts the words seen after one state, as pairs of a word ID and a Fenwick tree node in one slice,
+// which is all most states need for their single successor.
+// Tree node j, 1-based, holds the total count of words (j - j&-j, j], so counting another word and picking one by weight are both O(log n).
+type successors []uint32
+
+func (s successors) len() int {
+	return len(s) / 2
+}
+
+// node returns the index of tree node j in s
+func node(j int) int {
+	return 2*j - 1
+}
+
+// find returns the position of word, or -1
+func (s successors) find(word uint32) int {
+	for i := 0; i < len(s); i += 2 {
+		if s[i] == word {
+			return i / 2
+		}
+	}
+	return -1
+}
+
+// push adds word with a count of 1, returning its position
+func (s *successors) push(word uint32) int {
+	j := s.len() + 1
+	count := uint32(1)
+	for k := j - 1; k > j-(j&-j); k -= k & -k {
+		count += (*s)[node(k)]
+	}
+	*s = append(*s, word, count)
+	return j - 1
+}
+
+// add adds n to the count of the word at position i
+func (s successors) add(i int, n uint32) {
+	for j := i + 1; j <= s.len(); j += j & -j {
+		s[node(j)] += n
+	}
+}
+
+// prefix is the sum of the counts of the first j words
+func (s successors) prefix(j int) uint32 {
+	var sum uint32
+	for ; j > 0; j -= j & -j {
+		sum += s[node(j)]
+	}
+	return sum
+}
+
+// total is the sum of every count
+func (s successors) total() uint32 {
+	return s.prefix(s.len())
+}
+
+// count is how many times the word at position i was seen
+func (s successors) count(i int) uint32 {
+	return s.prefix(i+1) - s.prefix(i)
+}
+
+// pick returns the word whose share of the counts r, in [0, total), falls in
+func (s successors) pick(r uint32) uint32 {
+	pos := 0
+	step := 1
+	for step*2 <= s.len() {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if next := pos + step; next <= s.len() && s[node(next)] <= r {
+			pos = next
+			r -= s[node(next)]
+		}
+	}
+	return s[2*pos]
+}
+
+// pickFrom returns one of the words in allowed, chosen by its share of their counts, or false if none of them were seen
+func (s successors) pickFrom(allowed []uint32, opts Options) (uint32, bool) {
+	var words []uint32
+	var totals []uint32
+	var sum uint32
+	for i := 0; i < s.len(); i++ {
+		if contains(allowed, s[2*i]) {
+			sum += s.count(i)
+			words = append(words, s[2*i])
+			totals = append(totals, sum)
+		}
+	}
+	if sum == 0 {
+		return 0, false
+	}
+	r := uint32(opts.intn(int(sum)))
+	for i, total := range totals {
+		if r < total {
+			return words[i], true
+		}
+	}
+	return words[len(words)-1], true
+}
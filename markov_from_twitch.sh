@@ -1,8 +1,11 @@
-cp ~/irclogs/Twitch/\#$1.log ~/markov/$1_nolink
-sed -i -r 's,^--- Log opened .*$,,gm' ~/markov/$1_nolink
-sed -i -r 's,^--- Log closed .*$,,gm' ~/markov/$1_nolink
-sed -i -r 's,^..:.. .* has joined #'$1'$,,gm' ~/markov/$1_nolink
-sed -i -r 's,..:.. < .*?> ,,gm' ~/markov/$1_nolink
-sed -i -r 's,^..:.. -!- ServerMode\/.*$,,gm' ~/markov/$1_nolink
-sed -i -r 's,\.([[:alpha:]]+)\/,DOT\1,gm' ~/markov/$1_nolink
-sed -i -r '/^$/d' ~/markov/$1_nolink
+log=$(mktemp)
+cp ~/irclogs/Twitch/\#$1.log $log
+sed -i -r 's,^--- Log opened .*$,,gm' $log
+sed -i -r 's,^--- Log closed .*$,,gm' $log
+sed -i -r 's,^..:.. .* has joined #'$1'$,,gm' $log
+sed -i -r 's,..:.. < .*?> ,,gm' $log
+sed -i -r 's,^..:.. -!- ServerMode\/.*$,,gm' $log
+sed -i -r 's,\.([[:alpha:]]+)\/,DOT\1,gm' $log
+sed -i -r '/^$/d' $log
+disgo markov train $1 $log
+rm $log
@@ -0,0 +1,14 @@
+DROP TABLE vote;
+DROP TABLE voice_state;
+DROP TABLE user_presence;
+DROP TABLE user_money;
+DROP TABLE user_karma;
+DROP TABLE shipment;
+DROP TABLE reminder;
+DROP TABLE pee_log;
+DROP TABLE own_username;
+DROP TABLE message;
+DROP TABLE error_ip;
+DROP TABLE error;
+DROP TABLE discord_quote;
+DROP FUNCTION on_record_update();
@@ -0,0 +1,132 @@
+-- Reproduces the tables from the old schema.sql dump, plus reminder.sent_at which the code had been
+-- using without it ever making it into the dump. Everything is IF NOT EXISTS so databases that were
+-- created from the dump can adopt migrations without being rebuilt.
+
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+CREATE OR REPLACE FUNCTION on_record_update() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$ begin new.update_date := now(); return new; end; $$;
+
+CREATE TABLE IF NOT EXISTS discord_quote (
+    id serial PRIMARY KEY,
+    chan_id character varying(30) NOT NULL,
+    author_id character varying(30),
+    content text,
+    score integer NOT NULL,
+    is_fresh boolean NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS error (
+    id uuid DEFAULT gen_random_uuid() PRIMARY KEY,
+    create_date timestamp with time zone DEFAULT now() NOT NULL,
+    command text NOT NULL,
+    args text,
+    error text,
+    reported_count integer DEFAULT 0 NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS error_ip (
+    error_id uuid NOT NULL REFERENCES error(id),
+    ip inet NOT NULL,
+    UNIQUE (error_id, ip)
+);
+
+CREATE TABLE IF NOT EXISTS message (
+    id numeric PRIMARY KEY,
+    create_date timestamp with time zone DEFAULT now() NOT NULL,
+    chan_id numeric NOT NULL,
+    author_id numeric NOT NULL,
+    content text,
+    update_date timestamp with time zone DEFAULT now() NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS message_author_id_idx ON message USING btree (author_id);
+CREATE INDEX IF NOT EXISTS message_chan_id_idx ON message USING btree (chan_id);
+
+DROP TRIGGER IF EXISTS message_update ON message;
+CREATE TRIGGER message_update BEFORE UPDATE ON message FOR EACH ROW EXECUTE FUNCTION on_record_update();
+
+CREATE TABLE IF NOT EXISTS own_username (
+    id serial PRIMARY KEY,
+    create_date timestamp with time zone DEFAULT now() NOT NULL,
+    author_id character varying(30) NOT NULL,
+    username character varying(32) NOT NULL,
+    locked_minutes integer NOT NULL,
+    guild_id character varying(30)
+);
+
+CREATE TABLE IF NOT EXISTS pee_log (
+    create_date timestamp with time zone DEFAULT now() NOT NULL,
+    user_id character varying(30),
+    UNIQUE (create_date, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS reminder (
+    id serial PRIMARY KEY,
+    chan_id character varying(30) NOT NULL,
+    author_id character varying(30) NOT NULL,
+    send_time timestamp with time zone NOT NULL,
+    content text
+);
+
+ALTER TABLE reminder ADD COLUMN IF NOT EXISTS sent_at timestamp with time zone;
+
+CREATE TABLE IF NOT EXISTS shipment (
+    id serial PRIMARY KEY,
+    carrier text NOT NULL,
+    tracking_number text NOT NULL,
+    chan_id character varying(30) NOT NULL,
+    author_id character varying(30) NOT NULL,
+    UNIQUE (carrier, tracking_number, author_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_karma (
+    guild_id character varying(30) NOT NULL,
+    user_id character varying(30) NOT NULL,
+    karma integer NOT NULL,
+    UNIQUE (guild_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_money (
+    guild_id character varying(30) NOT NULL,
+    user_id character varying(30) NOT NULL,
+    money double precision NOT NULL,
+    UNIQUE (guild_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_presence (
+    id serial PRIMARY KEY,
+    create_date timestamp with time zone DEFAULT now() NOT NULL,
+    guild_id numeric NOT NULL,
+    user_id numeric NOT NULL,
+    presence character varying(20) NOT NULL,
+    game text
+);
+
+CREATE INDEX IF NOT EXISTS user_presence_guild_id_idx ON user_presence USING btree (guild_id);
+CREATE INDEX IF NOT EXISTS user_presence_user_id_idx ON user_presence USING btree (user_id);
+
+CREATE TABLE IF NOT EXISTS voice_state (
+    id serial PRIMARY KEY,
+    create_date timestamp with time zone DEFAULT now() NOT NULL,
+    guild_id character varying(30) NOT NULL,
+    chan_id character varying(30),
+    user_id character varying(30) NOT NULL,
+    session_id character varying(60) NOT NULL,
+    deaf boolean NOT NULL,
+    mute boolean NOT NULL,
+    self_deaf boolean NOT NULL,
+    self_mute boolean NOT NULL,
+    suppress boolean NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS vote (
+    id serial PRIMARY KEY,
+    create_date timestamp with time zone DEFAULT now() NOT NULL,
+    guild_id character varying(30) NOT NULL,
+    message_id bigint NOT NULL REFERENCES message(id),
+    voter_id character varying(30) NOT NULL,
+    votee_id character varying(30) NOT NULL,
+    is_upvote boolean NOT NULL
+);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS guild_settings (
+    guild_id character varying(30) PRIMARY KEY,
+    prefix character varying(10) DEFAULT '/' NOT NULL,
+    disabled_commands text[] DEFAULT '{}' NOT NULL,
+    disabled_categories text[] DEFAULT '{}' NOT NULL,
+    reactions_disabled boolean DEFAULT false NOT NULL
+);
+
+-- these guilds predate the commands listed, which used to be switched off for them in code
+INSERT INTO guild_settings (guild_id, disabled_commands) VALUES
+    ('161010139309015040', '{fortune,lastplayed,lastseen,math,playtime,recentplaytime,roll,top,topcommand,toplength,track,votes,whois}'),
+    ('166762056828059648', '{fortune,lastplayed,lastseen,math,playtime,recentplaytime,roll,top,topcommand,toplength,track,votes,whois}'),
+    ('184428741450006528', '{fortune,lastplayed,lastseen,math,playtime,recentplaytime,roll,top,topcommand,toplength,track,votes,whois}')
+ON CONFLICT (guild_id) DO NOTHING;
@@ -0,0 +1,3 @@
+DROP INDEX user_presence_guild_id_user_id_create_date_idx;
+
+ALTER TABLE user_presence DROP COLUMN activities;
@@ -0,0 +1,4 @@
+-- the full activity list alongside game, which stays the first "Playing" activity
+ALTER TABLE user_presence ADD COLUMN activities jsonb DEFAULT '[]' NOT NULL;
+
+CREATE INDEX user_presence_guild_id_user_id_create_date_idx ON user_presence USING btree (guild_id, user_id, create_date);
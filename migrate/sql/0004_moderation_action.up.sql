@@ -0,0 +1,17 @@
+CREATE TABLE moderation_action (
+    id serial PRIMARY KEY,
+    guild_id character varying(30) NOT NULL,
+    target_id character varying(30) NOT NULL,
+    actor_id character varying(30) NOT NULL,
+    kind text NOT NULL,
+    reason text DEFAULT '' NOT NULL,
+    start_date timestamp with time zone DEFAULT now() NOT NULL,
+    expire_date timestamp with time zone,
+    lifted_by character varying(30),
+    lifted_date timestamp with time zone
+);
+
+CREATE INDEX moderation_action_guild_id_target_id_idx ON moderation_action USING btree (guild_id, target_id, start_date);
+
+-- what has to be put back into effect on startup
+CREATE INDEX moderation_action_active_idx ON moderation_action USING btree (expire_date) WHERE lifted_date IS NULL;
@@ -0,0 +1,2 @@
+-- roles whose members may use disgo's moderation commands without the discord permissions for them
+ALTER TABLE guild_settings ADD COLUMN mod_roles text[] DEFAULT '{}' NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE moderation_action DROP COLUMN mute_role_id;
+ALTER TABLE moderation_action DROP COLUMN mute_backend;
+ALTER TABLE guild_settings DROP COLUMN mute_role_id;
+ALTER TABLE guild_settings DROP COLUMN mute_backend;
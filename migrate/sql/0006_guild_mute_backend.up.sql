@@ -0,0 +1,8 @@
+-- how mute is enforced: 'delete' removes the user's messages, 'timeout' uses discord's timeouts, 'role' gives them mute_role_id
+ALTER TABLE guild_settings ADD COLUMN mute_backend text DEFAULT 'delete' NOT NULL;
+ALTER TABLE guild_settings ADD COLUMN mute_role_id varchar(30) DEFAULT '' NOT NULL;
+
+-- how each mute was actually enforced, which can differ from the guild's setting by the time it's lifted
+ALTER TABLE moderation_action ADD COLUMN mute_backend text DEFAULT '' NOT NULL;
+ALTER TABLE moderation_action ADD COLUMN mute_role_id varchar(30) DEFAULT '' NOT NULL;
+UPDATE moderation_action SET mute_backend = 'delete' WHERE kind = 'mute';
@@ -0,0 +1 @@
+ALTER TABLE guild_settings DROP COLUMN timeout_chan_id;
@@ -0,0 +1,8 @@
+-- voice channel /timeout moves people to, '' if the guild hasn't set one
+ALTER TABLE guild_settings ADD COLUMN timeout_chan_id varchar(30) DEFAULT '' NOT NULL;
+
+-- the one channel from before timeout channels were per guild, from timeout_guild_id and timeout_chan_id in the config
+INSERT INTO guild_settings (guild_id, timeout_chan_id)
+SELECT current_setting('disgo.timeout_guild_id', true), current_setting('disgo.timeout_chan_id', true)
+WHERE coalesce(current_setting('disgo.timeout_guild_id', true), '') <> '' AND coalesce(current_setting('disgo.timeout_chan_id', true), '') <> ''
+ON CONFLICT (guild_id) DO UPDATE SET timeout_chan_id = EXCLUDED.timeout_chan_id;
@@ -0,0 +1,5 @@
+-- when each scheduled job last ran, so runs missed while the bot was down can be caught up
+CREATE TABLE job (
+    name text PRIMARY KEY,
+    last_run timestamp with time zone NOT NULL
+);
@@ -0,0 +1,5 @@
+DROP INDEX reminder_author_id_idx;
+ALTER TABLE reminder DROP COLUMN cancelled_at;
+ALTER TABLE reminder DROP COLUMN snoozed_until;
+ALTER TABLE reminder DROP COLUMN dm;
+ALTER TABLE reminder DROP COLUMN every;
@@ -0,0 +1,8 @@
+-- every is how often a reminder repeats, like '1d' or '2w', '' for one that doesn't.
+-- A snoozed reminder goes out at snoozed_until instead of send_time, which recurring reminders keep counting from.
+ALTER TABLE reminder ADD COLUMN every text DEFAULT '' NOT NULL;
+ALTER TABLE reminder ADD COLUMN dm boolean DEFAULT false NOT NULL;
+ALTER TABLE reminder ADD COLUMN snoozed_until timestamp with time zone;
+ALTER TABLE reminder ADD COLUMN cancelled_at timestamp with time zone;
+
+CREATE INDEX reminder_author_id_idx ON reminder USING btree (author_id) WHERE sent_at IS NULL AND cancelled_at IS NULL;
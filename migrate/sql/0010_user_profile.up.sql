@@ -0,0 +1,5 @@
+-- per-user preferences that follow them across guilds
+CREATE TABLE user_profile (
+    user_id character varying(30) PRIMARY KEY,
+    timezone text DEFAULT '' NOT NULL
+);
@@ -0,0 +1,10 @@
+-- what /countdown counts down to, or tracks progress towards when start_date is set
+CREATE TABLE countdown (
+    guild_id character varying(30) NOT NULL,
+    name text NOT NULL,
+    author_id character varying(30) NOT NULL,
+    start_date timestamp with time zone,
+    end_date timestamp with time zone NOT NULL,
+    annual boolean DEFAULT false NOT NULL, -- moves on a year at a time once end_date passes
+    PRIMARY KEY (guild_id, name)
+);
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+	"github.com/heydabop/disgo/store"
+)
+
+// kinds of moderation_action
+const (
+	moderationIgnore  = "ignore"
+	moderationMute    = "mute"
+	moderationTimeout = "timeout"
+)
+
+// ways a guild can enforce mutes, see /config mute
+const (
+	muteByDeleting = "delete"  //delete everything they say
+	muteByTimeout  = "timeout" //discord's own timeout
+	muteByRole     = "role"    //give them the guild's mute role
+)
+
+const (
+	defaultTimeout    = 30 * time.Second //how long /timeout keeps someone in the timeout channel if they don't say
+	modlogLimit       = 15
+	maxDiscordTimeout = 28 * 24 * time.Hour //discord refuses timeouts longer than this
+)
+
+// untilForever stands in for an action that never expires
+var untilForever = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+func actionUntil(action store.ModerationAction) time.Time {
+	if action.ExpireDate.IsZero() {
+		return untilForever
+	}
+	return action.ExpireDate
+}
+
+// auditReason is what goes in the audit log when disgo changes a member for action, escaped the way discord expects
+func auditReason(session discord.Session, action store.ModerationAction, verb, actorID string) discordgo.RequestOption {
+	actor, err := getUsername(session, actorID, action.GuildID)
+	if err != nil {
+		actor = actorID
+	}
+	reason := verb + " by " + actor
+	if len(action.Reason) > 0 {
+		reason += ": " + action.Reason
+	}
+	return discordgo.WithAuditLogReason(url.PathEscape(reason))
+}
+
+// enforceMute mutes action's target the way their guild is configured to, recording in action the backend and role that ended up enforcing it.
+// If discord refuses the timeout or role, the mute falls back to deleting their messages.
+func enforceMute(session discord.Session, action *store.ModerationAction) {
+	settings := getGuildSettings(action.GuildID)
+	action.MuteBackend, action.MuteRoleID = muteByDeleting, ""
+	var err error
+	switch settings.muteBackend {
+	case muteByTimeout:
+		until := actionUntil(*action)
+		err = session.GuildMemberTimeout(action.GuildID, action.TargetID, &until, auditReason(session, *action, "Muted", action.ActorID))
+	case muteByRole:
+		err = session.GuildMemberRoleAdd(action.GuildID, action.TargetID, settings.muteRoleID, auditReason(session, *action, "Muted", action.ActorID))
+	default:
+		return
+	}
+	if err != nil {
+		fmt.Println("ERROR muting by "+settings.muteBackend+", deleting messages instead", err)
+		return
+	}
+	action.MuteBackend = settings.muteBackend
+	if settings.muteBackend == muteByRole {
+		action.MuteRoleID = settings.muteRoleID
+	}
+}
+
+// releaseMute takes back what enforceMute did, through the backend and role recorded in action.
+// Timeouts that run out are lifted by discord, so they're only cleared when the mute is lifted early.
+func releaseMute(session discord.Session, action store.ModerationAction, liftedBy string, early bool) {
+	var err error
+	switch {
+	case action.MuteBackend == muteByRole:
+		err = session.GuildMemberRoleRemove(action.GuildID, action.TargetID, action.MuteRoleID, auditReason(session, action, "Unmuted", liftedBy))
+	case action.MuteBackend == muteByTimeout && early:
+		err = session.GuildMemberTimeout(action.GuildID, action.TargetID, nil, auditReason(session, action, "Unmuted", liftedBy))
+	}
+	if err != nil {
+		fmt.Println("ERROR unmuting "+action.TargetID, err)
+	}
+}
+
+// applyModeration puts action into effect and schedules it to be taken back out when it expires
+func applyModeration(session discord.Session, action store.ModerationAction) {
+	until := actionUntil(action)
+	switch action.Kind {
+	case moderationIgnore:
+		bot.guild(action.GuildID).ignore(action.TargetID, until)
+	case moderationMute:
+		bot.guild(action.GuildID).mute(action.TargetID, until, action.MuteBackend, action.MuteRoleID)
+	case moderationTimeout:
+		bot.guild(action.GuildID).timeOut(action.TargetID, until)
+	}
+	if !action.ExpireDate.IsZero() {
+		if err := jobs.Once(fmt.Sprintf("moderation %d", action.ID), action.ExpireDate, func() { expireModeration(session, action) }); err != nil {
+			fmt.Println("ERROR scheduling end of "+action.Kind, err)
+		}
+	}
+}
+
+// expireModeration forgets action once it's run out, unless a newer action has replaced it
+func expireModeration(session discord.Session, action store.ModerationAction) {
+	until := actionUntil(action)
+	switch action.Kind {
+	case moderationIgnore, moderationTimeout:
+		guild := bot.guild(action.GuildID)
+		guild.Lock()
+		defer guild.Unlock()
+		sanctions := guild.ignored
+		if action.Kind == moderationTimeout {
+			sanctions = guild.timedOut
+		}
+		if sanctions[action.TargetID].Equal(until) {
+			delete(sanctions, action.TargetID)
+		}
+	case moderationMute:
+		guild := bot.guild(action.GuildID)
+		guild.Lock()
+		mute, found := guild.muted[action.TargetID]
+		if found && mute.until.Equal(until) {
+			delete(guild.muted, action.TargetID)
+		}
+		guild.Unlock()
+		if found && mute.until.Equal(until) {
+			releaseMute(session, action, ownUserID, false)
+		}
+	}
+}
+
+// moderate records and applies kind against targetID for duration
+func moderate(session discord.Session, guildID, targetID, actorID, kind, reason string, duration time.Duration) error {
+	now := time.Now()
+	action := store.ModerationAction{GuildID: guildID, TargetID: targetID, ActorID: actorID, Kind: kind, Reason: reason, StartDate: now, ExpireDate: now.Add(duration)}
+	if kind == moderationMute {
+		enforceMute(session, &action) //first, so the record says how it was enforced
+	}
+	id, err := data.Moderation.Add(action)
+	if err != nil {
+		if kind == moderationMute {
+			releaseMute(session, action, actorID, true)
+		}
+		return err
+	}
+	action.ID = id
+	applyModeration(session, action)
+	return nil
+}
+
+// liftModeration ends any kind against targetID early
+func liftModeration(session discord.Session, guildID, targetID, kind, liftedBy string) error {
+	if err := data.Moderation.Lift(guildID, targetID, kind, liftedBy); err != nil {
+		return err
+	}
+	now := time.Now()
+	switch kind {
+	case moderationIgnore:
+		bot.guild(guildID).ignore(targetID, now)
+	case moderationMute:
+		guild := bot.guild(guildID)
+		action := store.ModerationAction{GuildID: guildID, TargetID: targetID}
+		if mute, found := guild.currentMute(targetID); found {
+			action.MuteBackend, action.MuteRoleID = mute.backend, mute.roleID
+		} else {
+			//the bot may have forgotten a mute discord is still enforcing
+			settings := getGuildSettings(guildID)
+			action.MuteBackend, action.MuteRoleID = settings.muteBackend, settings.muteRoleID
+		}
+		guild.mute(targetID, now, "", "")
+		releaseMute(session, action, liftedBy, true)
+	case moderationTimeout:
+		bot.guild(guildID).timeOut(targetID, now)
+	}
+	return nil
+}
+
+// loadModeration puts back into effect everything that was in effect when the bot last stopped.
+// Mutes keep the backend and role they were enforced with, whatever their guild is configured to now.
+func loadModeration(session discord.Session) error {
+	actions, err := data.Moderation.Active()
+	if err != nil {
+		return err
+	}
+	for _, action := range actions {
+		applyModeration(session, action)
+	}
+	return nil
+}
+
+// releaseMuteRoles takes guild's mute role back from anyone who isn't muted anymore, for mutes that ran out while the bot was down
+func releaseMuteRoles(session discord.Session, guild *discordgo.Guild) {
+	settings := getGuildSettings(guild.ID)
+	if settings.muteBackend != muteByRole {
+		return
+	}
+	state := bot.guild(guild.ID)
+	for _, member := range guild.Members {
+		if member.User == nil || !containsString(member.Roles, settings.muteRoleID) || state.isMuted(member.User.ID) {
+			continue
+		}
+		if err := session.GuildMemberRoleRemove(guild.ID, member.User.ID, settings.muteRoleID, discordgo.WithAuditLogReason("Mute expired")); err != nil {
+			fmt.Println("ERROR removing mute role from "+member.User.ID, err)
+		}
+	}
+}
+
+func describeModeration(session discord.Session, action store.ModerationAction, now time.Time) string {
+	actor, err := getUsername(session, action.ActorID, action.GuildID)
+	if err != nil {
+		actor = action.ActorID
+	}
+	line := fmt.Sprintf("`%s` %s by %s", action.StartDate.Format("2006-01-02 15:04"), action.Kind, actor)
+	if !action.ExpireDate.IsZero() {
+		line += " for " + timeSinceStr(action.ExpireDate.Sub(action.StartDate))
+	}
+	if len(action.Reason) > 0 {
+		line += ": " + action.Reason
+	}
+	switch {
+	case !action.LiftedDate.IsZero():
+		lifter, err := getUsername(session, action.LiftedBy, action.GuildID)
+		if err != nil {
+			lifter = action.LiftedBy
+		}
+		line += fmt.Sprintf(" (lifted by %s after %s)", lifter, timeSinceStr(action.LiftedDate.Sub(action.StartDate)))
+	case action.InEffect(now):
+		line += " (in effect)"
+	}
+	return line
+}
+
+func modlog(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	userID := args.user("@user")
+	actions, err := data.Moderation.History(guildID, userID, modlogLimit)
+	if err != nil {
+		return "", err
+	}
+	username, err := getUsername(session, userID, guildID)
+	if err != nil {
+		return "", err
+	}
+	if len(actions) == 0 {
+		return fmt.Sprintf("%s has a clean record", username), nil
+	}
+	now := time.Now()
+	lines := []string{fmt.Sprintf("**Moderation history for %s**", username)}
+	for _, action := range actions {
+		lines = append(lines, describeModeration(session, action, now))
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMuteReleasedTheWayItWasEnforced(t *testing.T) {
+	b := newTestBot(t)
+	b.fake.AddRole(testGuildID, "50", "muted", 0)
+	settings := getGuildSettings(testGuildID)
+	settings.muteBackend, settings.muteRoleID = muteByRole, "50"
+	if err := saveGuildSettings(testGuildID, settings); err != nil {
+		t.Fatal(err)
+	}
+	hasRole := func() bool {
+		member, err := b.fake.State().Member(testGuildID, b.alice.ID)
+		return err == nil && containsString(member.Roles, "50")
+	}
+
+	if err := moderate(b.fake, testGuildID, b.alice.ID, b.bob.ID, moderationMute, "", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if !hasRole() {
+		t.Fatal("alice wasn't given the mute role")
+	}
+	actions, err := data.Moderation.Active()
+	if err != nil || len(actions) != 1 || actions[0].MuteBackend != muteByRole || actions[0].MuteRoleID != "50" {
+		t.Fatalf("active actions are %+v (%v), want a mute by role 50", actions, err)
+	}
+
+	//the guild switches to deleting messages, then the bot restarts
+	settings.muteBackend, settings.muteRoleID = muteByDeleting, ""
+	if err := saveGuildSettings(testGuildID, settings); err != nil {
+		t.Fatal(err)
+	}
+	bot = botState{guilds: make(map[string]*guildState), channels: make(map[string]*channelState), users: make(map[string]*userState)}
+	if err := loadModeration(b.fake); err != nil {
+		t.Fatal(err)
+	}
+	if backend := bot.guild(testGuildID).muteBackend(b.alice.ID); backend != muteByRole {
+		t.Errorf("reloaded mute is enforced by %q, want %q", backend, muteByRole)
+	}
+	if err := liftModeration(b.fake, testGuildID, b.alice.ID, moderationMute, b.bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	if hasRole() {
+		t.Error("alice kept the mute role after the mute was lifted")
+	}
+	if history, _ := data.Moderation.History(testGuildID, b.alice.ID, 1); len(history) != 1 || history[0].InEffect(time.Now()) {
+		t.Errorf("alice's history is %+v, want the mute lifted", history)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+)
+
+// modRolePermissions is what a guild's mod roles (see /config modrole) grant for disgo's commands, on top of the roles' own permissions
+const modRolePermissions = discordgo.PermissionModerateMembers | discordgo.PermissionVoiceMoveMembers | discordgo.PermissionManageMessages
+
+const unauthorizedReply = "I don't have to listen to you."
+
+// allPermissions is what the owner and administrators have, discordgo.PermissionAll is missing newer permissions like Moderate Members
+const allPermissions = ^int64(0)
+
+// overwrite applies a channel permission overwrite to permissions
+func overwrite(permissions int64, o *discordgo.PermissionOverwrite) int64 {
+	return permissions&^o.Deny | o.Allow
+}
+
+// effectivePermissions computes userID's permissions in guildID the way Discord does: the owner and administrators can do anything,
+// everyone else gets @everyone's permissions plus their roles', then chanID's overwrites for @everyone, their roles and them, in that order.
+// If chanID isn't one of guildID's channels, the guild-wide permissions are returned.
+// Mod roles add modRolePermissions after overwrites, since they're disgo's and not discord's.
+func effectivePermissions(session discord.Session, guildID, chanID, userID string) (int64, error) {
+	state := session.State()
+	guild, err := state.Guild(guildID)
+	if err != nil {
+		return 0, err
+	}
+	if userID == guild.OwnerID {
+		return allPermissions, nil
+	}
+	member, err := state.Member(guildID, userID)
+	if err != nil {
+		if member, err = session.GuildMember(guildID, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	var permissions int64
+	if everyone, err := state.Role(guildID, guildID); err == nil {
+		permissions = everyone.Permissions
+	}
+	modRoles := getGuildSettings(guildID).modRoles
+	isMod := false
+	for _, roleID := range member.Roles {
+		if role, err := state.Role(guildID, roleID); err == nil {
+			permissions |= role.Permissions
+		}
+		isMod = isMod || containsString(modRoles, roleID)
+	}
+	if permissions&discordgo.PermissionAdministrator != 0 {
+		return allPermissions, nil
+	}
+
+	if channel, err := state.Channel(chanID); err == nil && channel.GuildID == guildID {
+		var roleOverwrite discordgo.PermissionOverwrite
+		var memberOverwrite *discordgo.PermissionOverwrite
+		for _, o := range channel.PermissionOverwrites {
+			switch {
+			case o.Type == discordgo.PermissionOverwriteTypeRole && o.ID == guildID:
+				permissions = overwrite(permissions, o)
+			case o.Type == discordgo.PermissionOverwriteTypeRole && containsString(member.Roles, o.ID):
+				roleOverwrite.Deny |= o.Deny
+				roleOverwrite.Allow |= o.Allow
+			case o.Type == discordgo.PermissionOverwriteTypeMember && o.ID == userID:
+				memberOverwrite = o
+			}
+		}
+		permissions = overwrite(permissions, &roleOverwrite)
+		if memberOverwrite != nil {
+			permissions = overwrite(permissions, memberOverwrite)
+		}
+	}
+
+	if isMod {
+		permissions |= modRolePermissions
+	}
+	return permissions, nil
+}
+
+// hasPermission reports whether userID has any of the permission bits in chanID
+func hasPermission(session discord.Session, guildID, chanID, userID string, permission int64) bool {
+	if len(guildID) == 0 {
+		return false
+	}
+	permissions, err := effectivePermissions(session, guildID, chanID, userID)
+	return err == nil && permissions&permission != 0
+}
+
+// canRunCommand reports whether userID passes cmd's requirement in chanID
+func canRunCommand(session discord.Session, guildID, chanID, userID string, cmd *command) bool {
+	if cmd.ownerOnly {
+		return userID == cfg.AdminID
+	}
+	if cmd.permission == 0 {
+		return true
+	}
+	return hasPermission(session, guildID, chanID, userID, cmd.permission)
+}
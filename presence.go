@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/store"
+)
+
+// presenceActivity is the part of a discordgo.Activity kept in user_presence.activities
+type presenceActivity struct {
+	Type    discordgo.ActivityType `json:"type"`
+	Name    string                 `json:"name"`
+	Details string                 `json:"details,omitempty"`
+	State   string                 `json:"state,omitempty"`
+	URL     string                 `json:"url,omitempty"`
+}
+
+// presenceState is one user_presence row minus its keys and time, compared to skip repeated identical presences
+type presenceState struct {
+	status     string
+	game       string //first "Playing" activity, what playtime and friends read
+	activities string //JSON array of presenceActivity
+}
+
+var (
+	presenceMutex      sync.Mutex
+	lastPresences      = make(map[[2]string]presenceState) //[guildID, userID]
+	presenceGuilds     = make(map[string]bool)             //guilds whose open presences have been reconciled this connection
+	presenceDisconnect time.Time
+)
+
+func newPresenceState(presence *discordgo.Presence) presenceState {
+	state := presenceState{status: string(presence.Status), activities: "[]"}
+	if presence.Status == discordgo.StatusOffline {
+		return state
+	}
+	activities := make([]presenceActivity, 0, len(presence.Activities))
+	for _, activity := range presence.Activities {
+		if activity == nil {
+			continue
+		}
+		if activity.Type == discordgo.ActivityTypeGame && len(state.game) == 0 {
+			state.game = activity.Name
+		}
+		activities = append(activities, presenceActivity{Type: activity.Type, Name: activity.Name, Details: activity.Details, State: activity.State, URL: activity.URL})
+	}
+	if encoded, err := json.Marshal(activities); err == nil {
+		state.activities = string(encoded)
+	}
+	return state
+}
+
+func insertPresence(guildID, userID string, state presenceState, at time.Time) error {
+	return data.Presence.Insert(guildID, userID, store.Presence{Status: state.status, Game: state.game, Activities: state.activities}, at)
+}
+
+// recordPresence inserts a row for the user unless it would repeat their last one, presenceMutex must be held
+func recordPresence(guildID string, presence *discordgo.Presence, at time.Time) {
+	if presence.User == nil {
+		return
+	}
+	key := [2]string{guildID, presence.User.ID}
+	state := newPresenceState(presence)
+	if last, found := lastPresences[key]; found && last == state {
+		return
+	}
+	if err := insertPresence(guildID, presence.User.ID, state, at); err != nil {
+		fmt.Println("ERROR inserting presence " + err.Error())
+		return
+	}
+	lastPresences[key] = state
+}
+
+// closeOpenPresences ends every session in guildID left open in the database, at the last time the bot could have seen it.
+// Without this, time spent disconnected (or crashed) would be counted as played or online.
+func closeOpenPresences(guildID string) error {
+	latest, err := data.Presence.Latest(guildID)
+	if err != nil {
+		return err
+	}
+	var openUserIDs []string
+	var closeAt time.Time
+	for _, presence := range latest {
+		if presence.CreateDate.After(closeAt) {
+			closeAt = presence.CreateDate
+		}
+		if presence.Status != string(discordgo.StatusOffline) {
+			openUserIDs = append(openUserIDs, presence.UserID)
+		}
+	}
+	//if the disconnect was seen it's the real end, otherwise the newest row is the last sign the bot was alive
+	if presenceDisconnect.After(closeAt) {
+		closeAt = presenceDisconnect
+	}
+	closed := presenceState{status: string(discordgo.StatusOffline), activities: "[]"}
+	for _, userID := range openUserIDs {
+		if err := insertPresence(guildID, userID, closed, closeAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handlePresenceUpdate records p like recordPresence, but writes it after releasing presenceMutex so a slow insert doesn't hold up every guild
+func handlePresenceUpdate(s *discordgo.Session, p *discordgo.PresenceUpdate) {
+	if p.User == nil {
+		return
+	}
+	now := time.Now()
+	key := [2]string{p.GuildID, p.User.ID}
+	state := newPresenceState(&p.Presence)
+	presenceMutex.Lock()
+	if !presenceGuilds[p.GuildID] {
+		presenceMutex.Unlock()
+		return //the GuildCreate snapshot hasn't been reconciled yet, it'll include this user
+	}
+	last, found := lastPresences[key]
+	if found && last == state {
+		presenceMutex.Unlock()
+		return
+	}
+	lastPresences[key] = state
+	presenceMutex.Unlock()
+
+	if err := insertPresence(p.GuildID, p.User.ID, state, now); err != nil {
+		fmt.Println("ERROR inserting presence " + err.Error())
+		//put back what's really in the database, unless a newer presence has already replaced this one
+		presenceMutex.Lock()
+		defer presenceMutex.Unlock()
+		if lastPresences[key] == state {
+			if found {
+				lastPresences[key] = last
+			} else {
+				delete(lastPresences, key)
+			}
+		}
+	}
+}
+
+// handlePresenceGuildCreate closes whatever was left open before the connection started, then records the guild's snapshot
+func handlePresenceGuildCreate(s *discordgo.Session, g *discordgo.GuildCreate) {
+	presenceMutex.Lock()
+	defer presenceMutex.Unlock()
+	if presenceGuilds[g.ID] {
+		return
+	}
+	if err := closeOpenPresences(g.ID); err != nil {
+		fmt.Println("ERROR closing presences " + err.Error())
+		return
+	}
+	for key := range lastPresences {
+		if key[0] == g.ID {
+			delete(lastPresences, key)
+		}
+	}
+	now := time.Now()
+	for _, presence := range g.Presences {
+		if presence.Status == discordgo.StatusOffline {
+			continue
+		}
+		recordPresence(g.ID, presence, now)
+	}
+	presenceGuilds[g.ID] = true
+}
+
+// handlePresenceDisconnect notes when the bot stopped seeing presences, a new session's GuildCreates close sessions at that time
+func handlePresenceDisconnect(s *discordgo.Session, d *discordgo.Disconnect) {
+	presenceMutex.Lock()
+	defer presenceMutex.Unlock()
+	presenceDisconnect = time.Now()
+	presenceGuilds = make(map[string]bool)
+}
+
+// handlePresenceResumed picks up where the old session left off, discord replays the events missed in between
+func handlePresenceResumed(s *discordgo.Session, r *discordgo.Resumed) {
+	presenceMutex.Lock()
+	defer presenceMutex.Unlock()
+	for _, guild := range s.State.Guilds {
+		presenceGuilds[guild.ID] = true
+	}
+}
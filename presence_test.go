@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/store"
+)
+
+// failingPresence is a presence store whose inserts fail while fail is set
+type failingPresence struct {
+	store.PresenceStore
+	fail bool
+}
+
+func (f *failingPresence) Insert(guildID, userID string, presence store.Presence, at time.Time) error {
+	if f.fail {
+		return errors.New("database is down")
+	}
+	return f.PresenceStore.Insert(guildID, userID, presence, at)
+}
+
+func TestPresenceUpdate(t *testing.T) {
+	b := newTestBot(t)
+	presences := &failingPresence{PresenceStore: data.Presence}
+	data.Presence = presences
+	presenceMutex.Lock()
+	lastPresences = make(map[[2]string]presenceState)
+	presenceGuilds = map[string]bool{testGuildID: true}
+	presenceMutex.Unlock()
+
+	update := func(status discordgo.Status, game string) {
+		p := &discordgo.PresenceUpdate{GuildID: testGuildID, Presence: discordgo.Presence{User: b.alice, Status: status}}
+		if len(game) > 0 {
+			p.Activities = []*discordgo.Activity{{Type: discordgo.ActivityTypeGame, Name: game}}
+		}
+		handlePresenceUpdate(nil, p)
+	}
+	rows := func() int {
+		n := 0
+		data.Presence.Each(store.PresenceFilter{GuildID: testGuildID}, func(store.PresenceRecord) error { n++; return nil })
+		return n
+	}
+
+	update(discordgo.StatusOnline, "")
+	update(discordgo.StatusOnline, "")
+	if n := rows(); n != 1 {
+		t.Errorf("%d rows after the same presence twice, want 1", n)
+	}
+	presences.fail = true
+	update(discordgo.StatusOnline, "chess")
+	presences.fail = false
+	update(discordgo.StatusOnline, "chess")
+	if n := rows(); n != 2 {
+		t.Errorf("%d rows after a failed insert was retried, want 2", n)
+	}
+	if last, err := data.Presence.Last(store.PresenceFilter{GuildID: testGuildID, UserID: b.alice.ID}); err != nil || last.Game != "chess" {
+		t.Errorf("alice's last presence is %+v (%v), want chess", last, err)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+)
+
+const localTimeLayout = "3:04 PM - Mon, Jan _2"
+
+// loadZone loads an IANA zone name like Europe/Oslo, refusing the server's own "Local"
+func loadZone(name string) (*time.Location, error) {
+	if len(name) == 0 || name == "Local" {
+		return nil, fmt.Errorf("unknown time zone %q", name)
+	}
+	return time.LoadLocation(name)
+}
+
+// profileLocation returns userID's stored zone, or nil if they haven't set one
+func profileLocation(userID string) (*time.Location, error) {
+	name, err := data.Profiles.Timezone(userID)
+	if err != nil || len(name) == 0 {
+		return nil, err
+	}
+	return loadZone(name)
+}
+
+// userLocation is the zone the times userID types are read in, and the times shown to them are written in.
+// It's their /tz zone, or the server's if they haven't set one.
+func userLocation(userID string) *time.Location {
+	loc, err := profileLocation(userID)
+	if err != nil {
+		fmt.Println("ERROR loading time zone of "+userID, err)
+	}
+	if loc == nil {
+		return time.Local
+	}
+	return loc
+}
+
+func tz(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	if len(args) == 0 {
+		loc, err := profileLocation(authorID)
+		if err != nil {
+			return "", err
+		}
+		if loc == nil {
+			return "You haven't set a timezone, times you give me are read as " + time.Now().Format("MST") + ". Set yours with /tz set Europe/Oslo", nil
+		}
+		return fmt.Sprintf("Your timezone is %s, it's %s there", loc, time.Now().In(loc).Format(localTimeLayout)), nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "set":
+		if len(args) != 2 {
+			return "", errors.New("Usage: /tz set [zone like Europe/Oslo or America/Chicago]")
+		}
+		loc, err := loadZone(args[1])
+		if err != nil {
+			return "", fmt.Errorf("I don't know the timezone %s, use a name like Europe/Oslo or America/Chicago", args[1])
+		}
+		if err := data.Profiles.SetTimezone(authorID, loc.String()); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("👍 %s, it's %s there", loc, time.Now().In(loc).Format(localTimeLayout)), nil
+	case "clear":
+		if err := data.Profiles.SetTimezone(authorID, ""); err != nil {
+			return "", err
+		}
+		return "👍", nil
+	}
+	return "", errors.New("Usage: /tz [set|clear (optional)] [zone (optional)]")
+}
+
+// userTime describes the time where userID is, or that they haven't set a zone
+func userTime(session discord.Session, guildID, userID string) (string, error) {
+	username, err := getUsername(session, userID, guildID)
+	if err != nil {
+		return "", err
+	}
+	loc, err := profileLocation(userID)
+	if err != nil {
+		return "", err
+	}
+	if loc == nil {
+		return fmt.Sprintf("%s hasn't set a timezone (/tz set)", username), nil
+	}
+	return fmt.Sprintf("%s — %s (%s)", username, time.Now().In(loc).Format(localTimeLayout), loc), nil
+}
+
+func timeCmd(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	if args.has("username") {
+		return userTime(session, guildID, args.user("username"))
+	}
+
+	guild, err := session.State().Guild(guildID)
+	if err != nil {
+		return "", err
+	}
+	zones, err := data.Profiles.Timezones()
+	if err != nil {
+		return "", err
+	}
+	type memberTime struct {
+		username string
+		loc      *time.Location
+		offset   int
+	}
+	now := time.Now()
+	var times []memberTime
+	for _, presence := range guild.Presences {
+		if presence.User == nil || presence.Status == discordgo.StatusOffline {
+			continue
+		}
+		zone, found := zones[presence.User.ID]
+		if !found {
+			continue
+		}
+		loc, err := loadZone(zone)
+		if err != nil {
+			continue
+		}
+		username, err := getUsername(session, presence.User.ID, guildID)
+		if err != nil || len(username) == 0 {
+			continue
+		}
+		_, offset := now.In(loc).Zone()
+		times = append(times, memberTime{username, loc, offset})
+	}
+	if len(times) == 0 {
+		return "Nobody online here has set a timezone, set yours with /tz set Europe/Oslo", nil
+	}
+	sort.Slice(times, func(i, j int) bool {
+		if times[i].offset != times[j].offset {
+			return times[i].offset < times[j].offset
+		}
+		return strings.ToLower(times[i].username) < strings.ToLower(times[j].username)
+	})
+	lines := make([]string, len(times))
+	for i, t := range times {
+		lines[i] = fmt.Sprintf("%s — %s (%s)", t.username, now.In(t.loc).Format(localTimeLayout), t.loc)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// usersTime runs a time_aliases command, showing the local time of each of userIDs
+func usersTime(userIDs []string) commandFunc {
+	return func(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+		if len(userIDs) == 1 {
+			loc, err := profileLocation(userIDs[0])
+			if err != nil {
+				return "", err
+			}
+			if loc != nil {
+				return time.Now().In(loc).Format(localTimeLayout), nil
+			}
+		}
+		lines := make([]string, len(userIDs))
+		for i, userID := range userIDs {
+			line, err := userTime(session, guildID, userID)
+			if err != nil {
+				return "", err
+			}
+			lines[i] = line
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heydabop/disgo/discord"
+	"github.com/heydabop/disgo/store"
+	"github.com/heydabop/disgo/timeparse"
+)
+
+const (
+	minReminderInterval = time.Hour //recurring reminders can't go off more often than this
+	reminderTimeLayout  = "Mon Jan 2 2006 15:04 MST"
+)
+
+func reminderJobName(id int64) string {
+	return fmt.Sprintf("reminder %d", id)
+}
+
+// parseEvery reads back a recurring reminder's interval
+func parseEvery(every string) (timeparse.Duration, error) {
+	tokens := strings.Fields(every)
+	d, used, err := timeparse.ParseDuration(tokens)
+	if err != nil {
+		return d, err
+	}
+	if used != len(tokens) {
+		return d, fmt.Errorf("unexpected %s in interval %s", tokens[used], every)
+	}
+	return d, nil
+}
+
+// scheduleReminder sends reminder when it's due, or right away if it came due while the bot was down
+func scheduleReminder(session discord.Session, reminder store.Reminder) error {
+	return jobs.Once(reminderJobName(reminder.ID), reminder.Due(), func() { sendReminder(session, reminder) })
+}
+
+// sendReminder delivers reminder, then schedules its next time if it repeats.
+// Times missed while the bot was down are skipped rather than all sent at once.
+func sendReminder(session discord.Session, reminder store.Reminder) {
+	chanID := reminder.ChanID
+	if reminder.DM {
+		channel, err := session.UserChannelCreate(reminder.AuthorID)
+		if err != nil {
+			fmt.Println("ERROR opening DM for reminder", err)
+			return
+		}
+		chanID = channel.ID
+	}
+	if _, err := session.ChannelMessageSend(chanID, fmt.Sprintf("<@%s> %s", reminder.AuthorID, reminder.Content)); err != nil {
+		fmt.Println("ERROR sending reminder", err)
+		return
+	}
+
+	if len(reminder.Every) == 0 {
+		if err := data.Reminders.MarkSent(reminder.ID); err != nil {
+			fmt.Println("ERROR marking reminder sent", err)
+		}
+		return
+	}
+	every, err := parseEvery(reminder.Every)
+	if err != nil {
+		fmt.Println("ERROR reading reminder interval", err)
+		return
+	}
+	//count in the author's zone so "every day at 09:00" stays at 09:00 across daylight saving changes
+	now := time.Now()
+	next := every.After(reminder.SendTime.In(userLocation(reminder.AuthorID)))
+	for !next.After(now) {
+		next = every.After(next)
+	}
+	if err := data.Reminders.Reschedule(reminder.ID, next); err != nil {
+		fmt.Println("ERROR rescheduling reminder", err)
+		return
+	}
+	reminder.SendTime, reminder.SnoozedUntil = next, time.Time{}
+	if err := scheduleReminder(session, reminder); err != nil {
+		fmt.Println("ERROR rescheduling reminder", err)
+	}
+}
+
+// remindEvery adds a recurring reminder from what follows "every" in /remindme, like "day at 09:00 to stretch" or "2 weeks to water the plants"
+func remindEvery(session discord.Session, chanID, authorID string, tokens []string, dm bool) (string, error) {
+	const usage = "Usage: remindme every [duration] at [HH:MM (optional)] to [x]"
+	if len(tokens) > 0 && timeparse.IsUnit(tokens[0]) {
+		tokens = append([]string{"1"}, tokens...) //"every day" is every 1 day
+	}
+	every, used, err := timeparse.ParseDuration(tokens)
+	if err != nil {
+		return "", errors.New(usage)
+	}
+	tokens = tokens[used:]
+	now := time.Now()
+	if every.After(now).Sub(now) < minReminderInterval {
+		return "", fmt.Errorf("Reminders can't repeat more often than every %s", timeSinceStr(minReminderInterval))
+	}
+
+	loc := userLocation(authorID)
+	first := every.After(now)
+	if len(tokens) >= 2 && strings.EqualFold(tokens[0], "at") {
+		if every.Clock != 0 {
+			return "", errors.New("A time of day only works with reminders every whole number of days, like every 2d at 09:00")
+		}
+		hour, minute, ok := timeparse.ParseClock(tokens[1])
+		if !ok {
+			return "", errors.New("Times of day look like 09:00 or 5pm")
+		}
+		local := now.In(loc)
+		first = time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+		if !first.After(now) {
+			first = first.AddDate(0, 0, 1)
+		}
+		tokens = tokens[2:]
+	}
+	if len(tokens) > 0 && strings.EqualFold(tokens[0], "to") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return "", errors.New(usage)
+	}
+
+	reminder := store.Reminder{ChanID: chanID, AuthorID: authorID, SendTime: first, Content: strings.Join(tokens, " "), Every: every.String(), DM: dm}
+	if reminder.ID, err = data.Reminders.Add(reminder); err != nil {
+		return "", err
+	}
+	if err := scheduleReminder(session, reminder); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("👍 every %s starting %s (#%d)", reminder.Every, first.In(loc).Format(reminderTimeLayout), reminder.ID), nil
+}
+
+func remindme(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	dm := len(args) > 0 && strings.EqualFold(args[0], "dm")
+	if dm {
+		args = args[1:]
+	}
+	if len(args) > 0 && strings.EqualFold(args[0], "every") {
+		return remindEvery(session, chanID, authorID, args[1:], dm)
+	}
+
+	now := time.Now()
+	loc := userLocation(authorID)
+	remindTime, used, err := timeparse.Parse(args, now, loc)
+	if err != nil {
+		return "What?", nil
+	}
+	content := args[used:]
+	if len(content) > 0 && strings.EqualFold(content[0], "to") {
+		content = content[1:]
+	}
+	if len(content) == 0 {
+		return "", errors.New("Remind you to do what?")
+	}
+	if remindTime.Before(now) {
+		responses := []string{"Sorry, I lost my Delorean.", "Hold on, gotta hit 88MPH first.", "Too late.", "I'm sorry Dave, I can't do that.", ":|", "Time is a one-way street."}
+		return responses[rand.Intn(len(responses))], nil
+	}
+
+	reminder := store.Reminder{ChanID: chanID, AuthorID: authorID, SendTime: remindTime, Content: strings.Join(content, " "), DM: dm}
+	if reminder.ID, err = data.Reminders.Add(reminder); err != nil {
+		return "", err
+	}
+	if err := scheduleReminder(session, reminder); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("👍 %s (#%d)", remindTime.In(loc).Format(reminderTimeLayout), reminder.ID), nil
+}
+
+func describeReminder(reminder store.Reminder, now time.Time, loc *time.Location) string {
+	line := fmt.Sprintf("`#%d` %s (in %s)", reminder.ID, reminder.Due().In(loc).Format(reminderTimeLayout), timeSinceStr(reminder.Due().Sub(now)))
+	if len(reminder.Every) > 0 {
+		line += ", every " + reminder.Every
+	}
+	if reminder.SnoozedUntil.After(reminder.SendTime) {
+		line += ", snoozed"
+	}
+	if reminder.DM {
+		line += ", by DM"
+	}
+	return line + ": " + reminder.Content
+}
+
+// findReminder returns authorID's pending reminder with the ID in arg, which may start with #
+func findReminder(authorID, arg string) (store.Reminder, error) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(arg, "#"), 10, 64)
+	if err != nil {
+		return store.Reminder{}, fmt.Errorf("%s isn't a reminder ID", arg)
+	}
+	reminders, err := data.Reminders.PendingFor(authorID)
+	if err != nil {
+		return store.Reminder{}, err
+	}
+	for _, reminder := range reminders {
+		if reminder.ID == id {
+			return reminder, nil
+		}
+	}
+	return store.Reminder{}, fmt.Errorf("You don't have a reminder #%d", id)
+}
+
+func reminders(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	if len(args) == 0 {
+		pending, err := data.Reminders.PendingFor(authorID)
+		if err != nil {
+			return "", err
+		}
+		if len(pending) == 0 {
+			return "You don't have any reminders", nil
+		}
+		now, loc := time.Now(), userLocation(authorID)
+		lines := []string{"**Your reminders**"}
+		for _, reminder := range pending {
+			lines = append(lines, describeReminder(reminder, now, loc))
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "cancel":
+		if len(args) != 2 {
+			return "", errors.New("Usage: reminders cancel [id]")
+		}
+		reminder, err := findReminder(authorID, args[1])
+		if err != nil {
+			return "", err
+		}
+		if err := data.Reminders.Cancel(reminder.ID); err != nil {
+			return "", err
+		}
+		jobs.Remove(reminderJobName(reminder.ID))
+		return fmt.Sprintf("Cancelled #%d", reminder.ID), nil
+	case "snooze":
+		if len(args) < 3 {
+			return "", errors.New("Usage: reminders snooze [id] [duration]")
+		}
+		reminder, err := findReminder(authorID, args[1])
+		if err != nil {
+			return "", err
+		}
+		d, used, err := timeparse.ParseDuration(args[2:])
+		if err != nil || used != len(args)-2 {
+			return "", errors.New("Snooze for a duration like 10m or 2 hours")
+		}
+		//an overdue reminder is put off from now, not from when it was missed
+		from := reminder.Due()
+		if now := time.Now(); now.After(from) {
+			from = now
+		}
+		reminder.SnoozedUntil = d.After(from)
+		if err := data.Reminders.Snooze(reminder.ID, reminder.SnoozedUntil); err != nil {
+			return "", err
+		}
+		if err := scheduleReminder(session, reminder); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("👍 #%d snoozed until %s", reminder.ID, reminder.SnoozedUntil.In(userLocation(authorID)).Format(reminderTimeLayout)), nil
+	}
+	return "", fmt.Errorf("Unknown option %s. Usage: reminders [cancel|snooze (optional)] [id (optional)] [duration (optional)]", args[0])
+}
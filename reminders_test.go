@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/heydabop/disgo/store"
+)
+
+func TestRemindEveryAtNeedsWholeDays(t *testing.T) {
+	b := newTestBot(t)
+	for _, content := range []string{"/remindme every 2h at 09:00 to stretch", "/remindme every 1d 12h at 09:00 to stretch"} {
+		expectReplies(t, content, b.say(b.alice, content), "⚠ `A time of day only works with reminders every whole number of days, like every 2d at 09:00`")
+	}
+	if pending, err := data.Reminders.PendingFor(b.alice.ID); err != nil || len(pending) != 0 {
+		t.Errorf("alice has reminders %+v (%v), want none", pending, err)
+	}
+}
+
+func TestSnoozeOverdueReminder(t *testing.T) {
+	b := newTestBot(t)
+	id, err := data.Reminders.Add(store.Reminder{ChanID: testChanID, AuthorID: b.alice.ID, SendTime: time.Now().Add(-2 * time.Hour), Content: "stretch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	content := fmt.Sprintf("/reminders snooze %d 10m", id)
+	if replies := b.say(b.alice, content); len(replies) != 1 || !strings.HasPrefix(replies[0], fmt.Sprintf("👍 #%d snoozed until", id)) {
+		t.Fatalf("%q got replies %q, want it snoozed", content, replies)
+	}
+	pending, err := data.Reminders.PendingFor(b.alice.ID)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("alice has reminders %+v (%v), want the snoozed one", pending, err)
+	}
+	if due := pending[0].Due(); due.Before(before.Add(10*time.Minute)) || due.After(time.Now().Add(10*time.Minute)) {
+		t.Errorf("overdue reminder snoozed until %v, want 10 minutes from now", due)
+	}
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	friday := time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"0 0 * * *", friday, time.Date(2023, time.March, 11, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * *", friday, time.Date(2023, time.March, 11, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * *", friday.Add(-30 * time.Second), friday},
+		{"*/15 * * * *", friday.Add(7 * time.Minute), friday.Add(15 * time.Minute)},
+		{"5/20 * * * *", friday.Add(30 * time.Minute), friday.Add(45 * time.Minute)},
+		{"30 9 * * 1-5", friday, time.Date(2023, time.March, 13, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", friday, time.Date(2023, time.March, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1 * 0", friday, time.Date(2023, time.March, 12, 12, 0, 0, 0, time.UTC)},
+		{"0 12 1 * 0", time.Date(2023, time.March, 26, 12, 0, 0, 0, time.UTC), time.Date(2023, time.April, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 1 *", friday, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", friday, time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 4 *", friday, time.Time{}},
+	}
+	for _, test := range tests {
+		schedule, err := Cron(test.spec)
+		if err != nil {
+			t.Errorf("Cron(%q) failed: %v", test.spec, err)
+			continue
+		}
+		if got := schedule.Next(test.from); !got.Equal(test.want) {
+			t.Errorf("Cron(%q).Next(%v) = %v, want %v", test.spec, test.from, got, test.want)
+		}
+	}
+}
+
+func TestCronRejects(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := Cron(spec); err == nil {
+			t.Errorf("Cron(%q) parsed, want an error", spec)
+		}
+	}
+}
+
+func TestEveryNext(t *testing.T) {
+	from := time.Date(2023, time.March, 10, 23, 0, 0, 0, time.UTC)
+	tests := []struct {
+		every time.Duration
+		want  time.Time
+	}{
+		{time.Minute, from.Add(time.Minute)},
+		{90 * time.Minute, time.Date(2023, time.March, 11, 0, 30, 0, 0, time.UTC)},
+		{24 * time.Hour, time.Date(2023, time.March, 11, 23, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		if got := Every(test.every).Next(from); !got.Equal(test.want) {
+			t.Errorf("Every(%v).Next(%v) = %v, want %v", test.every, from, got, test.want)
+		}
+	}
+}
+
+func TestBetweenNext(t *testing.T) {
+	from := time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		min, max       time.Duration
+		earliest, last time.Time //the range Next has to land in, inclusive
+	}{
+		{time.Hour, 2 * time.Hour, from.Add(time.Hour), from.Add(2*time.Hour - 1)},
+		{0, time.Minute, from, from.Add(time.Minute - 1)},
+		{time.Hour, time.Hour, from.Add(time.Hour), from.Add(time.Hour)},
+		{time.Hour, time.Minute, from.Add(time.Hour), from.Add(time.Hour)}, //max below min waits min
+	}
+	for _, test := range tests {
+		schedule := Between(test.min, test.max)
+		for i := 0; i < 100; i++ {
+			if got := schedule.Next(from); got.Before(test.earliest) || got.After(test.last) {
+				t.Errorf("Between(%v, %v).Next(%v) = %v, want %v to %v", test.min, test.max, from, got, test.earliest, test.last)
+				break
+			}
+		}
+	}
+}
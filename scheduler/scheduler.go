@@ -0,0 +1,239 @@
+// Package scheduler runs disgo's timed jobs from one goroutine: recurring jobs on a Schedule, and one-off jobs at a set time.
+// Recurring jobs' last runs are persisted so a run missed while the bot was down can be caught up when it's added back.
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LastRuns persists when each recurring job last ran
+type LastRuns interface {
+	// LastRun returns when the job called name last ran, or the zero time if it never has
+	LastRun(name string) (time.Time, error)
+	SetLastRun(name string, at time.Time) error
+}
+
+// Job is a recurring job
+type Job struct {
+	Name     string
+	Schedule Schedule
+	CatchUp  bool //run right away when added if a scheduled run was missed since the last one
+	Run      func()
+}
+
+// Status is what Jobs reports about each job
+type Status struct {
+	Name    string
+	Next    time.Time //zero if it won't run again
+	LastRun time.Time //zero if it hasn't run
+	Running bool
+	Once    bool
+}
+
+type entry struct {
+	name     string
+	schedule Schedule //nil for one-off jobs
+	run      func()
+	next     time.Time
+	lastRun  time.Time
+	running  bool
+}
+
+type Scheduler struct {
+	mutex    sync.Mutex
+	entries  map[string]*entry
+	lastRuns LastRuns
+	wake     chan struct{}
+	stop     chan struct{}
+	stopped  bool
+	running  sync.WaitGroup
+	now      func() time.Time //time.Now, except in tests
+}
+
+var ErrStopped = errors.New("scheduler is stopped")
+
+// New starts a scheduler that persists recurring jobs' runs to lastRuns
+func New(lastRuns LastRuns) *Scheduler {
+	return start(lastRuns, time.Now)
+}
+
+// start is New reading the time from now
+func start(lastRuns LastRuns, now func() time.Time) *Scheduler {
+	s := &Scheduler{
+		entries:  make(map[string]*entry),
+		lastRuns: lastRuns,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		now:      now,
+	}
+	go s.loop()
+	return s
+}
+
+// Add schedules job, replacing any job with the same name.
+// A replaced job that's running is left to finish, but isn't scheduled again.
+func (s *Scheduler) Add(job Job) error {
+	if job.Schedule == nil || job.Run == nil {
+		return fmt.Errorf("job %s needs a schedule and something to run", job.Name)
+	}
+	lastRun, err := s.lastRuns.LastRun(job.Name)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	next := job.Schedule.Next(now)
+	if !lastRun.IsZero() {
+		if missed := job.Schedule.Next(lastRun); job.CatchUp && !missed.IsZero() && missed.Before(now) {
+			next = now
+		}
+	}
+	return s.put(&entry{name: job.Name, schedule: job.Schedule, run: job.Run, next: next, lastRun: lastRun})
+}
+
+// Once runs run at at, or right away if at has passed, replacing any job with the same name
+func (s *Scheduler) Once(name string, at time.Time, run func()) error {
+	return s.put(&entry{name: name, run: run, next: at})
+}
+
+func (s *Scheduler) put(e *entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stopped {
+		return ErrStopped
+	}
+	s.entries[e.name] = e
+	s.poke()
+	return nil
+}
+
+// Remove unschedules the job called name, letting it finish if it's running
+func (s *Scheduler) Remove(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.entries, name)
+	s.poke()
+}
+
+// Jobs returns every scheduled job, soonest first
+func (s *Scheduler) Jobs() []Status {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	jobs := make([]Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		jobs = append(jobs, Status{Name: e.name, Next: e.next, LastRun: e.lastRun, Running: e.running, Once: e.schedule == nil})
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Next.IsZero() != jobs[j].Next.IsZero() {
+			return jobs[j].Next.IsZero()
+		}
+		if !jobs[i].Next.Equal(jobs[j].Next) {
+			return jobs[i].Next.Before(jobs[j].Next)
+		}
+		return jobs[i].Name < jobs[j].Name
+	})
+	return jobs
+}
+
+// Stop stops starting jobs and waits for the ones that are running to finish
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	s.mutex.Unlock()
+	s.running.Wait()
+}
+
+// poke wakes the loop to look at the schedule again, callers hold the mutex
+func (s *Scheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		wait := s.startDue(s.now())
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// startDue starts every job due by now and returns how long until the next one is
+func (s *Scheduler) startDue(now time.Time) time.Duration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.stopped {
+		return time.Hour
+	}
+	wait := time.Hour
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			continue
+		}
+		if e.next.After(now) {
+			if until := e.next.Sub(now); until < wait {
+				wait = until
+			}
+			continue
+		}
+		//a running job has no next run until it finishes, so it can't overlap itself
+		e.running = true
+		e.next = time.Time{}
+		s.running.Add(1)
+		go s.run(e, now)
+	}
+	return wait
+}
+
+// run runs e, recovering if it panics, then persists the run and schedules the next one
+func (s *Scheduler) run(e *entry, started time.Time) {
+	defer s.running.Done()
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("ERROR job %s panicked: %v\n%s", e.name, r, debug.Stack())
+			}
+		}()
+		e.run()
+	}()
+
+	if e.schedule != nil {
+		if err := s.lastRuns.SetLastRun(e.name, started); err != nil {
+			fmt.Println("ERROR saving last run of "+e.name, err)
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e.running = false
+	e.lastRun = started
+	if s.entries[e.name] != e {
+		return //removed or replaced while it ran
+	}
+	if e.schedule == nil {
+		delete(s.entries, e.name)
+		return
+	}
+	e.next = e.schedule.Next(s.now())
+	s.poke()
+}
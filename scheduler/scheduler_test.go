@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when it's told to
+type fakeClock struct {
+	mutex sync.Mutex
+	t     time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.t
+}
+
+// fakeLastRuns keeps last runs in memory
+type fakeLastRuns struct {
+	mutex sync.Mutex
+	runs  map[string]time.Time
+}
+
+func (f *fakeLastRuns) LastRun(name string) (time.Time, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.runs[name], nil
+}
+
+func (f *fakeLastRuns) SetLastRun(name string, at time.Time) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.runs[name] = at
+	return nil
+}
+
+var testStart = time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+// newTestScheduler starts a scheduler on a fake clock at testStart, lastRuns holding runs
+func newTestScheduler(t *testing.T, runs map[string]time.Time) (*Scheduler, *fakeClock, *fakeLastRuns) {
+	if runs == nil {
+		runs = make(map[string]time.Time)
+	}
+	clock := &fakeClock{t: testStart}
+	lastRuns := &fakeLastRuns{runs: runs}
+	s := start(lastRuns, clock.Now)
+	t.Cleanup(s.Stop)
+	return s, clock, lastRuns
+}
+
+// advance moves the clock on by d and wakes the scheduler to notice
+func advance(s *Scheduler, clock *fakeClock, d time.Duration) {
+	clock.mutex.Lock()
+	clock.t = clock.t.Add(d)
+	clock.mutex.Unlock()
+	s.mutex.Lock()
+	s.poke()
+	s.mutex.Unlock()
+}
+
+// signal returns a job that closes its channel when it runs
+func signal() (chan struct{}, func()) {
+	ran := make(chan struct{})
+	var once sync.Once
+	return ran, func() { once.Do(func() { close(ran) }) }
+}
+
+func waitFor(t *testing.T, ran chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s never ran", what)
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		lastRun time.Time
+		catchUp bool
+		runs    bool
+		next    time.Time //when it's scheduled if it doesn't run right away
+	}{
+		{"missed", testStart.Add(-72 * time.Hour), true, true, time.Time{}},
+		{"missed without catching up", testStart.Add(-72 * time.Hour), false, false, testStart.Add(24 * time.Hour)},
+		{"not missed", testStart.Add(-time.Hour), true, false, testStart.Add(24 * time.Hour)},
+		{"never ran", time.Time{}, true, false, testStart.Add(24 * time.Hour)},
+	}
+	for _, test := range tests {
+		runs := make(map[string]time.Time)
+		if !test.lastRun.IsZero() {
+			runs["daily"] = test.lastRun
+		}
+		s, _, lastRuns := newTestScheduler(t, runs)
+		ran, run := signal()
+		if err := s.Add(Job{Name: "daily", Schedule: Every(24 * time.Hour), CatchUp: test.catchUp, Run: run}); err != nil {
+			t.Fatal(err)
+		}
+		if test.runs {
+			waitFor(t, ran, test.name)
+			s.Stop()
+			if last, _ := lastRuns.LastRun("daily"); !last.Equal(testStart) {
+				t.Errorf("%s: last run saved as %v, want %v", test.name, last, testStart)
+			}
+			if jobs := s.Jobs(); len(jobs) != 1 || !jobs[0].Next.Equal(testStart.Add(24*time.Hour)) {
+				t.Errorf("%s: jobs after catching up are %+v, want the next run a day later", test.name, jobs)
+			}
+			continue
+		}
+		if jobs := s.Jobs(); len(jobs) != 1 || !jobs[0].Next.Equal(test.next) {
+			t.Errorf("%s: jobs are %+v, want the next run at %v", test.name, jobs, test.next)
+		}
+	}
+}
+
+func TestOnceRemovesItself(t *testing.T) {
+	s, clock, _ := newTestScheduler(t, nil)
+	ran, run := signal()
+	if err := s.Once("later", testStart.Add(time.Hour), run); err != nil {
+		t.Fatal(err)
+	}
+	if jobs := s.Jobs(); len(jobs) != 1 || !jobs[0].Once || !jobs[0].Next.Equal(testStart.Add(time.Hour)) {
+		t.Fatalf("jobs are %+v, want the one-off an hour out", jobs)
+	}
+	advance(s, clock, time.Hour)
+	waitFor(t, ran, "the one-off job")
+	s.Stop()
+	if jobs := s.Jobs(); len(jobs) != 0 {
+		t.Errorf("jobs after the one-off ran are %+v, want none", jobs)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	s, clock, _ := newTestScheduler(t, nil)
+	removed := false
+	s.Once("removed", testStart.Add(time.Hour), func() { removed = true })
+	ran, run := signal()
+	s.Once("kept", testStart.Add(time.Hour), run)
+	s.Remove("removed")
+	advance(s, clock, time.Hour)
+	waitFor(t, ran, "the job that was kept")
+	s.Stop()
+	if removed {
+		t.Error("a removed job ran")
+	}
+}
+
+func TestStop(t *testing.T) {
+	s, _, _ := newTestScheduler(t, nil)
+	started, start := signal()
+	release := make(chan struct{})
+	finished := false
+	s.Once("slow", testStart, func() {
+		start()
+		<-release
+		finished = true
+	})
+	waitFor(t, started, "the slow job")
+	stopped := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while a job was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+	if !finished {
+		t.Error("Stop returned before the running job finished")
+	}
+	if err := s.Once("late", testStart, func() {}); !errors.Is(err, ErrStopped) {
+		t.Errorf("Once after Stop returned %v, want ErrStopped", err)
+	}
+	if err := s.Add(Job{Name: "late", Schedule: Every(time.Hour), Run: func() {}}); !errors.Is(err, ErrStopped) {
+		t.Errorf("Add after Stop returned %v, want ErrStopped", err)
+	}
+}
+
+func TestPanickingJob(t *testing.T) {
+	s, clock, lastRuns := newTestScheduler(t, map[string]time.Time{"boom": testStart.Add(-2 * time.Hour)})
+	panicked, panicking := signal()
+	if err := s.Add(Job{Name: "boom", Schedule: Every(time.Hour), CatchUp: true, Run: func() {
+		panicking()
+		panic("job failed")
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, panicked, "the panicking job")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if jobs := s.Jobs(); len(jobs) == 1 && !jobs[0].Running {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the panicking job never finished")
+		}
+	}
+
+	ran, run := signal()
+	s.Once("after", testStart.Add(time.Minute), run)
+	advance(s, clock, time.Minute)
+	waitFor(t, ran, "a job after the panic")
+	s.Stop()
+	if last, _ := lastRuns.LastRun("boom"); !last.Equal(testStart) {
+		t.Errorf("panicking job's last run saved as %v, want %v", last, testStart)
+	}
+	if jobs := s.Jobs(); len(jobs) != 1 || jobs[0].Name != "boom" || !jobs[0].Next.Equal(testStart.Add(time.Hour)) {
+		t.Errorf("jobs after the panic are %+v, want boom scheduled again", jobs)
+	}
+}
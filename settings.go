@@ -0,0 +1,312 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+	"github.com/heydabop/disgo/store"
+)
+
+const (
+	defaultPrefix   = "/"
+	maxPrefixLength = 10
+)
+
+// guildSettings is a guild's row in guild_settings, or the defaults if it has none
+type guildSettings struct {
+	prefix             string
+	disabledCommands   []string
+	disabledCategories []string
+	reactionsDisabled  bool     //no 🤖 reactions to messages mentioning bots
+	modRoles           []string //role IDs granted modRolePermissions
+	muteBackend        string   //muteByDeleting, muteByTimeout or muteByRole
+	muteRoleID         string   //role given by muteByRole
+	timeoutChanID      string   //voice channel /timeout moves people to
+}
+
+var (
+	guildSettingsCache = make(map[string]guildSettings)
+	guildSettingsMutex sync.RWMutex
+)
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	kept := make([]string, 0, len(list))
+	for _, item := range list {
+		if item != s {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// disables reports whether cmd, or its whole category, is turned off by these settings
+func (g guildSettings) disables(cmd *command) bool {
+	return containsString(g.disabledCommands, cmd.name) || containsString(g.disabledCategories, string(cmd.category))
+}
+
+// getGuildSettings returns guildID's settings, loading them from the database the first time they're needed
+func getGuildSettings(guildID string) guildSettings {
+	defaults := guildSettings{prefix: defaultPrefix, muteBackend: muteByDeleting}
+	if len(guildID) == 0 {
+		return defaults
+	}
+	guildSettingsMutex.RLock()
+	settings, found := guildSettingsCache[guildID]
+	guildSettingsMutex.RUnlock()
+	if found {
+		return settings
+	}
+
+	stored, err := data.Settings.Get(guildID)
+	if err != nil {
+		if err != store.ErrNotFound {
+			fmt.Println("ERROR loading guild settings " + err.Error())
+			return defaults
+		}
+		settings = defaults
+	} else {
+		settings = guildSettings{prefix: stored.Prefix, disabledCommands: stored.DisabledCommands, disabledCategories: stored.DisabledCategories, reactionsDisabled: stored.ReactionsDisabled, modRoles: stored.ModRoles,
+			muteBackend: stored.MuteBackend, muteRoleID: stored.MuteRoleID, timeoutChanID: stored.TimeoutChanID}
+		if len(settings.muteBackend) == 0 {
+			settings.muteBackend = muteByDeleting
+		}
+	}
+	guildSettingsMutex.Lock()
+	guildSettingsCache[guildID] = settings
+	guildSettingsMutex.Unlock()
+	return settings
+}
+
+func saveGuildSettings(guildID string, settings guildSettings) error {
+	if err := data.Settings.Save(guildID, store.GuildSettings{
+		Prefix:             settings.prefix,
+		DisabledCommands:   settings.disabledCommands,
+		DisabledCategories: settings.disabledCategories,
+		ReactionsDisabled:  settings.reactionsDisabled,
+		ModRoles:           settings.modRoles,
+		MuteBackend:        settings.muteBackend,
+		MuteRoleID:         settings.muteRoleID,
+		TimeoutChanID:      settings.timeoutChanID,
+	}); err != nil {
+		return err
+	}
+	guildSettingsMutex.Lock()
+	guildSettingsCache[guildID] = settings
+	guildSettingsMutex.Unlock()
+	return nil
+}
+
+func describeGuildSettings(session discord.Session, guildID string, settings guildSettings) string {
+	disabledCommands, disabledCategories, reactions, modRoles := "none", "none", "on", "none"
+	if len(settings.disabledCommands) > 0 {
+		disabledCommands = strings.Join(settings.disabledCommands, ", ")
+	}
+	if len(settings.disabledCategories) > 0 {
+		disabledCategories = strings.Join(settings.disabledCategories, ", ")
+	}
+	if settings.reactionsDisabled {
+		reactions = "off"
+	}
+	if len(settings.modRoles) > 0 {
+		names := make([]string, len(settings.modRoles))
+		for i, roleID := range settings.modRoles {
+			names[i] = roleID
+			if role, err := session.State().Role(guildID, roleID); err == nil {
+				names[i] = role.Name
+			}
+		}
+		modRoles = strings.Join(names, ", ")
+	}
+	mute := "delete messages"
+	switch settings.muteBackend {
+	case muteByTimeout:
+		mute = "Discord timeout"
+	case muteByRole:
+		mute = "role " + settings.muteRoleID
+		if role, err := session.State().Role(guildID, settings.muteRoleID); err == nil {
+			mute = "role " + role.Name
+		}
+	}
+	timeoutChannel := "none"
+	if len(settings.timeoutChanID) > 0 {
+		timeoutChannel = settings.timeoutChanID
+		if channel, err := session.State().Channel(settings.timeoutChanID); err == nil {
+			timeoutChannel = channel.Name
+		}
+	}
+	return fmt.Sprintf("Prefix: `%s`\nDisabled commands: %s\nDisabled categories: %s\nReactions: %s\nMod roles: %s\nMute: %s\nTimeout channel: %s",
+		settings.prefix, disabledCommands, disabledCategories, reactions, modRoles, mute, timeoutChannel)
+}
+
+// findRole resolves a role mention or name (ignoring case) to one of guildID's role IDs
+func findRole(session discord.Session, guildID, role string) (string, error) {
+	if match := roleIDRegex.FindStringSubmatch(role); match != nil {
+		role = match[1]
+	}
+	guild, err := session.State().Guild(guildID)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range guild.Roles {
+		if r.ID == role || strings.EqualFold(r.Name, role) {
+			return r.ID, nil
+		}
+	}
+	return "", fmt.Errorf("No role named %s", role)
+}
+
+// findVoiceChannel resolves a channel mention or name (ignoring case) to one of guildID's voice channels
+func findVoiceChannel(session discord.Session, guildID, channel string) (string, error) {
+	if match := channelIDRegex.FindStringSubmatch(channel); match != nil {
+		channel = match[1]
+	}
+	guild, err := session.State().Guild(guildID)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range guild.Channels {
+		if c.Type == discordgo.ChannelTypeGuildVoice && (c.ID == channel || strings.EqualFold(c.Name, channel)) {
+			return c.ID, nil
+		}
+	}
+	return "", fmt.Errorf("No voice channel named %s", channel)
+}
+
+// setEnabled turns each named command or category on or off in settings
+func setEnabled(settings *guildSettings, names []string, enabled bool) error {
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if _, isCategory := categoryTitles[commandCategory(name)]; isCategory {
+			settings.disabledCategories = removeString(settings.disabledCategories, name)
+			if !enabled {
+				settings.disabledCategories = append(settings.disabledCategories, name)
+			}
+			continue
+		}
+		cmd, found := lookupCommand(name)
+		if !found {
+			return fmt.Errorf("No command or category named %s", name)
+		}
+		if !enabled && (cmd.name == "config" || cmd.name == "help") {
+			return fmt.Errorf("%s can't be disabled", cmd.name)
+		}
+		settings.disabledCommands = removeString(settings.disabledCommands, cmd.name)
+		if !enabled {
+			settings.disabledCommands = append(settings.disabledCommands, cmd.name)
+		}
+	}
+	return nil
+}
+
+func config(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	if len(guildID) == 0 {
+		return "", errors.New("Settings are per server, run this in one")
+	}
+	settings := getGuildSettings(guildID)
+	if len(args) == 0 {
+		return describeGuildSettings(session, guildID, settings), nil
+	}
+	if !hasPermission(session, guildID, chanID, authorID, discordgo.PermissionAdministrator) {
+		return unauthorizedReply, nil
+	}
+
+	//copy the lists so a failed update can't leave the cached settings half changed
+	settings.disabledCommands = append([]string(nil), settings.disabledCommands...)
+	settings.disabledCategories = append([]string(nil), settings.disabledCategories...)
+	settings.modRoles = append([]string(nil), settings.modRoles...)
+	switch strings.ToLower(args[0]) {
+	case "prefix":
+		if len(args) != 2 {
+			return "", errors.New("Usage: config prefix [prefix]")
+		}
+		if len(args[1]) > maxPrefixLength {
+			return "", fmt.Errorf("Prefix can't be longer than %d characters", maxPrefixLength)
+		}
+		settings.prefix = args[1]
+	case "disable", "enable":
+		if len(args) < 2 {
+			return "", fmt.Errorf("Usage: config %s [command or category...]", strings.ToLower(args[0]))
+		}
+		if err := setEnabled(&settings, args[1:], strings.EqualFold(args[0], "enable")); err != nil {
+			return "", err
+		}
+	case "reactions":
+		if len(args) != 2 || (!strings.EqualFold(args[1], "on") && !strings.EqualFold(args[1], "off")) {
+			return "", errors.New("Usage: config reactions [on|off]")
+		}
+		settings.reactionsDisabled = strings.EqualFold(args[1], "off")
+	case "modrole":
+		if len(args) < 3 || (!strings.EqualFold(args[1], "add") && !strings.EqualFold(args[1], "remove")) {
+			return "", errors.New("Usage: config modrole [add|remove] [role]")
+		}
+		roleID, err := findRole(session, guildID, strings.Join(args[2:], " "))
+		if err != nil {
+			return "", err
+		}
+		settings.modRoles = removeString(settings.modRoles, roleID)
+		if strings.EqualFold(args[1], "add") {
+			settings.modRoles = append(settings.modRoles, roleID)
+		}
+	case "mute":
+		if len(args) < 2 {
+			return "", errors.New("Usage: config mute [delete|timeout|role] [role (role only)]")
+		}
+		switch backend := strings.ToLower(args[1]); backend {
+		case muteByDeleting, muteByTimeout:
+			if len(args) != 2 {
+				return "", errors.New("Usage: config mute [delete|timeout|role] [role (role only)]")
+			}
+			settings.muteBackend, settings.muteRoleID = backend, ""
+		case muteByRole:
+			if len(args) < 3 {
+				return "", errors.New("Usage: config mute role [role]")
+			}
+			roleID, err := findRole(session, guildID, strings.Join(args[2:], " "))
+			if err != nil {
+				return "", err
+			}
+			settings.muteBackend, settings.muteRoleID = backend, roleID
+		default:
+			return "", fmt.Errorf("Unknown mute backend %s", args[1])
+		}
+	case "timeout":
+		if len(args) < 2 {
+			return "", errors.New("Usage: config timeout [voice channel|create|off]")
+		}
+		switch channel := strings.Join(args[1:], " "); strings.ToLower(channel) {
+		case "off":
+			settings.timeoutChanID = ""
+		case "create":
+			created, err := session.GuildChannelCreate(guildID, "timeout", discordgo.ChannelTypeGuildVoice)
+			if err != nil {
+				return "", err
+			}
+			settings.timeoutChanID = created.ID
+		default:
+			chanID, err := findVoiceChannel(session, guildID, channel)
+			if err != nil {
+				return "", err
+			}
+			settings.timeoutChanID = chanID
+		}
+	default:
+		return "", fmt.Errorf("Unknown setting %s", args[0])
+	}
+	if err := saveGuildSettings(guildID, settings); err != nil {
+		return "", err
+	}
+	return describeGuildSettings(session, guildID, settings), nil
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/discord"
+	"github.com/heydabop/disgo/markov"
+	"github.com/heydabop/disgo/store"
+)
+
+const (
+	maxMarkovOrder = 5 //past this, chains mostly repeat whole messages back
+	markovFileExt  = ".markov"
+)
+
+var markovNameRegex = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+// markovOrderArg is the --order flag of the spam commands, how many words each next word depends on
+func markovOrderArg(def int) argSpec {
+	return argSpec{name: "order", kind: argInt, flag: "--order", optional: true, min: 1, max: maxMarkovOrder, def: def}
+}
+
+var (
+	markovSeedArg     = argSpec{name: "words", kind: argText, flag: "about", optional: true}
+	markovMinWordsArg = argSpec{name: "min", kind: argInt, flag: "--min", optional: true, min: 0, max: 100}
+	markovMaxWordsArg = argSpec{name: "max", kind: argInt, flag: "--max", optional: true, min: 0, max: 100}
+)
+
+// spamArgs are the args every spam command takes after its own, with the order defaulting to def
+func spamArgs(def int) []argSpec {
+	return []argSpec{markovOrderArg(def), markovSeedArg, markovMinWordsArg, markovMaxWordsArg}
+}
+
+// chains caches the models spamuser and spamdiscord generate from, set up in main once the config is loaded
+var chains *markov.Cache
+
+func guildChainKey(guildID string, order int) string {
+	return fmt.Sprintf("guild %s %d", guildID, order)
+}
+
+func userChainKey(guildID, userID string, order int) string {
+	return fmt.Sprintf("user %s %s %d", guildID, userID, order)
+}
+
+// learnMessage adds m to the cached models it belongs in, the same messages buildChain would read for them
+func learnMessage(session discord.Session, m *discordgo.MessageCreate) {
+	channel, err := session.State().Channel(m.ChannelID)
+	if err != nil || len(channel.GuildID) == 0 {
+		return
+	}
+	for order := 1; order <= maxMarkovOrder; order++ {
+		chains.Learn(userChainKey(channel.GuildID, m.Author.ID, order), m.Content)
+		if m.Author.ID != ownUserID {
+			chains.Learn(guildChainKey(channel.GuildID, order), m.Content)
+		}
+	}
+}
+
+func guildChannelIDs(session discord.Session, guildID string) ([]string, error) {
+	guild, err := session.State().Guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+	chanIDs := make([]string, 0, len(guild.Channels))
+	for _, channel := range guild.Channels {
+		chanIDs = append(chanIDs, channel.ID)
+	}
+	return chanIDs, nil
+}
+
+// buildChain returns a builder for chains.Get that reads the content of every message filter matches into a model of order
+func buildChain(order int, filter store.MessageFilter) func() (*markov.Model, error) {
+	return func() (*markov.Model, error) {
+		model := markov.NewModel(order)
+		if err := data.Messages.Each(filter, func(message store.Message) error {
+			model.Add(message.Content)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		return model, nil
+	}
+}
+
+// generate returns a sentence from model meeting args, preferring one that isn't just something it was given,
+// and whether it is such a fresh one.
+// Only whole messages count as given: the old check also turned down sentences found inside a longer message,
+// but that took a LIKE query over the message table per try, where the model can check whole lines by hash.
+func generate(model *markov.Model, args commandArgs) (string, bool, error) {
+	opts := markov.Options{
+		Seed:     strings.Fields(args.text("words")),
+		MinWords: args.int("min"),
+		MaxWords: args.int("max"),
+		Novel:    true,
+	}
+	if opts.MaxWords > 0 && opts.MinWords > opts.MaxWords {
+		return "", false, errors.New("--min can't be more than --max")
+	}
+	out, err := model.Generate(opts)
+	if err == nil {
+		return out, true, nil
+	}
+	opts.Novel = false
+	if out, err = model.Generate(opts); err == nil {
+		return out, false, nil
+	}
+	if len(opts.Seed) > 0 {
+		return "", false, fmt.Errorf("I couldn't come up with anything about %s", strings.Join(opts.Seed, " "))
+	}
+	return "", false, errors.New("I couldn't come up with anything")
+}
+
+// saveQuote records generated content so it can be upvoted with /upquote
+func saveQuote(chanID, authorID, content string, fresh bool) {
+	quoteID, err := data.Quotes.Add(store.Quote{ChanID: chanID, AuthorID: authorID, Content: content, IsFresh: fresh})
+	if err != nil {
+		fmt.Println("ERROR inserting into DiscordQuote ", err.Error())
+		return
+	}
+	channel := bot.channel(chanID)
+	channel.Lock()
+	channel.lastQuoteID = quoteID
+	channel.upQuoters = nil
+	channel.Unlock()
+}
+
+func spamuser(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	markovOrder := args.int("order")
+	userID := args.user("username")
+	username, err := getUsername(session, userID, guildID)
+	if err != nil {
+		return "", err
+	}
+	chanIDs, err := guildChannelIDs(session, guildID)
+	if err != nil {
+		return "", err
+	}
+
+	model, err := chains.Get(userChainKey(guildID, userID, markovOrder),
+		buildChain(markovOrder, store.MessageFilter{ChanIDs: chanIDs, AuthorID: userID}))
+	if err != nil {
+		return "", err
+	}
+
+	outStr, fresh, err := generate(model, args)
+	if err != nil {
+		return "", err
+	}
+	saveQuote(chanID, userID, outStr, fresh)
+	return fmt.Sprintf("%s: %s", username, outStr), nil
+}
+
+func spamdiscord(session discord.Session, guildID, chanID, authorID, messageID string, args commandArgs) (string, error) {
+	markovOrder := args.int("order")
+	chanIDs, err := guildChannelIDs(session, guildID)
+	if err != nil {
+		return "", err
+	}
+
+	model, err := chains.Get(guildChainKey(guildID, markovOrder),
+		buildChain(markovOrder, store.MessageFilter{ChanIDs: chanIDs, NotAuthorIDs: []string{ownUserID}, NoBlank: true}))
+	if err != nil {
+		return "", err
+	}
+
+	outStr, fresh, err := generate(model, args)
+	if err != nil {
+		return "", err
+	}
+	saveQuote(chanID, "", outStr, fresh)
+	return outStr, nil
+}
+
+// markovModelPath is where disgo markov train saves the model called name
+func markovModelPath(name string) string {
+	return filepath.Join(cfg.MarkovRoot, name+markovFileExt)
+}
+
+// markovModelNames lists the models saved in markov_root
+func markovModelNames() ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(cfg.MarkovRoot, "*"+markovFileExt))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = strings.TrimSuffix(filepath.Base(path), markovFileExt)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// loadMarkovModel returns the model saved as name, through chains so it's only read again once it's retrained or evicted
+func loadMarkovModel(name string) (*markov.Model, error) {
+	path := markovModelPath(name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	//a retrained file replaces the model read from the old one
+	return chains.GetVersion("file "+name, strconv.FormatInt(info.ModTime().UnixNano(), 10), func() (*markov.Model, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return markov.LoadModel(file)
+	})
+}
+
+func spam(session discord.Session, guildID, chanID, authorID, messageID string, args []string) (string, error) {
+	names, err := markovModelNames()
+	if err != nil {
+		return "", err
+	}
+	if len(args) < 1 {
+		if len(names) == 0 {
+			return "", errors.New("No logs have been trained yet")
+		}
+		return strings.Join(names, ", "), nil
+	}
+	name := strings.ToLower(args[0])
+	found := false
+	var similar []string
+	for _, other := range names {
+		if other == name {
+			found = true
+			break
+		}
+		if strings.Contains(other, name) {
+			similar = append(similar, other)
+		}
+	}
+	if !found {
+		switch len(similar) {
+		case 0:
+			return "", errors.New("No logs found for " + args[0])
+		case 1:
+			name = similar[0]
+		default:
+			return "Did you mean one of the following: " + strings.Join(similar, ", "), nil
+		}
+	}
+
+	model, err := loadMarkovModel(name)
+	if err != nil {
+		return "", err
+	}
+	outStr, err := model.Generate(markov.Options{})
+	if err != nil {
+		return "", errors.New("I couldn't come up with anything for " + name)
+	}
+	return fmt.Sprintf("%s:\n%s", name, outStr), nil
+}
+
+// markovCommand handles "disgo markov train <name> <logfile> [order]", saving a model of the logfile's lines for /spam <name>
+func markovCommand(args []string) error {
+	if len(args) < 3 || len(args) > 4 || args[0] != "train" {
+		return errors.New("usage: disgo markov train <name> <logfile> [order]")
+	}
+	if len(cfg.MarkovRoot) == 0 {
+		return errors.New("markov_root (or DISGO_MARKOV_ROOT) isn't set")
+	}
+	name := strings.ToLower(args[1])
+	if !markovNameRegex.MatchString(name) {
+		return fmt.Errorf("names are up to 64 letters, numbers, - and _, got %s", args[1])
+	}
+	order := 1
+	if len(args) == 4 {
+		var err error
+		if order, err = strconv.Atoi(args[3]); err != nil || order < 1 || order > maxMarkovOrder {
+			return fmt.Errorf("order must be between 1 and %d, got %s", maxMarkovOrder, args[3])
+		}
+	}
+
+	logs, err := os.Open(args[2])
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+	chain := markov.NewChain(order)
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(nil, 1<<20)
+	lines := 0
+	for scanner.Scan() {
+		chain.Add(scanner.Text())
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	//written beside the model and renamed over it, so /spam never reads half a file
+	if err := os.MkdirAll(cfg.MarkovRoot, 0755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(cfg.MarkovRoot, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := chain.Save(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	path := markovModelPath(name)
+	if err := os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+	fmt.Printf("Trained %s from %d lines of %s into %s\n", name, lines, args[2], path)
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/hangman"
+)
+
+// guildState is what the bot remembers about a guild between messages, guarded by its mutex
+type guildState struct {
+	sync.Mutex
+	ignored          map[string]time.Time //userID -> ignored until
+	muted            map[string]muteState
+	timedOut         map[string]time.Time //userID -> kept in the timeout channel until
+	rouletteBets     []userBet
+	rouletteSpinning bool
+	wasNicknamed     bool //the next GuildMemberUpdate for the bot is its own nickname change
+}
+
+// muteState is how long a user is muted for and how the mute is being enforced
+type muteState struct {
+	until   time.Time
+	backend string //muteByDeleting, muteByTimeout or muteByRole
+	roleID  string //role given by muteByRole
+}
+
+// channelState is what the bot remembers about a channel between messages, guarded by its mutex
+type channelState struct {
+	sync.Mutex
+	lastMessages [4]string
+	hangmanGame  *hangman.Game
+	lastQuoteID  int64    //discord_quote ID of the last spam, 0 if there hasn't been one
+	upQuoters    []string //users who've upquoted lastQuoteID
+}
+
+// userState is what the bot remembers about a user between messages, guarded by its mutex
+type userState struct {
+	sync.Mutex
+	lastVote           time.Time
+	lastKappa          time.Time
+	lastMessage        discordgo.Message //the bot's last reply to the user
+	lastCommandMessage discordgo.Message //the user's command that got that reply
+}
+
+// botState shards everything by guild, channel, and user, so handlers running on different goroutines only contend when they share one
+type botState struct {
+	mutex    sync.Mutex
+	guilds   map[string]*guildState
+	channels map[string]*channelState
+	users    map[string]*userState
+}
+
+var bot = botState{
+	guilds:   make(map[string]*guildState),
+	channels: make(map[string]*channelState),
+	users:    make(map[string]*userState),
+}
+
+func (b *botState) guild(guildID string) *guildState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	guild, found := b.guilds[guildID]
+	if !found {
+		guild = &guildState{ignored: make(map[string]time.Time), muted: make(map[string]muteState), timedOut: make(map[string]time.Time)}
+		b.guilds[guildID] = guild
+	}
+	return guild
+}
+
+func (b *botState) channel(chanID string) *channelState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	channel, found := b.channels[chanID]
+	if !found {
+		channel = &channelState{}
+		b.channels[chanID] = channel
+	}
+	return channel
+}
+
+func (b *botState) user(userID string) *userState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	user, found := b.users[userID]
+	if !found {
+		user = &userState{}
+		b.users[userID] = user
+	}
+	return user
+}
+
+func (g *guildState) isIgnored(userID string) bool {
+	g.Lock()
+	defer g.Unlock()
+	return g.ignored[userID].After(time.Now())
+}
+
+func (g *guildState) isMuted(userID string) bool {
+	g.Lock()
+	defer g.Unlock()
+	return g.muted[userID].until.After(time.Now())
+}
+
+// muteBackend returns how userID's mute is being enforced, or "" if they aren't muted
+func (g *guildState) muteBackend(userID string) string {
+	mute, _ := g.currentMute(userID)
+	return mute.backend
+}
+
+// currentMute returns userID's mute, or false if they aren't muted
+func (g *guildState) currentMute(userID string) (muteState, bool) {
+	g.Lock()
+	defer g.Unlock()
+	if mute := g.muted[userID]; mute.until.After(time.Now()) {
+		return mute, true
+	}
+	return muteState{}, false
+}
+
+func (g *guildState) isTimedOut(userID string) bool {
+	g.Lock()
+	defer g.Unlock()
+	return g.timedOut[userID].After(time.Now())
+}
+
+func (g *guildState) ignore(userID string, until time.Time) {
+	g.Lock()
+	defer g.Unlock()
+	g.ignored[userID] = until
+}
+
+func (g *guildState) timeOut(userID string, until time.Time) {
+	g.Lock()
+	defer g.Unlock()
+	g.timedOut[userID] = until
+}
+
+func (g *guildState) mute(userID string, until time.Time, backend, roleID string) {
+	g.Lock()
+	defer g.Unlock()
+	g.muted[userID] = muteState{until: until, backend: backend, roleID: roleID}
+}
+
+// placeBet adds bet to the spinning wheel, returning false if the wheel isn't spinning
+func (g *guildState) placeBet(bet userBet) bool {
+	g.Lock()
+	defer g.Unlock()
+	if !g.rouletteSpinning {
+		return false
+	}
+	g.rouletteBets = append(g.rouletteBets, bet)
+	return true
+}
+
+// takeBets stops the wheel and returns the bets that were on it
+func (g *guildState) takeBets() []userBet {
+	g.Lock()
+	defer g.Unlock()
+	bets := g.rouletteBets
+	g.rouletteBets = nil
+	g.rouletteSpinning = false
+	return bets
+}
+
+// tryVote records a vote by the user at now, returning false without recording it if they already voted within window of now
+func (u *userState) tryVote(now time.Time, window time.Duration) bool {
+	u.Lock()
+	defer u.Unlock()
+	if !u.lastVote.IsZero() && now.Sub(u.lastVote) < window {
+		return false
+	}
+	u.lastVote = now
+	return true
+}
+
+// setLastReply records the bot's reply to the user's command, for /delete
+func (u *userState) setLastReply(command, reply discordgo.Message) {
+	u.Lock()
+	defer u.Unlock()
+	u.lastCommandMessage = command
+	u.lastMessage = reply
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/heydabop/disgo/store"
+)
+
+func TestTryVote(t *testing.T) {
+	var user userState
+	start := time.Now()
+	if !user.tryVote(start, 5*time.Minute) {
+		t.Fatal("first vote refused")
+	}
+	if user.tryVote(start.Add(4*time.Minute), 5*time.Minute) {
+		t.Error("vote 4 minutes later allowed with a 5 minute window")
+	}
+	if !user.tryVote(start.Add(5*time.Minute), 5*time.Minute) {
+		t.Error("vote 5 minutes later refused with a 5 minute window")
+	}
+}
+
+// sayAll has each of authors say content(i) at once, returning everything the bot sent in reply
+func (b *testBot) sayAll(authors []*discordgo.User, content func(i int) string) []string {
+	before := len(b.fake.Sent())
+	var wg sync.WaitGroup
+	for i, author := range authors {
+		wg.Add(1)
+		go func(i int, author *discordgo.User) {
+			defer wg.Done()
+			b.handle(b.fake, b.fake.MessageCreate(testChanID, author, content(i)))
+		}(i, author)
+	}
+	wg.Wait()
+	b.messagesSeen += len(authors)
+	var replies []string
+	for _, message := range b.fake.Sent()[before:] {
+		replies = append(replies, message.Content)
+	}
+	return replies
+}
+
+func count(replies []string, want string) int {
+	n := 0
+	for _, reply := range replies {
+		if reply == want {
+			n++
+		}
+	}
+	return n
+}
+
+func TestConcurrentVotesByOneUser(t *testing.T) {
+	b := newTestBot(t)
+	authors := make([]*discordgo.User, 20)
+	for i := range authors {
+		authors[i] = b.bob
+	}
+	replies := b.sayAll(authors, func(int) string { return "<@4> ++" })
+	if karma, err := data.Karma.Karma(testGuildID, b.alice.ID); err != nil || karma != 1 {
+		t.Errorf("alice has %d karma (%v) after bob voted 20 times at once, want 1", karma, err)
+	}
+	if slow := count(replies, "Slow down champ."); slow != len(authors)-1 {
+		t.Errorf("%d votes told to slow down, want %d", slow, len(authors)-1)
+	}
+}
+
+func TestConcurrentVotesByManyUsers(t *testing.T) {
+	b := newTestBot(t)
+	authors := make([]*discordgo.User, 20)
+	for i := range authors {
+		authors[i] = &discordgo.User{ID: strconv.Itoa(100 + i), Username: "voter" + strconv.Itoa(i)}
+		b.fake.AddMember(testGuildID, authors[i])
+	}
+	replies := b.sayAll(authors, func(int) string { return "<@4> ++" })
+	if karma, err := data.Karma.Karma(testGuildID, b.alice.ID); err != nil || karma != len(authors) {
+		t.Errorf("alice has %d karma (%v), want %d", karma, err, len(authors))
+	}
+	if len(replies) != 0 {
+		t.Errorf("votes got replies %q, want none", replies)
+	}
+}
+
+func TestConcurrentHangman(t *testing.T) {
+	b := newTestBot(t)
+	authors := make([]*discordgo.User, 20)
+	for i := range authors {
+		authors[i] = &discordgo.User{ID: strconv.Itoa(100 + i), Username: "player" + strconv.Itoa(i)}
+		b.fake.AddMember(testGuildID, authors[i])
+	}
+	replies := b.sayAll(authors, func(int) string { return "/hangman" })
+	if busy := count(replies, "There's already a game going in here"); busy != len(authors)-1 {
+		t.Errorf("%d of %d /hangmans found a game going, want all but one: %q", busy, len(authors), replies)
+	}
+
+	channel := bot.channel(testChanID)
+	channel.Lock()
+	game := channel.hangmanGame
+	channel.Unlock()
+	if game == nil {
+		t.Fatal("no game stored for the channel")
+	}
+	//half the players guess at once while the other half keep chatting
+	replies = b.sayAll(authors, func(i int) string {
+		if i%2 == 0 {
+			return "/guess " + string(rune('a'+i))
+		}
+		return "chatting " + strconv.Itoa(i)
+	})
+	if len(replies) != len(authors)/2 {
+		t.Errorf("%d replies to %d guesses: %q", len(replies), len(authors)/2, replies)
+	}
+	for _, reply := range replies {
+		if strings.Contains(reply, "panic") {
+			t.Errorf("guess panicked: %s", reply)
+		}
+	}
+	if count, err := data.Messages.Count(store.MessageFilter{ChanIDs: []string{testChanID}}); err != nil || count != int64(b.messagesSeen) {
+		t.Errorf("stored %d messages (%v), want %d", count, err, b.messagesSeen)
+	}
+}
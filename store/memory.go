@@ -0,0 +1,843 @@
+package store
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type memMessage struct {
+	Message
+	seq int64 //insertion order, for messages created in the same instant
+}
+
+type memMessages struct {
+	sync.Mutex
+	nextSeq  int64
+	messages map[string]memMessage
+}
+
+type memKarma struct {
+	sync.Mutex
+	karma map[Account]int
+	votes []Vote
+}
+
+type memMoney struct {
+	sync.Mutex
+	money map[Account]float64
+}
+
+type memPresenceRow struct {
+	guildID, userID string
+	presence        Presence
+	at              time.Time
+}
+
+type memPresence struct {
+	sync.Mutex
+	rows []memPresenceRow
+}
+
+type memUsernames struct {
+	sync.Mutex
+	usernames []OwnUsername
+}
+
+type memPee struct {
+	userID string
+	at     time.Time
+}
+
+type memPees struct {
+	sync.Mutex
+	pees []memPee
+}
+
+type memVoice struct {
+	sync.Mutex
+	states []VoiceState
+}
+
+type memReminder struct {
+	Reminder
+	sent, cancelled bool
+}
+
+type memReminders struct {
+	sync.Mutex
+	reminders []memReminder
+}
+
+type memShipments struct {
+	sync.Mutex
+	nextID    int64
+	shipments []Shipment
+}
+
+type memQuotes struct {
+	sync.Mutex
+	quotes []Quote
+}
+
+type memError struct {
+	reportedCount int
+	reporters     map[string]bool
+}
+
+type memErrors struct {
+	sync.Mutex
+	errors map[string]*memError
+}
+
+type memSettings struct {
+	sync.Mutex
+	settings map[string]GuildSettings
+}
+
+type memModeration struct {
+	sync.Mutex
+	actions []ModerationAction
+}
+
+type memJobs struct {
+	sync.Mutex
+	lastRuns map[string]time.Time
+}
+
+type memProfiles struct {
+	sync.Mutex
+	timezones map[string]string
+}
+
+type memCountdowns struct {
+	sync.Mutex
+	countdowns map[[2]string]Countdown //[guildID, name]
+}
+
+// NewMemory returns an empty Store that keeps everything in memory, for tests and running without a database
+func NewMemory() Store {
+	return Store{
+		Messages:   &memMessages{messages: make(map[string]memMessage)},
+		Karma:      &memKarma{karma: make(map[Account]int)},
+		Money:      &memMoney{money: make(map[Account]float64)},
+		Presence:   &memPresence{},
+		Reminders:  &memReminders{},
+		Shipments:  &memShipments{},
+		Usernames:  &memUsernames{},
+		Pees:       &memPees{},
+		Voice:      &memVoice{},
+		Quotes:     &memQuotes{},
+		Errors:     &memErrors{errors: make(map[string]*memError)},
+		Settings:   &memSettings{settings: make(map[string]GuildSettings)},
+		Moderation: &memModeration{},
+		Jobs:       &memJobs{lastRuns: make(map[string]time.Time)},
+		Profiles:   &memProfiles{timezones: make(map[string]string)},
+		Countdowns: &memCountdowns{countdowns: make(map[[2]string]Countdown)},
+	}
+}
+
+func (s *memMessages) Insert(id, chanID, authorID, content string) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, found := s.messages[id]; found {
+		return errors.New("duplicate message " + id)
+	}
+	s.nextSeq++
+	s.messages[id] = memMessage{Message{id, chanID, authorID, content, time.Now()}, s.nextSeq}
+	return nil
+}
+
+func (s *memMessages) Update(id, content string) error {
+	s.Lock()
+	defer s.Unlock()
+	if message, found := s.messages[id]; found {
+		message.Content = content
+		s.messages[id] = message
+	}
+	return nil
+}
+
+func (s *memMessages) Delete(id string) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.messages, id)
+	return nil
+}
+
+func (s *memMessages) TopAuthors(chanID string, limit int) ([]UserCount, error) {
+	s.Lock()
+	defer s.Unlock()
+	counts := make(map[string]int64)
+	for _, message := range s.messages {
+		if message.ChanID == chanID && !strings.HasPrefix(message.Content, "/") {
+			counts[message.AuthorID]++
+		}
+	}
+	top := make([]UserCount, 0, len(counts))
+	for userID, count := range counts {
+		top = append(top, UserCount{userID, count})
+	}
+	sort.Slice(top, func(i, j int) bool { return top[i].Count > top[j].Count })
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (f MessageFilter) matches(m Message) bool {
+	return containsString(f.ChanIDs, m.ChanID) &&
+		(f.AuthorID == "" || m.AuthorID == f.AuthorID) &&
+		!containsString(f.NotAuthorIDs, m.AuthorID) &&
+		strings.HasPrefix(m.Content, f.Prefix) &&
+		!(f.NoCommands && strings.HasPrefix(m.Content, "/")) &&
+		!(f.NoBlank && strings.Trim(m.Content, " ") == "") &&
+		!(f.SingleLine && strings.Contains(m.Content, "\n")) &&
+		(!f.Links || strings.HasPrefix(m.Content, "http://") || strings.HasPrefix(m.Content, "https://"))
+}
+
+// matching returns the messages filter matches, oldest first
+func (s *memMessages) matching(filter MessageFilter) []Message {
+	s.Lock()
+	defer s.Unlock()
+	var found []memMessage
+	for _, message := range s.messages {
+		if filter.matches(message.Message) {
+			found = append(found, message)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].CreateDate.Equal(found[j].CreateDate) {
+			return found[i].CreateDate.Before(found[j].CreateDate)
+		}
+		return found[i].seq < found[j].seq
+	})
+	messages := make([]Message, len(found))
+	for i, message := range found {
+		messages[i] = message.Message
+	}
+	return messages
+}
+
+func (s *memMessages) Each(filter MessageFilter, fn func(Message) error) error {
+	for _, message := range s.matching(filter) {
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memMessages) Count(filter MessageFilter) (int64, error) {
+	return int64(len(s.matching(filter))), nil
+}
+
+func (s *memMessages) CountByAuthor(filter MessageFilter) ([]UserCount, error) {
+	counts := make(map[string]int64)
+	for _, message := range s.matching(filter) {
+		counts[message.AuthorID]++
+	}
+	byAuthor := make([]UserCount, 0, len(counts))
+	for userID, count := range counts {
+		byAuthor = append(byAuthor, UserCount{userID, count})
+	}
+	sort.Slice(byAuthor, func(i, j int) bool { return byAuthor[i].Count > byAuthor[j].Count })
+	return byAuthor, nil
+}
+
+func (s *memMessages) First(filter MessageFilter) (Message, error) {
+	if messages := s.matching(filter); len(messages) > 0 {
+		return messages[0], nil
+	}
+	return Message{}, ErrNotFound
+}
+
+func (s *memMessages) Last(filter MessageFilter) (Message, error) {
+	if messages := s.matching(filter); len(messages) > 0 {
+		return messages[len(messages)-1], nil
+	}
+	return Message{}, ErrNotFound
+}
+
+func (s *memMessages) Random(filter MessageFilter, limit int) ([]Message, error) {
+	messages := s.matching(filter)
+	rand.Shuffle(len(messages), func(i, j int) { messages[i], messages[j] = messages[j], messages[i] })
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+	return messages, nil
+}
+
+func (s *memKarma) Karma(guildID, userID string) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.karma[Account{guildID, userID}], nil
+}
+
+func (s *memKarma) AddKarma(guildID, userID string, delta int) error {
+	s.Lock()
+	defer s.Unlock()
+	s.karma[Account{guildID, userID}] += delta
+	return nil
+}
+
+func (s *memKarma) TopKarma(guildID string, limit int) ([]UserKarma, error) {
+	s.Lock()
+	defer s.Unlock()
+	var top []UserKarma
+	for account, karma := range s.karma {
+		if account.GuildID == guildID {
+			top = append(top, UserKarma{account.UserID, karma})
+		}
+	}
+	sort.Slice(top, func(i, j int) bool { return top[i].Karma > top[j].Karma })
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+func (s *memKarma) RecordVote(vote Vote) error {
+	s.Lock()
+	defer s.Unlock()
+	if vote.CreateDate.IsZero() {
+		vote.CreateDate = time.Now()
+	}
+	s.votes = append(s.votes, vote)
+	return nil
+}
+
+func (s *memKarma) lastVote(match func(Vote) bool) (Vote, error) {
+	s.Lock()
+	defer s.Unlock()
+	for i := len(s.votes) - 1; i >= 0; i-- {
+		if match(s.votes[i]) {
+			return s.votes[i], nil
+		}
+	}
+	return Vote{}, ErrNotFound
+}
+
+func (s *memKarma) LastVoteFor(guildID, voteeID string) (Vote, error) {
+	return s.lastVote(func(v Vote) bool { return v.GuildID == guildID && v.VoteeID == voteeID })
+}
+
+func (s *memKarma) LastVoteBy(guildID, voterID string) (Vote, error) {
+	return s.lastVote(func(v Vote) bool { return v.GuildID == guildID && v.VoterID == voterID })
+}
+
+func (s *memMoney) Balance(guildID, userID string) (float64, error) {
+	s.Lock()
+	defer s.Unlock()
+	account := Account{guildID, userID}
+	money, found := s.money[account]
+	if !found {
+		money = StartingMoney
+		s.money[account] = money
+	}
+	return money, nil
+}
+
+func (s *memMoney) Change(guildID, userID string, delta float64) error {
+	s.Lock()
+	defer s.Unlock()
+	//like the UPDATE it stands in for, changing an account that was never opened does nothing
+	if money, found := s.money[Account{guildID, userID}]; found {
+		s.money[Account{guildID, userID}] = money + delta
+	}
+	return nil
+}
+
+func (s *memMoney) Top(guildID string, limit int) ([]UserMoney, error) {
+	s.Lock()
+	defer s.Unlock()
+	var top []UserMoney
+	for account, money := range s.money {
+		if account.GuildID == guildID {
+			top = append(top, UserMoney{account.UserID, money})
+		}
+	}
+	sort.Slice(top, func(i, j int) bool { return top[i].Money > top[j].Money })
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+func (s *memMoney) Accounts() ([]Account, error) {
+	s.Lock()
+	defer s.Unlock()
+	accounts := make([]Account, 0, len(s.money))
+	for account := range s.money {
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (s *memPresence) Insert(guildID, userID string, presence Presence, at time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	s.rows = append(s.rows, memPresenceRow{guildID, userID, presence, at})
+	return nil
+}
+
+func (s *memPresence) Latest(guildID string) ([]LatestPresence, error) {
+	s.Lock()
+	defer s.Unlock()
+	latest := make(map[string]LatestPresence)
+	for _, row := range s.rows {
+		if row.guildID != guildID {
+			continue
+		}
+		if last, found := latest[row.userID]; !found || !row.at.Before(last.CreateDate) {
+			latest[row.userID] = LatestPresence{row.userID, row.presence.Status, row.at}
+		}
+	}
+	presences := make([]LatestPresence, 0, len(latest))
+	for _, presence := range latest {
+		presences = append(presences, presence)
+	}
+	return presences, nil
+}
+
+func (f PresenceFilter) matches(row memPresenceRow) bool {
+	return row.guildID == f.GuildID &&
+		(f.UserID == "" || row.userID == f.UserID) &&
+		!containsString(f.NotUserIDs, row.userID) &&
+		row.at.After(f.After) &&
+		(len(f.Statuses) == 0 || containsString(f.Statuses, row.presence.Status)) &&
+		(f.NotStatus == "" || row.presence.Status != f.NotStatus) &&
+		(!f.Playing || row.presence.Game != "") &&
+		(f.GameOrIdle == "" || row.presence.Game == "" || strings.EqualFold(row.presence.Game, f.GameOrIdle))
+}
+
+// matching returns the presences filter matches, oldest first
+func (s *memPresence) matching(filter PresenceFilter) []PresenceRecord {
+	s.Lock()
+	defer s.Unlock()
+	var records []PresenceRecord
+	for _, row := range s.rows {
+		if filter.matches(row) {
+			records = append(records, PresenceRecord{row.userID, row.presence.Status, row.presence.Game, row.at})
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].CreateDate.Before(records[j].CreateDate) })
+	return records
+}
+
+func (s *memPresence) Each(filter PresenceFilter, fn func(PresenceRecord) error) error {
+	for _, record := range s.matching(filter) {
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memPresence) First(filter PresenceFilter) (PresenceRecord, error) {
+	if records := s.matching(filter); len(records) > 0 {
+		return records[0], nil
+	}
+	return PresenceRecord{}, ErrNotFound
+}
+
+func (s *memPresence) Last(filter PresenceFilter) (PresenceRecord, error) {
+	if records := s.matching(filter); len(records) > 0 {
+		return records[len(records)-1], nil
+	}
+	return PresenceRecord{}, ErrNotFound
+}
+
+func (s *memUsernames) Add(username OwnUsername) error {
+	s.Lock()
+	defer s.Unlock()
+	if username.CreateDate.IsZero() {
+		username.CreateDate = time.Now()
+	}
+	s.usernames = append(s.usernames, username)
+	return nil
+}
+
+func (s *memUsernames) Latest(guildID string) (OwnUsername, error) {
+	s.Lock()
+	defer s.Unlock()
+	for i := len(s.usernames) - 1; i >= 0; i-- {
+		if s.usernames[i].GuildID == guildID {
+			return s.usernames[i], nil
+		}
+	}
+	return OwnUsername{}, ErrNotFound
+}
+
+func (s *memPees) Add(userID string) error {
+	s.Lock()
+	defer s.Unlock()
+	s.pees = append(s.pees, memPee{userID, time.Now()})
+	return nil
+}
+
+func (s *memPees) Last(userID string) (time.Time, error) {
+	s.Lock()
+	defer s.Unlock()
+	for i := len(s.pees) - 1; i >= 0; i-- {
+		if s.pees[i].userID == userID {
+			return s.pees[i].at, nil
+		}
+	}
+	return time.Time{}, ErrNotFound
+}
+
+func (s *memPees) Since(t time.Time) ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+	var userIDs []string
+	for _, pee := range s.pees {
+		if !pee.at.Before(t) {
+			userIDs = append(userIDs, pee.userID)
+		}
+	}
+	return userIDs, nil
+}
+
+func (s *memVoice) Insert(state VoiceState) error {
+	s.Lock()
+	defer s.Unlock()
+	s.states = append(s.states, state)
+	return nil
+}
+
+func (s *memReminders) Add(reminder Reminder) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+	reminder.ID = int64(len(s.reminders) + 1)
+	s.reminders = append(s.reminders, memReminder{Reminder: reminder})
+	return reminder.ID, nil
+}
+
+// find returns reminder id, callers hold the lock
+func (s *memReminders) find(id int64) (*memReminder, error) {
+	if id < 1 || id > int64(len(s.reminders)) {
+		return nil, ErrNotFound
+	}
+	return &s.reminders[id-1], nil
+}
+
+func (s *memReminders) MarkSent(id int64) error {
+	s.Lock()
+	defer s.Unlock()
+	reminder, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	reminder.sent = true
+	return nil
+}
+
+func (s *memReminders) Reschedule(id int64, sendTime time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	reminder, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	reminder.SendTime, reminder.SnoozedUntil = sendTime, time.Time{}
+	return nil
+}
+
+func (s *memReminders) Snooze(id int64, until time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	reminder, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	reminder.SnoozedUntil = until
+	return nil
+}
+
+func (s *memReminders) Cancel(id int64) error {
+	s.Lock()
+	defer s.Unlock()
+	reminder, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	reminder.cancelled = true
+	return nil
+}
+
+func (s *memReminders) Pending() ([]Reminder, error) {
+	return s.PendingFor("")
+}
+
+func (s *memReminders) PendingFor(authorID string) ([]Reminder, error) {
+	s.Lock()
+	defer s.Unlock()
+	var pending []Reminder
+	for _, reminder := range s.reminders {
+		if !reminder.sent && !reminder.cancelled && (len(authorID) == 0 || reminder.AuthorID == authorID) {
+			pending = append(pending, reminder.Reminder)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Due().Before(pending[j].Due()) })
+	return pending, nil
+}
+
+func (s *memShipments) Add(shipment Shipment) error {
+	s.Lock()
+	defer s.Unlock()
+	for _, existing := range s.shipments {
+		if existing.Carrier == shipment.Carrier && existing.TrackingNumber == shipment.TrackingNumber && existing.AuthorID == shipment.AuthorID {
+			return errors.New("shipment already tracked")
+		}
+	}
+	s.nextID++
+	shipment.ID = s.nextID
+	s.shipments = append(s.shipments, shipment)
+	return nil
+}
+
+func (s *memShipments) All() ([]Shipment, error) {
+	s.Lock()
+	defer s.Unlock()
+	return append([]Shipment(nil), s.shipments...), nil
+}
+
+func (s *memShipments) Delete(id int64) error {
+	s.Lock()
+	defer s.Unlock()
+	for i, shipment := range s.shipments {
+		if shipment.ID == id {
+			s.shipments = append(s.shipments[:i], s.shipments[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *memQuotes) Add(quote Quote) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+	quote.ID = int64(len(s.quotes) + 1)
+	quote.Score = 0
+	s.quotes = append(s.quotes, quote)
+	return quote.ID, nil
+}
+
+func (s *memQuotes) Upvote(id int64) error {
+	s.Lock()
+	defer s.Unlock()
+	if id < 1 || id > int64(len(s.quotes)) {
+		return nil
+	}
+	s.quotes[id-1].Score++
+	return nil
+}
+
+func (s *memQuotes) Top(chanID string, limit int) ([]Quote, error) {
+	s.Lock()
+	defer s.Unlock()
+	var top []Quote
+	for _, quote := range s.quotes {
+		if quote.ChanID == chanID && quote.Score > 0 {
+			top = append(top, quote)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Score > top[j].Score })
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+func (s *memErrors) Record(command, args, message string) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	id := uuid.NewV4().String()
+	s.errors[id] = &memError{reporters: make(map[string]bool)}
+	return id, nil
+}
+
+func (s *memErrors) AddReporter(errorID, ip string) error {
+	s.Lock()
+	defer s.Unlock()
+	e, found := s.errors[errorID]
+	if !found {
+		return ErrNotFound
+	}
+	if e.reporters[ip] {
+		return errors.New("already reported from " + ip)
+	}
+	e.reporters[ip] = true
+	return nil
+}
+
+func (s *memErrors) IncrementReported(errorID string) error {
+	s.Lock()
+	defer s.Unlock()
+	if e, found := s.errors[errorID]; found {
+		e.reportedCount++
+	}
+	return nil
+}
+
+func (s *memSettings) Get(guildID string) (GuildSettings, error) {
+	s.Lock()
+	defer s.Unlock()
+	settings, found := s.settings[guildID]
+	if !found {
+		return settings, ErrNotFound
+	}
+	return settings, nil
+}
+
+func (s *memSettings) Save(guildID string, settings GuildSettings) error {
+	s.Lock()
+	defer s.Unlock()
+	s.settings[guildID] = settings
+	return nil
+}
+
+func (s *memModeration) Add(action ModerationAction) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+	if action.StartDate.IsZero() {
+		action.StartDate = time.Now()
+	}
+	action.ID = int64(len(s.actions) + 1)
+	action.LiftedBy, action.LiftedDate = "", time.Time{}
+	s.actions = append(s.actions, action)
+	return action.ID, nil
+}
+
+func (s *memModeration) Lift(guildID, targetID, kind, liftedBy string) error {
+	s.Lock()
+	defer s.Unlock()
+	now := time.Now()
+	for i, action := range s.actions {
+		if action.GuildID == guildID && action.TargetID == targetID && action.Kind == kind && action.InEffect(now) {
+			s.actions[i].LiftedBy, s.actions[i].LiftedDate = liftedBy, now
+		}
+	}
+	return nil
+}
+
+func (s *memModeration) Active() ([]ModerationAction, error) {
+	s.Lock()
+	defer s.Unlock()
+	now := time.Now()
+	var active []ModerationAction
+	for _, action := range s.actions {
+		if action.InEffect(now) {
+			active = append(active, action)
+		}
+	}
+	return active, nil
+}
+
+func (s *memModeration) History(guildID, targetID string, limit int) ([]ModerationAction, error) {
+	s.Lock()
+	defer s.Unlock()
+	var history []ModerationAction
+	for i := len(s.actions) - 1; i >= 0 && len(history) < limit; i-- {
+		if s.actions[i].GuildID == guildID && s.actions[i].TargetID == targetID {
+			history = append(history, s.actions[i])
+		}
+	}
+	return history, nil
+}
+
+func (s *memJobs) LastRun(name string) (time.Time, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.lastRuns[name], nil
+}
+
+func (s *memJobs) SetLastRun(name string, at time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+	s.lastRuns[name] = at
+	return nil
+}
+
+func (s *memProfiles) Timezone(userID string) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.timezones[userID], nil
+}
+
+func (s *memProfiles) SetTimezone(userID, zone string) error {
+	s.Lock()
+	defer s.Unlock()
+	if len(zone) == 0 {
+		delete(s.timezones, userID)
+		return nil
+	}
+	s.timezones[userID] = zone
+	return nil
+}
+
+func (s *memProfiles) Timezones() (map[string]string, error) {
+	s.Lock()
+	defer s.Unlock()
+	zones := make(map[string]string, len(s.timezones))
+	for userID, zone := range s.timezones {
+		zones[userID] = zone
+	}
+	return zones, nil
+}
+
+func (s *memCountdowns) Add(countdown Countdown) error {
+	s.Lock()
+	defer s.Unlock()
+	key := [2]string{countdown.GuildID, countdown.Name}
+	if _, found := s.countdowns[key]; found {
+		return ErrExists
+	}
+	s.countdowns[key] = countdown
+	return nil
+}
+
+func (s *memCountdowns) Get(guildID, name string) (Countdown, error) {
+	s.Lock()
+	defer s.Unlock()
+	countdown, found := s.countdowns[[2]string{guildID, name}]
+	if !found {
+		return countdown, ErrNotFound
+	}
+	return countdown, nil
+}
+
+func (s *memCountdowns) List(guildID string) ([]Countdown, error) {
+	s.Lock()
+	defer s.Unlock()
+	var countdowns []Countdown
+	for key, countdown := range s.countdowns {
+		if key[0] == guildID {
+			countdowns = append(countdowns, countdown)
+		}
+	}
+	sort.Slice(countdowns, func(i, j int) bool { return countdowns[i].Name < countdowns[j].Name })
+	return countdowns, nil
+}
+
+func (s *memCountdowns) Delete(guildID, name string) error {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.countdowns, [2]string{guildID, name})
+	return nil
+}
@@ -0,0 +1,870 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	uuid "github.com/satori/go.uuid"
+)
+
+type pgMessages struct{ db *sql.DB }
+type pgKarma struct{ db *sql.DB }
+type pgMoney struct{ db *sql.DB }
+type pgPresence struct{ db *sql.DB }
+type pgReminders struct{ db *sql.DB }
+type pgShipments struct{ db *sql.DB }
+type pgUsernames struct{ db *sql.DB }
+type pgPees struct{ db *sql.DB }
+type pgVoice struct{ db *sql.DB }
+type pgQuotes struct{ db *sql.DB }
+type pgErrors struct{ db *sql.DB }
+type pgSettings struct{ db *sql.DB }
+type pgModeration struct{ db *sql.DB }
+type pgJobs struct{ db *sql.DB }
+type pgProfiles struct{ db *sql.DB }
+type pgCountdowns struct{ db *sql.DB }
+
+// NewPostgres returns a Store backed by db, which should already be migrated
+func NewPostgres(db *sql.DB) Store {
+	return Store{
+		Messages:   pgMessages{db},
+		Karma:      pgKarma{db},
+		Money:      pgMoney{db},
+		Presence:   pgPresence{db},
+		Reminders:  pgReminders{db},
+		Shipments:  pgShipments{db},
+		Usernames:  pgUsernames{db},
+		Pees:       pgPees{db},
+		Voice:      pgVoice{db},
+		Quotes:     pgQuotes{db},
+		Errors:     pgErrors{db},
+		Settings:   pgSettings{db},
+		Moderation: pgModeration{db},
+		Jobs:       pgJobs{db},
+		Profiles:   pgProfiles{db},
+		Countdowns: pgCountdowns{db},
+	}
+}
+
+// parseIDs converts Discord IDs for the tables that store them as numbers
+func parseIDs(ids ...string) ([]uint64, error) {
+	parsed := make([]uint64, len(ids))
+	for i, id := range ids {
+		var err error
+		if parsed[i], err = strconv.ParseUint(id, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+func (s pgMessages) Insert(id, chanID, authorID, content string) error {
+	ids, err := parseIDs(id, chanID, authorID)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO message (id, chan_id, author_id, content) VALUES ($1, $2, $3, $4)`, ids[0], ids[1], ids[2], content)
+	return err
+}
+
+func (s pgMessages) Update(id, content string) error {
+	ids, err := parseIDs(id)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`UPDATE message SET content = $1 WHERE id = $2`, content, ids[0])
+	return err
+}
+
+func (s pgMessages) Delete(id string) error {
+	ids, err := parseIDs(id)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`DELETE FROM message WHERE id = $1`, ids[0])
+	return err
+}
+
+func (s pgMessages) TopAuthors(chanID string, limit int) ([]UserCount, error) {
+	ids, err := parseIDs(chanID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT author_id, count(author_id) AS num_messages FROM message WHERE chan_id = $1 AND content NOT LIKE '/%' GROUP BY author_id ORDER BY count(author_id) DESC LIMIT $2`, ids[0], limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var counts []UserCount
+	for rows.Next() {
+		var count UserCount
+		if err := rows.Scan(&count.UserID, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// conditions builds a WHERE clause, numbering the arguments it collects from $1
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+// add appends clause, with each ? in it standing for the next of args
+func (c *conditions) add(clause string, args ...interface{}) {
+	for _, arg := range args {
+		c.args = append(c.args, arg)
+		clause = strings.Replace(clause, "?", "$"+strconv.Itoa(len(c.args)), 1)
+	}
+	c.clauses = append(c.clauses, clause)
+}
+
+func (c *conditions) String() string {
+	return strings.Join(c.clauses, " AND ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (f MessageFilter) where() (*conditions, error) {
+	chanIDs, err := parseIDs(f.ChanIDs...)
+	if err != nil {
+		return nil, err
+	}
+	c := &conditions{}
+	c.add(`chan_id = ANY(?)`, uint64Array(chanIDs))
+	if f.AuthorID != "" {
+		ids, err := parseIDs(f.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		c.add(`author_id = ?`, ids[0])
+	}
+	if len(f.NotAuthorIDs) > 0 {
+		ids, err := parseIDs(f.NotAuthorIDs...)
+		if err != nil {
+			return nil, err
+		}
+		c.add(`author_id != ALL(?)`, uint64Array(ids))
+	}
+	if f.Prefix != "" {
+		c.add(`content LIKE ?`, likeEscaper.Replace(f.Prefix)+"%")
+	}
+	if f.NoCommands {
+		c.add(`content NOT LIKE '/%'`)
+	}
+	if f.NoBlank {
+		c.add(`trim(content) != ''`)
+	}
+	if f.SingleLine {
+		c.add(`strpos(content, chr(10)) = 0`)
+	}
+	if f.Links {
+		c.add(`(content LIKE 'http://%' OR content LIKE 'https://%')`)
+	}
+	return c, nil
+}
+
+// query calls fn with each message filter matches in orderBy, at most limit of them unless it's 0
+func (s pgMessages) query(filter MessageFilter, orderBy string, limit int, fn func(Message) error) error {
+	where, err := filter.where()
+	if err != nil {
+		return err
+	}
+	query := `SELECT id, chan_id, author_id, coalesce(content, ''), create_date FROM message WHERE ` + where.String() + ` ORDER BY ` + orderBy
+	if limit > 0 {
+		where.args = append(where.args, limit)
+		query += ` LIMIT $` + strconv.Itoa(len(where.args))
+	}
+	rows, err := s.db.Query(query, where.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var message Message
+		if err := rows.Scan(&message.ID, &message.ChanID, &message.AuthorID, &message.Content, &message.CreateDate); err != nil {
+			return err
+		}
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s pgMessages) Each(filter MessageFilter, fn func(Message) error) error {
+	return s.query(filter, `create_date`, 0, fn)
+}
+
+func (s pgMessages) one(filter MessageFilter, orderBy string) (Message, error) {
+	var found []Message
+	if err := s.query(filter, orderBy, 1, func(m Message) error {
+		found = append(found, m)
+		return nil
+	}); err != nil {
+		return Message{}, err
+	}
+	if len(found) == 0 {
+		return Message{}, ErrNotFound
+	}
+	return found[0], nil
+}
+
+func (s pgMessages) First(filter MessageFilter) (Message, error) {
+	return s.one(filter, `create_date`)
+}
+
+func (s pgMessages) Last(filter MessageFilter) (Message, error) {
+	return s.one(filter, `create_date DESC`)
+}
+
+func (s pgMessages) Random(filter MessageFilter, limit int) ([]Message, error) {
+	var messages []Message
+	err := s.query(filter, `random()`, limit, func(m Message) error {
+		messages = append(messages, m)
+		return nil
+	})
+	return messages, err
+}
+
+func (s pgMessages) Count(filter MessageFilter) (int64, error) {
+	where, err := filter.where()
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = s.db.QueryRow(`SELECT count(*) FROM message WHERE `+where.String(), where.args...).Scan(&count)
+	return count, err
+}
+
+func (s pgMessages) CountByAuthor(filter MessageFilter) ([]UserCount, error) {
+	where, err := filter.where()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT author_id, count(*) FROM message WHERE `+where.String()+` GROUP BY author_id ORDER BY count(*) DESC`, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var counts []UserCount
+	for rows.Next() {
+		var count UserCount
+		if err := rows.Scan(&count.UserID, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+func (s pgKarma) Karma(guildID, userID string) (int, error) {
+	var karma int
+	if err := s.db.QueryRow(`SELECT karma FROM user_karma WHERE guild_id = $1 AND user_id = $2`, guildID, userID).Scan(&karma); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return karma, nil
+}
+
+func (s pgKarma) AddKarma(guildID, userID string, delta int) error {
+	_, err := s.db.Exec(`INSERT INTO user_karma(guild_id, user_id, karma) VALUES ($1, $2, $3)
+		ON CONFLICT (guild_id, user_id) DO UPDATE SET karma = user_karma.karma + EXCLUDED.karma`, guildID, userID, delta)
+	return err
+}
+
+func (s pgKarma) TopKarma(guildID string, limit int) ([]UserKarma, error) {
+	rows, err := s.db.Query(`SELECT user_id, karma FROM user_karma WHERE guild_id = $1 ORDER BY karma DESC LIMIT $2`, guildID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var karmas []UserKarma
+	for rows.Next() {
+		var karma UserKarma
+		if err := rows.Scan(&karma.UserID, &karma.Karma); err != nil {
+			return nil, err
+		}
+		karmas = append(karmas, karma)
+	}
+	return karmas, rows.Err()
+}
+
+func (s pgKarma) RecordVote(vote Vote) error {
+	ids, err := parseIDs(vote.MessageID)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO vote(guild_id, message_id, voter_id, votee_id, is_upvote) values ($1, $2, $3, $4, $5)`,
+		vote.GuildID, ids[0], vote.VoterID, vote.VoteeID, vote.IsUpvote)
+	return err
+}
+
+func (s pgKarma) lastVote(query, guildID, userID string) (Vote, error) {
+	vote := Vote{GuildID: guildID}
+	if err := s.db.QueryRow(query, guildID, userID).Scan(&vote.MessageID, &vote.VoterID, &vote.VoteeID, &vote.IsUpvote, &vote.CreateDate); err != nil {
+		if err == sql.ErrNoRows {
+			return vote, ErrNotFound
+		}
+		return vote, err
+	}
+	return vote, nil
+}
+
+func (s pgKarma) LastVoteFor(guildID, voteeID string) (Vote, error) {
+	return s.lastVote(`SELECT message_id, voter_id, votee_id, is_upvote, create_date FROM vote WHERE guild_id = $1 AND votee_id = $2 ORDER BY create_date DESC LIMIT 1`, guildID, voteeID)
+}
+
+func (s pgKarma) LastVoteBy(guildID, voterID string) (Vote, error) {
+	return s.lastVote(`SELECT message_id, voter_id, votee_id, is_upvote, create_date FROM vote WHERE guild_id = $1 AND voter_id = $2 ORDER BY create_date DESC LIMIT 1`, guildID, voterID)
+}
+
+func (s pgMoney) Balance(guildID, userID string) (float64, error) {
+	var money float64
+	if err := s.db.QueryRow(`SELECT money FROM user_money WHERE guild_id = $1 AND user_id = $2`, guildID, userID).Scan(&money); err != nil {
+		if err != sql.ErrNoRows {
+			return 0, err
+		}
+		money = StartingMoney
+		if _, err := s.db.Exec(`INSERT INTO user_money(guild_id, user_id, money) VALUES ($1, $2, $3)`, guildID, userID, money); err != nil {
+			return 0, err
+		}
+	}
+	return money, nil
+}
+
+func (s pgMoney) Change(guildID, userID string, delta float64) error {
+	_, err := s.db.Exec(`UPDATE user_money SET money = money + $1 WHERE guild_id = $2 AND user_id = $3`, delta, guildID, userID)
+	return err
+}
+
+func (s pgMoney) Top(guildID string, limit int) ([]UserMoney, error) {
+	rows, err := s.db.Query(`SELECT user_id, money FROM user_money WHERE guild_id = $1 ORDER BY money DESC LIMIT $2`, guildID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var monies []UserMoney
+	for rows.Next() {
+		var money UserMoney
+		if err := rows.Scan(&money.UserID, &money.Money); err != nil {
+			return nil, err
+		}
+		monies = append(monies, money)
+	}
+	return monies, rows.Err()
+}
+
+func (s pgMoney) Accounts() ([]Account, error) {
+	rows, err := s.db.Query(`SELECT guild_id, user_id FROM user_money`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var accounts []Account
+	for rows.Next() {
+		var account Account
+		if err := rows.Scan(&account.GuildID, &account.UserID); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+func (s pgPresence) Insert(guildID, userID string, presence Presence, at time.Time) error {
+	ids, err := parseIDs(guildID, userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO user_presence(create_date, guild_id, user_id, presence, game, activities) VALUES ($1, $2, $3, $4, $5, $6)`,
+		at, ids[0], ids[1], presence.Status, presence.Game, presence.Activities)
+	return err
+}
+
+func (s pgPresence) Latest(guildID string) ([]LatestPresence, error) {
+	ids, err := parseIDs(guildID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT DISTINCT ON (user_id) user_id, presence, create_date FROM user_presence WHERE guild_id = $1 ORDER BY user_id, create_date DESC`, ids[0])
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var latest []LatestPresence
+	for rows.Next() {
+		var presence LatestPresence
+		if err := rows.Scan(&presence.UserID, &presence.Status, &presence.CreateDate); err != nil {
+			return nil, err
+		}
+		latest = append(latest, presence)
+	}
+	return latest, rows.Err()
+}
+
+func (f PresenceFilter) where() (*conditions, error) {
+	guildIDs, err := parseIDs(f.GuildID)
+	if err != nil {
+		return nil, err
+	}
+	c := &conditions{}
+	c.add(`guild_id = ?`, guildIDs[0])
+	if f.UserID != "" {
+		ids, err := parseIDs(f.UserID)
+		if err != nil {
+			return nil, err
+		}
+		c.add(`user_id = ?`, ids[0])
+	}
+	if len(f.NotUserIDs) > 0 {
+		ids, err := parseIDs(f.NotUserIDs...)
+		if err != nil {
+			return nil, err
+		}
+		c.add(`user_id != ALL(?)`, uint64Array(ids))
+	}
+	if !f.After.IsZero() {
+		c.add(`create_date > ?`, f.After)
+	}
+	if len(f.Statuses) > 0 {
+		c.add(`presence = ANY(?)`, pq.Array(f.Statuses))
+	}
+	if f.NotStatus != "" {
+		c.add(`presence != ?`, f.NotStatus)
+	}
+	if f.Playing {
+		c.add(`game != ''`)
+	}
+	if f.GameOrIdle != "" {
+		c.add(`(lower(game) = lower(?) OR game = '')`, f.GameOrIdle)
+	}
+	return c, nil
+}
+
+// query calls fn with each presence filter matches in orderBy, at most limit of them unless it's 0
+func (s pgPresence) query(filter PresenceFilter, orderBy string, limit int, fn func(PresenceRecord) error) error {
+	where, err := filter.where()
+	if err != nil {
+		return err
+	}
+	query := `SELECT user_id, presence, coalesce(game, ''), create_date FROM user_presence WHERE ` + where.String() + ` ORDER BY ` + orderBy
+	if limit > 0 {
+		where.args = append(where.args, limit)
+		query += ` LIMIT $` + strconv.Itoa(len(where.args))
+	}
+	rows, err := s.db.Query(query, where.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var record PresenceRecord
+		if err := rows.Scan(&record.UserID, &record.Status, &record.Game, &record.CreateDate); err != nil {
+			return err
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s pgPresence) Each(filter PresenceFilter, fn func(PresenceRecord) error) error {
+	return s.query(filter, `create_date`, 0, fn)
+}
+
+func (s pgPresence) one(filter PresenceFilter, orderBy string) (PresenceRecord, error) {
+	var found []PresenceRecord
+	if err := s.query(filter, orderBy, 1, func(r PresenceRecord) error {
+		found = append(found, r)
+		return nil
+	}); err != nil {
+		return PresenceRecord{}, err
+	}
+	if len(found) == 0 {
+		return PresenceRecord{}, ErrNotFound
+	}
+	return found[0], nil
+}
+
+func (s pgPresence) First(filter PresenceFilter) (PresenceRecord, error) {
+	return s.one(filter, `create_date`)
+}
+
+func (s pgPresence) Last(filter PresenceFilter) (PresenceRecord, error) {
+	return s.one(filter, `create_date DESC`)
+}
+
+func (s pgUsernames) Add(username OwnUsername) error {
+	_, err := s.db.Exec(`INSERT INTO own_username (author_id, username, locked_minutes, guild_id) VALUES ($1, $2, $3, $4)`,
+		username.AuthorID, username.Username, username.LockedMinutes, username.GuildID)
+	return err
+}
+
+func (s pgUsernames) Latest(guildID string) (OwnUsername, error) {
+	username := OwnUsername{GuildID: guildID}
+	if err := s.db.QueryRow(`SELECT author_id, username, locked_minutes, create_date FROM own_username WHERE guild_id = $1 ORDER BY create_date DESC LIMIT 1`, guildID).
+		Scan(&username.AuthorID, &username.Username, &username.LockedMinutes, &username.CreateDate); err != nil {
+		if err == sql.ErrNoRows {
+			return username, ErrNotFound
+		}
+		return username, err
+	}
+	return username, nil
+}
+
+func (s pgPees) Add(userID string) error {
+	_, err := s.db.Exec(`INSERT INTO pee_log(user_id) VALUES ($1)`, userID)
+	return err
+}
+
+func (s pgPees) Last(userID string) (time.Time, error) {
+	var last time.Time
+	if err := s.db.QueryRow(`SELECT create_date FROM pee_log WHERE user_id = $1 ORDER BY create_date DESC LIMIT 1`, userID).Scan(&last); err != nil {
+		if err == sql.ErrNoRows {
+			return last, ErrNotFound
+		}
+		return last, err
+	}
+	return last, nil
+}
+
+func (s pgPees) Since(t time.Time) ([]string, error) {
+	rows, err := s.db.Query(`SELECT user_id FROM pee_log WHERE create_date >= $1`, t)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+func (s pgVoice) Insert(state VoiceState) error {
+	_, err := s.db.Exec(`INSERT INTO voice_state (guild_id, chan_id, user_id, session_id, deaf, mute, self_deaf, self_mute, suppress) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		state.GuildID, state.ChanID, state.UserID, state.SessionID, state.Deaf, state.Mute, state.SelfDeaf, state.SelfMute, state.Suppress)
+	return err
+}
+
+func (s pgReminders) Add(reminder Reminder) (int64, error) {
+	var id int64
+	err := s.db.QueryRow(`INSERT INTO reminder (chan_id, author_id, send_time, content, every, dm) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		reminder.ChanID, reminder.AuthorID, reminder.SendTime.UTC(), reminder.Content, reminder.Every, reminder.DM).Scan(&id)
+	return id, err
+}
+
+func (s pgReminders) MarkSent(id int64) error {
+	_, err := s.db.Exec(`UPDATE reminder SET sent_at = now() WHERE id = $1`, id)
+	return err
+}
+
+func (s pgReminders) Reschedule(id int64, sendTime time.Time) error {
+	_, err := s.db.Exec(`UPDATE reminder SET send_time = $2, snoozed_until = NULL WHERE id = $1`, id, sendTime.UTC())
+	return err
+}
+
+func (s pgReminders) Snooze(id int64, until time.Time) error {
+	_, err := s.db.Exec(`UPDATE reminder SET snoozed_until = $2 WHERE id = $1`, id, until.UTC())
+	return err
+}
+
+func (s pgReminders) Cancel(id int64) error {
+	_, err := s.db.Exec(`UPDATE reminder SET cancelled_at = now() WHERE id = $1`, id)
+	return err
+}
+
+func (s pgReminders) Pending() ([]Reminder, error) {
+	return s.query(`SELECT id, chan_id, author_id, send_time, content, every, dm, snoozed_until FROM reminder WHERE sent_at IS NULL AND cancelled_at IS NULL`)
+}
+
+func (s pgReminders) PendingFor(authorID string) ([]Reminder, error) {
+	return s.query(`SELECT id, chan_id, author_id, send_time, content, every, dm, snoozed_until FROM reminder
+WHERE author_id = $1 AND sent_at IS NULL AND cancelled_at IS NULL ORDER BY COALESCE(snoozed_until, send_time)`, authorID)
+}
+
+func (s pgReminders) query(query string, args ...interface{}) ([]Reminder, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var reminders []Reminder
+	for rows.Next() {
+		var reminder Reminder
+		var content sql.NullString
+		var snoozedUntil sql.NullTime
+		if err := rows.Scan(&reminder.ID, &reminder.ChanID, &reminder.AuthorID, &reminder.SendTime, &content, &reminder.Every, &reminder.DM, &snoozedUntil); err != nil {
+			return nil, err
+		}
+		reminder.Content = content.String
+		reminder.SnoozedUntil = snoozedUntil.Time
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+func (s pgShipments) Add(shipment Shipment) error {
+	_, err := s.db.Exec(`INSERT INTO shipment(carrier, tracking_number, chan_id, author_id) VALUES ($1, $2, $3, $4)`,
+		shipment.Carrier, shipment.TrackingNumber, shipment.ChanID, shipment.AuthorID)
+	return err
+}
+
+func (s pgShipments) All() ([]Shipment, error) {
+	rows, err := s.db.Query(`SELECT id, carrier, tracking_number, chan_id, author_id FROM shipment`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var shipments []Shipment
+	for rows.Next() {
+		var shipment Shipment
+		if err := rows.Scan(&shipment.ID, &shipment.Carrier, &shipment.TrackingNumber, &shipment.ChanID, &shipment.AuthorID); err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+	return shipments, rows.Err()
+}
+
+func (s pgShipments) Delete(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM shipment WHERE id = $1`, id)
+	return err
+}
+
+func (s pgQuotes) Add(quote Quote) (int64, error) {
+	var id int64
+	var err error
+	if len(quote.AuthorID) > 0 {
+		err = s.db.QueryRow(`INSERT INTO discord_quote(chan_id, author_id, content, score, is_fresh) VALUES ($1, $2, $3, 0, $4) RETURNING id`,
+			quote.ChanID, quote.AuthorID, quote.Content, quote.IsFresh).Scan(&id)
+	} else {
+		err = s.db.QueryRow(`INSERT INTO discord_quote(chan_id, content, score, is_fresh) values ($1, $2, 0, $3) RETURNING id`,
+			quote.ChanID, quote.Content, quote.IsFresh).Scan(&id)
+	}
+	return id, err
+}
+
+func (s pgQuotes) Upvote(id int64) error {
+	_, err := s.db.Exec(`UPDATE discord_quote SET score = score + 1 WHERE id = $1`, id)
+	return err
+}
+
+func (s pgQuotes) Top(chanID string, limit int) ([]Quote, error) {
+	rows, err := s.db.Query(`SELECT author_id, content, score FROM discord_quote WHERE chan_id = $1 AND score > 0 ORDER BY score DESC LIMIT $2`, chanID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var quotes []Quote
+	for rows.Next() {
+		quote := Quote{ChanID: chanID}
+		var authorID sql.NullString
+		if err := rows.Scan(&authorID, &quote.Content, &quote.Score); err != nil {
+			return nil, err
+		}
+		quote.AuthorID = authorID.String
+		quotes = append(quotes, quote)
+	}
+	return quotes, rows.Err()
+}
+
+func (s pgErrors) Record(command, args, message string) (string, error) {
+	var errorID uuid.UUID
+	err := s.db.QueryRow(`INSERT INTO error(command, args, error) VALUES ($1, $2, $3) RETURNING id`, command, args, message).Scan(&errorID)
+	return errorID.String(), err
+}
+
+func (s pgErrors) AddReporter(errorID, ip string) error {
+	_, err := s.db.Exec(`INSERT INTO error_ip(error_id, ip) VALUES ($1, $2)`, errorID, ip)
+	return err
+}
+
+func (s pgErrors) IncrementReported(errorID string) error {
+	_, err := s.db.Exec(`UPDATE error SET reported_count = reported_count + 1 WHERE id = $1`, errorID)
+	return err
+}
+
+func (s pgSettings) Get(guildID string) (GuildSettings, error) {
+	var settings GuildSettings
+	if err := s.db.QueryRow(`SELECT prefix, disabled_commands, disabled_categories, reactions_disabled, mod_roles, mute_backend, mute_role_id, timeout_chan_id FROM guild_settings WHERE guild_id = $1`, guildID).Scan(
+		&settings.Prefix, pq.Array(&settings.DisabledCommands), pq.Array(&settings.DisabledCategories), &settings.ReactionsDisabled, pq.Array(&settings.ModRoles), &settings.MuteBackend, &settings.MuteRoleID, &settings.TimeoutChanID); err != nil {
+		if err == sql.ErrNoRows {
+			return settings, ErrNotFound
+		}
+		return settings, err
+	}
+	return settings, nil
+}
+
+func (s pgSettings) Save(guildID string, settings GuildSettings) error {
+	_, err := s.db.Exec(`INSERT INTO guild_settings(guild_id, prefix, disabled_commands, disabled_categories, reactions_disabled, mod_roles, mute_backend, mute_role_id, timeout_chan_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (guild_id) DO UPDATE SET prefix = EXCLUDED.prefix, disabled_commands = EXCLUDED.disabled_commands, disabled_categories = EXCLUDED.disabled_categories,
+reactions_disabled = EXCLUDED.reactions_disabled, mod_roles = EXCLUDED.mod_roles, mute_backend = EXCLUDED.mute_backend, mute_role_id = EXCLUDED.mute_role_id,
+timeout_chan_id = EXCLUDED.timeout_chan_id`,
+		guildID, settings.Prefix, pq.Array(settings.DisabledCommands), pq.Array(settings.DisabledCategories), settings.ReactionsDisabled, pq.Array(settings.ModRoles), settings.MuteBackend, settings.MuteRoleID, settings.TimeoutChanID)
+	return err
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func (s pgModeration) Add(action ModerationAction) (int64, error) {
+	if action.StartDate.IsZero() {
+		action.StartDate = time.Now()
+	}
+	var id int64
+	err := s.db.QueryRow(`INSERT INTO moderation_action(guild_id, target_id, actor_id, kind, reason, start_date, expire_date, mute_backend, mute_role_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`,
+		action.GuildID, action.TargetID, action.ActorID, action.Kind, action.Reason, action.StartDate, nullTime(action.ExpireDate), action.MuteBackend, action.MuteRoleID).Scan(&id)
+	return id, err
+}
+
+func (s pgModeration) Lift(guildID, targetID, kind, liftedBy string) error {
+	_, err := s.db.Exec(`UPDATE moderation_action SET lifted_by = $1, lifted_date = now()
+WHERE guild_id = $2 AND target_id = $3 AND kind = $4 AND lifted_date IS NULL AND (expire_date IS NULL OR expire_date > now())`, liftedBy, guildID, targetID, kind)
+	return err
+}
+
+func (s pgModeration) query(query string, args ...interface{}) ([]ModerationAction, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var actions []ModerationAction
+	for rows.Next() {
+		var action ModerationAction
+		var expireDate, liftedDate sql.NullTime
+		var liftedBy sql.NullString
+		if err := rows.Scan(&action.ID, &action.GuildID, &action.TargetID, &action.ActorID, &action.Kind, &action.Reason, &action.StartDate, &expireDate, &liftedBy, &liftedDate, &action.MuteBackend, &action.MuteRoleID); err != nil {
+			return nil, err
+		}
+		action.ExpireDate, action.LiftedBy, action.LiftedDate = expireDate.Time, liftedBy.String, liftedDate.Time
+		actions = append(actions, action)
+	}
+	return actions, rows.Err()
+}
+
+func (s pgModeration) Active() ([]ModerationAction, error) {
+	return s.query(`SELECT id, guild_id, target_id, actor_id, kind, reason, start_date, expire_date, lifted_by, lifted_date, mute_backend, mute_role_id FROM moderation_action
+WHERE lifted_date IS NULL AND (expire_date IS NULL OR expire_date > now()) ORDER BY start_date`)
+}
+
+func (s pgModeration) History(guildID, targetID string, limit int) ([]ModerationAction, error) {
+	return s.query(`SELECT id, guild_id, target_id, actor_id, kind, reason, start_date, expire_date, lifted_by, lifted_date, mute_backend, mute_role_id FROM moderation_action
+WHERE guild_id = $1 AND target_id = $2 ORDER BY start_date DESC LIMIT $3`, guildID, targetID, limit)
+}
+
+func (s pgJobs) LastRun(name string) (time.Time, error) {
+	var lastRun time.Time
+	err := s.db.QueryRow(`SELECT last_run FROM job WHERE name = $1`, name).Scan(&lastRun)
+	if err == sql.ErrNoRows {
+		return lastRun, nil
+	}
+	return lastRun, err
+}
+
+func (s pgJobs) SetLastRun(name string, at time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO job (name, last_run) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET last_run = EXCLUDED.last_run`, name, at.UTC())
+	return err
+}
+
+func (s pgProfiles) Timezone(userID string) (string, error) {
+	var zone string
+	err := s.db.QueryRow(`SELECT timezone FROM user_profile WHERE user_id = $1`, userID).Scan(&zone)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return zone, err
+}
+
+func (s pgProfiles) SetTimezone(userID, zone string) error {
+	_, err := s.db.Exec(`INSERT INTO user_profile (user_id, timezone) VALUES ($1, $2) ON CONFLICT (user_id) DO UPDATE SET timezone = EXCLUDED.timezone`, userID, zone)
+	return err
+}
+
+func (s pgProfiles) Timezones() (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT user_id, timezone FROM user_profile WHERE timezone != ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	zones := make(map[string]string)
+	for rows.Next() {
+		var userID, zone string
+		if err := rows.Scan(&userID, &zone); err != nil {
+			return nil, err
+		}
+		zones[userID] = zone
+	}
+	return zones, rows.Err()
+}
+
+func (s pgCountdowns) Add(countdown Countdown) error {
+	result, err := s.db.Exec(`INSERT INTO countdown (guild_id, name, author_id, start_date, end_date, annual) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (guild_id, name) DO NOTHING`,
+		countdown.GuildID, countdown.Name, countdown.AuthorID, nullTime(countdown.Start), countdown.End, countdown.Annual)
+	if err != nil {
+		return err
+	}
+	if added, err := result.RowsAffected(); err != nil {
+		return err
+	} else if added == 0 {
+		return ErrExists
+	}
+	return nil
+}
+
+func (s pgCountdowns) query(query string, args ...interface{}) ([]Countdown, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var countdowns []Countdown
+	for rows.Next() {
+		var countdown Countdown
+		var start sql.NullTime
+		if err := rows.Scan(&countdown.GuildID, &countdown.Name, &countdown.AuthorID, &start, &countdown.End, &countdown.Annual); err != nil {
+			return nil, err
+		}
+		countdown.Start = start.Time
+		countdowns = append(countdowns, countdown)
+	}
+	return countdowns, rows.Err()
+}
+
+func (s pgCountdowns) Get(guildID, name string) (Countdown, error) {
+	countdowns, err := s.query(`SELECT guild_id, name, author_id, start_date, end_date, annual FROM countdown WHERE guild_id = $1 AND name = $2`, guildID, name)
+	if err != nil {
+		return Countdown{}, err
+	}
+	if len(countdowns) == 0 {
+		return Countdown{}, ErrNotFound
+	}
+	return countdowns[0], nil
+}
+
+func (s pgCountdowns) List(guildID string) ([]Countdown, error) {
+	return s.query(`SELECT guild_id, name, author_id, start_date, end_date, annual FROM countdown WHERE guild_id = $1 ORDER BY name`, guildID)
+}
+
+func (s pgCountdowns) Delete(guildID, name string) error {
+	_, err := s.db.Exec(`DELETE FROM countdown WHERE guild_id = $1 AND name = $2`, guildID, name)
+	return err
+}
@@ -0,0 +1,354 @@
+// Package store is disgo's data access layer, one interface per domain with Postgres and in-memory implementations.
+package store
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrExists   = errors.New("already exists")
+)
+
+// StartingMoney is what an account holds when it's first opened
+const StartingMoney = 10
+
+type UserCount struct {
+	UserID string
+	Count  int64
+}
+
+type UserKarma struct {
+	UserID string
+	Karma  int
+}
+
+type UserMoney struct {
+	UserID string
+	Money  float64
+}
+
+type Account struct {
+	GuildID string
+	UserID  string
+}
+
+type Vote struct {
+	GuildID    string
+	MessageID  string
+	VoterID    string
+	VoteeID    string
+	IsUpvote   bool
+	CreateDate time.Time
+}
+
+type Message struct {
+	ID         string
+	ChanID     string
+	AuthorID   string
+	Content    string
+	CreateDate time.Time
+}
+
+// MessageFilter picks which messages a query covers, its zero fields matching every message in ChanIDs
+type MessageFilter struct {
+	ChanIDs      []string
+	AuthorID     string   //only this author's messages
+	NotAuthorIDs []string //leaving out these authors'
+	Prefix       string   //only messages starting with this
+	NoCommands   bool     //leaving out messages starting with /
+	NoBlank      bool     //leaving out messages that are empty or only spaces
+	SingleLine   bool     //leaving out messages with more than one line
+	Links        bool     //only messages starting with http:// or https://
+}
+
+type Presence struct {
+	Status     string
+	Game       string
+	Activities string //JSON array
+}
+
+type LatestPresence struct {
+	UserID     string
+	Status     string
+	CreateDate time.Time
+}
+
+type PresenceRecord struct {
+	UserID     string
+	Status     string
+	Game       string
+	CreateDate time.Time
+}
+
+// PresenceFilter picks which of GuildID's presences a query covers, its zero fields matching every one
+type PresenceFilter struct {
+	GuildID    string
+	UserID     string    //only this user's presences
+	NotUserIDs []string  //leaving out these users'
+	After      time.Time //only presences recorded after this
+	Statuses   []string  //only presences with one of these statuses
+	NotStatus  string    //leaving out presences with this status
+	Playing    bool      //only presences with a game
+	GameOrIdle string    //only presences playing this game, ignoring case, or playing nothing
+}
+
+type Reminder struct {
+	ID           int64
+	ChanID       string
+	AuthorID     string
+	SendTime     time.Time
+	Content      string
+	Every        string    //how often it repeats, like "1d" or "2w", "" if it doesn't
+	DM           bool      //sent to AuthorID directly instead of in ChanID
+	SnoozedUntil time.Time //zero unless it's been put off past SendTime
+}
+
+// Due is when reminder should next go out
+func (r Reminder) Due() time.Time {
+	if r.SnoozedUntil.After(r.SendTime) {
+		return r.SnoozedUntil
+	}
+	return r.SendTime
+}
+
+type Shipment struct {
+	ID             int64
+	Carrier        string
+	TrackingNumber string
+	ChanID         string
+	AuthorID       string
+}
+
+type OwnUsername struct {
+	GuildID       string
+	AuthorID      string //who gave it
+	Username      string
+	LockedMinutes int //how long until it can be changed again
+	CreateDate    time.Time
+}
+
+type VoiceState struct {
+	GuildID   string
+	ChanID    string //empty when the user left voice
+	UserID    string
+	SessionID string
+	Deaf      bool
+	Mute      bool
+	SelfDeaf  bool
+	SelfMute  bool
+	Suppress  bool
+}
+
+type Quote struct {
+	ID       int64
+	ChanID   string
+	AuthorID string //empty when the quote was generated from the whole channel
+	Content  string
+	Score    int
+	IsFresh  bool //didn't appear verbatim in the channel's history
+}
+
+type GuildSettings struct {
+	Prefix             string
+	DisabledCommands   []string
+	DisabledCategories []string
+	ReactionsDisabled  bool
+	ModRoles           []string
+	MuteBackend        string
+	MuteRoleID         string
+	TimeoutChanID      string
+}
+
+type ModerationAction struct {
+	ID          int64
+	GuildID     string
+	TargetID    string
+	ActorID     string
+	Kind        string
+	Reason      string
+	StartDate   time.Time
+	ExpireDate  time.Time //zero if it never expires
+	LiftedBy    string
+	LiftedDate  time.Time //zero unless someone lifted it early
+	MuteBackend string    //how a mute was enforced, so it's released the same way after the guild's settings change; empty for other kinds
+	MuteRoleID  string    //the role a mute enforced by role gave
+}
+
+// InEffect reports whether the action applies at t
+func (a ModerationAction) InEffect(t time.Time) bool {
+	return a.LiftedDate.IsZero() && (a.ExpireDate.IsZero() || a.ExpireDate.After(t))
+}
+
+type Countdown struct {
+	GuildID  string
+	Name     string
+	AuthorID string
+	Start    time.Time //zero for a plain countdown, set to track progress from Start to End
+	End      time.Time
+	Annual   bool //Start and End move on a year at a time once End passes
+}
+
+type MessageStore interface {
+	Insert(id, chanID, authorID, content string) error
+	Update(id, content string) error
+	Delete(id string) error
+	// TopAuthors counts messages per author in chanID, leaving out commands
+	TopAuthors(chanID string, limit int) ([]UserCount, error)
+	// Each calls fn with every message filter matches, oldest first, stopping at the first error fn returns
+	Each(filter MessageFilter, fn func(Message) error) error
+	Count(filter MessageFilter) (int64, error)
+	// CountByAuthor counts the messages filter matches per author, most first
+	CountByAuthor(filter MessageFilter) ([]UserCount, error)
+	// First returns the oldest message filter matches, or ErrNotFound
+	First(filter MessageFilter) (Message, error)
+	// Last returns the newest message filter matches, or ErrNotFound
+	Last(filter MessageFilter) (Message, error)
+	// Random returns up to limit messages filter matches, in random order
+	Random(filter MessageFilter, limit int) ([]Message, error)
+}
+
+type KarmaStore interface {
+	Karma(guildID, userID string) (int, error)
+	AddKarma(guildID, userID string, delta int) error
+	TopKarma(guildID string, limit int) ([]UserKarma, error)
+	RecordVote(vote Vote) error
+	// LastVoteFor returns the latest vote cast on voteeID, or ErrNotFound
+	LastVoteFor(guildID, voteeID string) (Vote, error)
+	// LastVoteBy returns the latest vote cast by voterID, or ErrNotFound
+	LastVoteBy(guildID, voterID string) (Vote, error)
+}
+
+type MoneyStore interface {
+	// Balance returns userID's money, opening an account with StartingMoney if they don't have one
+	Balance(guildID, userID string) (float64, error)
+	Change(guildID, userID string, delta float64) error
+	Top(guildID string, limit int) ([]UserMoney, error)
+	Accounts() ([]Account, error)
+}
+
+type PresenceStore interface {
+	Insert(guildID, userID string, presence Presence, at time.Time) error
+	// Latest returns each user's newest presence in guildID
+	Latest(guildID string) ([]LatestPresence, error)
+	// Each calls fn with every presence filter matches, oldest first, stopping at the first error fn returns
+	Each(filter PresenceFilter, fn func(PresenceRecord) error) error
+	// First returns the oldest presence filter matches, or ErrNotFound
+	First(filter PresenceFilter) (PresenceRecord, error)
+	// Last returns the newest presence filter matches, or ErrNotFound
+	Last(filter PresenceFilter) (PresenceRecord, error)
+}
+
+type ReminderStore interface {
+	Add(reminder Reminder) (int64, error)
+	// MarkSent records that a one-off reminder went out
+	MarkSent(id int64) error
+	// Reschedule moves a recurring reminder on to its next sendTime, clearing any snooze
+	Reschedule(id int64, sendTime time.Time) error
+	Snooze(id int64, until time.Time) error
+	Cancel(id int64) error
+	// Pending returns every reminder that hasn't gone out or been cancelled
+	Pending() ([]Reminder, error)
+	// PendingFor returns authorID's pending reminders, soonest first
+	PendingFor(authorID string) ([]Reminder, error)
+}
+
+type ShipmentStore interface {
+	Add(shipment Shipment) error
+	All() ([]Shipment, error)
+	Delete(id int64) error
+}
+
+type UsernameStore interface {
+	Add(username OwnUsername) error
+	// Latest returns the newest name the bot was given in guildID, or ErrNotFound
+	Latest(guildID string) (OwnUsername, error)
+}
+
+type PeeStore interface {
+	Add(userID string) error
+	// Last returns when userID last went, or ErrNotFound
+	Last(userID string) (time.Time, error)
+	// Since returns the user ID of everyone who went at or after t, once for each time they went
+	Since(t time.Time) ([]string, error)
+}
+
+type VoiceStore interface {
+	Insert(state VoiceState) error
+}
+
+type QuoteStore interface {
+	Add(quote Quote) (int64, error)
+	Upvote(id int64) error
+	Top(chanID string, limit int) ([]Quote, error)
+}
+
+type ErrorStore interface {
+	// Record saves a failed command and returns the error's ID
+	Record(command, args, message string) (string, error)
+	// AddReporter notes that ip reported errorID, failing if it already has
+	AddReporter(errorID, ip string) error
+	IncrementReported(errorID string) error
+}
+
+type SettingsStore interface {
+	// Get returns guildID's settings, or ErrNotFound if they've never been changed
+	Get(guildID string) (GuildSettings, error)
+	Save(guildID string, settings GuildSettings) error
+}
+
+type ModerationStore interface {
+	// Add records action, defaulting StartDate to now, and returns its ID
+	Add(action ModerationAction) (int64, error)
+	// Lift ends every action of kind on targetID still in effect
+	Lift(guildID, targetID, kind, liftedBy string) error
+	// Active returns every action still in effect, across all guilds
+	Active() ([]ModerationAction, error)
+	// History returns the latest limit actions taken against targetID, newest first
+	History(guildID, targetID string, limit int) ([]ModerationAction, error)
+}
+
+type JobStore interface {
+	// LastRun returns when the job called name last ran, or the zero time if it never has
+	LastRun(name string) (time.Time, error)
+	SetLastRun(name string, at time.Time) error
+}
+
+type ProfileStore interface {
+	// Timezone returns userID's IANA zone name, or "" if they haven't set one
+	Timezone(userID string) (string, error)
+	// SetTimezone saves userID's zone, "" clearing it
+	SetTimezone(userID, zone string) error
+	// Timezones returns every user's zone that's been set, by user ID
+	Timezones() (map[string]string, error)
+}
+
+type CountdownStore interface {
+	// Add saves countdown, or returns ErrExists if its guild already has one by that name
+	Add(countdown Countdown) error
+	// Get returns the countdown called name, or ErrNotFound
+	Get(guildID, name string) (Countdown, error)
+	// List returns guildID's countdowns by name
+	List(guildID string) ([]Countdown, error)
+	Delete(guildID, name string) error
+}
+
+type Store struct {
+	Messages   MessageStore
+	Karma      KarmaStore
+	Money      MoneyStore
+	Presence   PresenceStore
+	Reminders  ReminderStore
+	Shipments  ShipmentStore
+	Usernames  UsernameStore
+	Pees       PeeStore
+	Voice      VoiceStore
+	Quotes     QuoteStore
+	Errors     ErrorStore
+	Settings   SettingsStore
+	Moderation ModerationStore
+	Jobs       JobStore
+	Profiles   ProfileStore
+	Countdowns CountdownStore
+}
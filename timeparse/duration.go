@@ -0,0 +1,127 @@
+package timeparse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a duration whose years, months and days follow the calendar rather than being a fixed number of hours
+type Duration struct {
+	Years, Months, Days int
+	Clock               time.Duration
+}
+
+var (
+	durationPartRegex     = regexp.MustCompile(`(?i)^(\d+)(years?|y|months?|mo|weeks?|w|days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)`)
+	durationUnitOnlyRegex = regexp.MustCompile(`(?i)^(years?|y|months?|mo|weeks?|w|days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)$`)
+)
+
+// IsUnit reports whether token is a duration unit on its own, like "day" in "every day"
+func IsUnit(token string) bool {
+	return durationUnitOnlyRegex.MatchString(token)
+}
+
+func (d Duration) After(t time.Time) time.Time {
+	return t.AddDate(d.Years, d.Months, d.Days).Add(d.Clock)
+}
+
+func (d Duration) Before(t time.Time) time.Time {
+	return t.AddDate(-d.Years, -d.Months, -d.Days).Add(-d.Clock)
+}
+
+// String writes d compactly, the way ParseDuration reads it back, like "1y2mo3d4h5m6s"
+func (d Duration) String() string {
+	var b strings.Builder
+	clock := d.Clock
+	for _, part := range []struct {
+		value int
+		unit  string
+	}{
+		{d.Years, "y"},
+		{d.Months, "mo"},
+		{d.Days, "d"},
+		{int(clock / time.Hour), "h"},
+		{int(clock % time.Hour / time.Minute), "m"},
+		{int(clock % time.Minute / time.Second), "s"},
+	} {
+		if part.value != 0 {
+			fmt.Fprintf(&b, "%d%s", part.value, part.unit)
+		}
+	}
+	if b.Len() == 0 {
+		return "0s"
+	}
+	return b.String()
+}
+
+func (d *Duration) addUnit(value int, unit string) {
+	switch unit = strings.ToLower(unit); {
+	case strings.HasPrefix(unit, "y"):
+		d.Years += value
+	case strings.HasPrefix(unit, "mo"):
+		d.Months += value
+	case strings.HasPrefix(unit, "w"):
+		d.Days += 7 * value
+	case strings.HasPrefix(unit, "d"):
+		d.Days += value
+	case strings.HasPrefix(unit, "h"):
+		d.Clock += time.Duration(value) * time.Hour
+	case strings.HasPrefix(unit, "m"):
+		d.Clock += time.Duration(value) * time.Minute
+	case strings.HasPrefix(unit, "s"):
+		d.Clock += time.Duration(value) * time.Second
+	}
+}
+
+// ParseDuration reads a duration like "in 2 weeks and 3 days" or "1h30m" from the front of tokens, returning it and how many tokens it used
+func ParseDuration(tokens []string) (Duration, int, error) {
+	var d Duration
+	used, parts := 0, 0
+	if len(tokens) > 0 && strings.EqualFold(tokens[0], "in") {
+		used++
+	}
+	for used < len(tokens) {
+		token := tokens[used]
+		if strings.EqualFold(token, "and") && parts > 0 {
+			used++
+			continue
+		}
+		if value, err := strconv.Atoi(token); err == nil && used+1 < len(tokens) && IsUnit(tokens[used+1]) {
+			d.addUnit(value, tokens[used+1])
+			used += 2
+			parts++
+			continue
+		}
+		rest := token
+		compactParts := 0
+		for len(rest) > 0 {
+			match := durationPartRegex.FindStringSubmatch(rest)
+			if match == nil {
+				break
+			}
+			value, err := strconv.Atoi(match[1])
+			if err != nil {
+				return d, 0, err
+			}
+			d.addUnit(value, match[2])
+			rest = rest[len(match[0]):]
+			compactParts++
+		}
+		if compactParts == 0 || len(rest) > 0 {
+			break
+		}
+		used++
+		parts += compactParts
+	}
+	if parts == 0 {
+		return d, 0, errors.New("no duration found")
+	}
+	if used > 0 && strings.EqualFold(tokens[used-1], "and") {
+		used--
+	}
+	return d, used, nil
+}
@@ -0,0 +1,265 @@
+// Package timeparse reads the times and durations people type in commands: "in 2 hours", "tomorrow at 5pm", "next friday",
+// "at 18:30", "on dec 24", and ISO-8601 like 2024-12-24T18:30:00+01:00.
+package timeparse
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHour is the time of day for expressions that only give a date, like "next friday"
+const DefaultHour = 9
+
+var (
+	clockRegex   = regexp.MustCompile(`(?i)^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+	ordinalRegex = regexp.MustCompile(`(?i)^(\d{1,2})(?:st|nd|rd|th)?,?$`)
+	yearRegex    = regexp.MustCompile(`^\d{4}$`)
+
+	// layouts an absolute time can be written in, by how many tokens it takes; the ones without an offset are in the caller's zone
+	absoluteLayouts = []struct {
+		tokens int
+		layout string
+	}{
+		{3, "2006-01-02 15:04:05 -0700"}, //what remindme used to insist on
+		{2, "2006-01-02 15:04:05"},
+		{2, "2006-01-02 15:04"},
+		{1, time.RFC3339},
+		{1, "2006-01-02T15:04:05Z0700"},
+		{1, "2006-01-02T15:04Z07:00"},
+		{1, "2006-01-02T15:04:05"},
+		{1, "2006-01-02T15:04"},
+	}
+
+	months = map[string]time.Month{
+		"jan": time.January, "january": time.January, "feb": time.February, "february": time.February, "mar": time.March, "march": time.March,
+		"apr": time.April, "april": time.April, "may": time.May, "jun": time.June, "june": time.June, "jul": time.July, "july": time.July,
+		"aug": time.August, "august": time.August, "sep": time.September, "sept": time.September, "september": time.September,
+		"oct": time.October, "october": time.October, "nov": time.November, "november": time.November, "dec": time.December, "december": time.December,
+	}
+	weekdays = map[string]time.Weekday{
+		"sun": time.Sunday, "sunday": time.Sunday, "mon": time.Monday, "monday": time.Monday, "tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+		"wed": time.Wednesday, "wednesday": time.Wednesday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+		"fri": time.Friday, "friday": time.Friday, "sat": time.Saturday, "saturday": time.Saturday,
+	}
+
+	ErrNoTime = errors.New("no time found")
+)
+
+// day is a calendar date, year may be left to Parse to work out
+type day struct {
+	year      int
+	month     time.Month
+	day       int
+	knownYear bool
+}
+
+// daysIn is how many days month has in year
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func lookupMonth(name string) (time.Month, bool) {
+	month, found := months[strings.ToLower(strings.TrimSuffix(name, ","))]
+	return month, found
+}
+
+func lookupWeekday(name string) (time.Weekday, bool) {
+	weekday, found := weekdays[strings.ToLower(strings.TrimSuffix(name, ","))]
+	return weekday, found
+}
+
+// ParseClock reads a time of day like "18:30", "5pm" or "5:30am", or "noon" or "midnight"
+func ParseClock(token string) (hour, minute int, ok bool) {
+	switch strings.ToLower(token) {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+	match := clockRegex.FindStringSubmatch(token)
+	if match == nil || (len(match[2]) == 0 && len(match[3]) == 0) {
+		return 0, 0, false //a bare number isn't a time
+	}
+	hour, _ = strconv.Atoi(match[1])
+	if len(match[2]) > 0 {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	switch strings.ToLower(match[3]) {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if strings.EqualFold(match[3], "pm") {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// parseClock reads a time of day from the front of tokens, allowing "5 pm" as two tokens
+func parseClock(tokens []string) (hour, minute, used int, ok bool) {
+	if len(tokens) == 0 {
+		return 0, 0, 0, false
+	}
+	if len(tokens) > 1 && (strings.EqualFold(tokens[1], "am") || strings.EqualFold(tokens[1], "pm")) {
+		if hour, minute, ok := ParseClock(tokens[0] + tokens[1]); ok {
+			return hour, minute, 2, true
+		}
+	}
+	hour, minute, ok = ParseClock(tokens[0])
+	return hour, minute, 1, ok
+}
+
+// parseDay reads a date from the front of tokens: today, tomorrow, a weekday, a month and day, or an ISO date
+func parseDay(tokens []string, local time.Time) (day, int, bool) {
+	if len(tokens) == 0 {
+		return day{}, 0, false
+	}
+	offset := func(days int) day {
+		t := local.AddDate(0, 0, days)
+		return day{t.Year(), t.Month(), t.Day(), true}
+	}
+	first := strings.ToLower(tokens[0])
+	switch first {
+	case "today":
+		return offset(0), 1, true
+	case "tomorrow":
+		return offset(1), 1, true
+	}
+
+	//"friday", "next friday" and "this friday" all mean the next one after today
+	used := 0
+	if (first == "next" || first == "this") && len(tokens) > 1 {
+		used++
+	}
+	if weekday, found := lookupWeekday(tokens[used]); found {
+		days := (int(weekday)-int(local.Weekday())+6)%7 + 1
+		return offset(days), used + 1, true
+	}
+
+	if t, err := time.Parse("2006-01-02", tokens[0]); err == nil {
+		return day{t.Year(), t.Month(), t.Day(), true}, 1, true
+	}
+
+	//"dec 24" or "24 dec", with an optional year after either
+	var d day
+	if month, found := lookupMonth(tokens[0]); found && len(tokens) > 1 && ordinalRegex.MatchString(tokens[1]) {
+		d.month = month
+		d.day, _ = strconv.Atoi(ordinalRegex.FindStringSubmatch(tokens[1])[1])
+	} else if len(tokens) > 1 && ordinalRegex.MatchString(tokens[0]) {
+		month, found := lookupMonth(tokens[1])
+		if !found {
+			return day{}, 0, false
+		}
+		d.month = month
+		d.day, _ = strconv.Atoi(ordinalRegex.FindStringSubmatch(tokens[0])[1])
+	} else {
+		return day{}, 0, false
+	}
+	used = 2
+	if len(tokens) > 2 && yearRegex.MatchString(tokens[2]) {
+		d.year, _ = strconv.Atoi(tokens[2])
+		d.knownYear = true
+		used++
+	}
+	maxDay := daysIn(d.month, 2000) //a leap year, so feb 29 gets through until Parse picks the year
+	if d.knownYear {
+		maxDay = daysIn(d.month, d.year)
+	}
+	if d.day < 1 || d.day > maxDay {
+		return day{}, 0, false
+	}
+	return d, used, true
+}
+
+// parseAbsolute reads an ISO-8601 time, or the older "2006-01-02 15:04:05 -0700", from the front of tokens
+func parseAbsolute(tokens []string, loc *time.Location) (time.Time, int, bool) {
+	for _, l := range absoluteLayouts {
+		if len(tokens) < l.tokens {
+			continue
+		}
+		if t, err := time.ParseInLocation(l.layout, strings.Join(tokens[:l.tokens], " "), loc); err == nil {
+			return t, l.tokens, true
+		}
+	}
+	return time.Time{}, 0, false
+}
+
+// isKeyword reports whether token is a filler word that can come before a time or date, like "at 5pm" or "on friday"
+func isKeyword(token string) bool {
+	return strings.EqualFold(token, "at") || strings.EqualFold(token, "on")
+}
+
+// Parse reads a time expression from the front of tokens, returning the time it means and how many tokens it used.
+// Durations count from now, and dates and times of day are in loc: "at 18:30" is the next 18:30 there,
+// a date without a time is at DefaultHour, and a month and day without a year is the next one to come.
+// Days past the end of their month, like "feb 30", aren't dates.
+func Parse(tokens []string, now time.Time, loc *time.Location) (time.Time, int, error) {
+	start := 0
+	if len(tokens) > 0 && isKeyword(tokens[0]) {
+		start = 1
+	}
+	if t, used, ok := parseAbsolute(tokens[start:], loc); ok {
+		return t, start + used, nil
+	}
+	if d, used, err := ParseDuration(tokens); err == nil {
+		return d.After(now), used, nil
+	}
+
+	//a date and a time of day, in either order, each maybe after "at" or "on"
+	local := now.In(loc)
+	var date day
+	hour, minute := -1, 0
+	haveDate := false
+	i := 0
+	for part := 0; part < 2; part++ {
+		j := i
+		if j < len(tokens) && isKeyword(tokens[j]) {
+			j++
+		}
+		if !haveDate {
+			if d, used, ok := parseDay(tokens[j:], local); ok {
+				date, haveDate = d, true
+				i = j + used
+				continue
+			}
+		}
+		if hour < 0 {
+			if h, m, used, ok := parseClock(tokens[j:]); ok {
+				hour, minute = h, m
+				i = j + used
+				continue
+			}
+		}
+		break
+	}
+	if !haveDate && hour < 0 {
+		return time.Time{}, 0, ErrNoTime
+	}
+
+	if !haveDate {
+		t := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, i, nil
+	}
+	if hour < 0 {
+		hour = DefaultHour
+	}
+	if !date.knownYear {
+		//the next one to come that exists, which for feb 29 can be years off
+		date.year = local.Year()
+		for date.day > daysIn(date.month, date.year) || !time.Date(date.year, date.month, date.day, hour, minute, 0, 0, loc).After(now) {
+			date.year++
+		}
+	}
+	return time.Date(date.year, date.month, date.day, hour, minute, 0, 0, loc), i, nil
+}
@@ -0,0 +1,96 @@
+package timeparse
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDates(t *testing.T) {
+	now := time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"dec 24", time.Date(2023, time.December, 24, DefaultHour, 0, 0, 0, time.UTC)},
+		{"jan 31 at 18:00", time.Date(2024, time.January, 31, 18, 0, 0, 0, time.UTC)},
+		{"feb 29", time.Date(2024, time.February, 29, DefaultHour, 0, 0, 0, time.UTC)},
+		{"29 feb 2028", time.Date(2028, time.February, 29, DefaultHour, 0, 0, 0, time.UTC)},
+		{"apr 30", time.Date(2023, time.April, 30, DefaultHour, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		got, used, err := Parse(strings.Fields(test.in), now, time.UTC)
+		if err != nil || !got.Equal(test.want) || used != len(strings.Fields(test.in)) {
+			t.Errorf("Parse(%q) = %v, %d, %v, want %v", test.in, got, used, err, test.want)
+		}
+	}
+}
+
+func TestParseRejectsMissingDays(t *testing.T) {
+	now := time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+	for _, in := range []string{"feb 30", "30 feb", "feb 29 2023", "apr 31", "jan 32", "jan 0"} {
+		if got, _, err := Parse(strings.Fields(in), now, time.UTC); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", in, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	loc := time.FixedZone("UTC-6", -6*60*60)
+	now := time.Date(2023, time.March, 10, 20, 0, 0, 0, time.UTC) //Friday, 14:00 in loc
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2023, month, day, hour, minute, 0, 0, loc)
+	}
+	tests := []struct {
+		in   string
+		want time.Time
+		used int
+	}{
+		//days, with and without a time
+		{"tomorrow at 5pm", at(time.March, 11, 17, 0), 3},
+		{"tomorrow at 5 pm to stretch", at(time.March, 11, 17, 0), 4},
+		{"5pm tomorrow", at(time.March, 11, 17, 0), 2},
+		{"tomorrow", at(time.March, 11, DefaultHour, 0), 1},
+		{"today at 18:30", at(time.March, 10, 18, 30), 3},
+		{"next friday", at(time.March, 17, DefaultHour, 0), 2},
+		{"friday at 8am", at(time.March, 17, 8, 0), 3},
+		{"this monday", at(time.March, 13, DefaultHour, 0), 2},
+		{"on monday at noon", at(time.March, 13, 12, 0), 4},
+		{"on 2023-12-24", at(time.December, 24, DefaultHour, 0), 2},
+
+		//times of day, rolling over to tomorrow once they've passed
+		{"at 18:30", at(time.March, 10, 18, 30), 2},
+		{"at 14:01", at(time.March, 10, 14, 1), 2},
+		{"at 14:00", at(time.March, 11, 14, 0), 2},
+		{"at 9:00", at(time.March, 11, 9, 0), 2},
+		{"midnight", at(time.March, 11, 0, 0), 1},
+
+		//ISO-8601, in loc unless they have an offset
+		{"2024-12-24T18:30:00+01:00", time.Date(2024, time.December, 24, 17, 30, 0, 0, time.UTC), 1},
+		{"at 2024-12-24T18:30:00Z", time.Date(2024, time.December, 24, 18, 30, 0, 0, time.UTC), 2},
+		{"2024-12-24T18:30", time.Date(2024, time.December, 24, 18, 30, 0, 0, loc), 1},
+		{"2023-03-11 08:00", at(time.March, 11, 8, 0), 2},
+		{"2023-03-11 08:00:00 -0700", time.Date(2023, time.March, 11, 15, 0, 0, 0, time.UTC), 3},
+
+		//durations from now
+		{"90m", now.Add(90 * time.Minute), 1},
+		{"1h30m", now.Add(90 * time.Minute), 1},
+		{"in 2 hours and 30 minutes", now.Add(150 * time.Minute), 6},
+		{"1d", now.AddDate(0, 0, 1), 1},
+	}
+	for _, test := range tests {
+		got, used, err := Parse(strings.Fields(test.in), now, loc)
+		if err != nil || !got.Equal(test.want) || used != test.used {
+			t.Errorf("Parse(%q) = %v, %d, %v, want %v, %d", test.in, got, used, err, test.want, test.used)
+		}
+	}
+}
+
+func TestParseRejectsGarbage(t *testing.T) {
+	now := time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+	for _, in := range []string{"", "soon", "at", "next", "next week", "25:00", "13pm", "0am", "12:60", "17", "h30m", "2024-13-01T00:00", "to stretch at 5pm"} {
+		if got, used, err := Parse(strings.Fields(in), now, time.UTC); err != ErrNoTime {
+			t.Errorf("Parse(%q) = %v, %d, %v, want ErrNoTime", in, got, used, err)
+		}
+	}
+}